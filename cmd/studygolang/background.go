@@ -50,6 +50,17 @@ func ServeBackGround() {
 	c := cron.New()
 
 	if config.ConfigFile.MustBool("global", "is_master", false) {
+		// 补齐升级后新增的索引，已有的不会重复创建
+		go logic.DefaultInstall.EnsureIndexes()
+		// 补齐升级后新增的权限，已有的不会重复添加
+		go logic.DefaultInstall.UpgradeAuthority()
+		// 旧的 Redis IP 黑名单导入库中，已导入的会从旧黑名单删除
+		go logic.DefaultRisk.UpgradeBlackIP()
+		// 旧的收藏归入默认收藏夹，已升级的不会再处理
+		go logic.DefaultFavorite.Upgrade()
+		// 老评论按回复的楼层迁移到评论树，已迁移的不会再处理
+		go logic.DefaultComment.UpgradeTree()
+
 		// 每天对非活跃用户降频
 		c.AddFunc("@daily", decrUserActiveWeight)
 
@@ -70,6 +81,12 @@ func ServeBackGround() {
 		// 取消置顶
 		c.AddFunc("0 * * * * *", unsetTop)
 
+		// 定时发布到时间的主题和文章
+		c.AddFunc("0 * * * * *", logic.DefaultSchedule.PublishDue)
+
+		// 结算到期的悬赏
+		c.AddFunc("@every 5m", logic.DefaultBounty.SettleExpired)
+
 		// 每天对活跃用户奖励铜币
 		c.AddFunc("@daily", logic.DefaultUserRich.AwardCooper)
 
@@ -1,6 +1,6 @@
 module github.com/studygolang/studygolang
 
-go 1.16
+go 1.18
 
 require (
 	code.gitea.io/sdk/gitea v0.0.0-20191106151626-e4082d89cc3b
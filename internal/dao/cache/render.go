@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/polaris1119/nosql"
+	"github.com/studygolang/studygolang/internal/model"
+)
+
+// 渲染结果只和内容有关，缓存时间可以长一些
+const renderExpire = 7 * 86400
+
+type renderCache struct{}
+
+var Render renderCache
+
+func (renderCache) Get(ctx context.Context, hash string) *model.Rendered {
+	redisClient := nosql.NewRedisClient()
+	defer redisClient.Close()
+
+	s := redisClient.GET("render:" + hash)
+	if s == "" {
+		return nil
+	}
+
+	rendered := &model.Rendered{}
+	err := json.Unmarshal([]byte(s), rendered)
+	if err != nil {
+		return nil
+	}
+
+	return rendered
+}
+
+func (renderCache) Set(ctx context.Context, hash string, rendered *model.Rendered) {
+	redisClient := nosql.NewRedisClient()
+	defer redisClient.Close()
+
+	b, _ := json.Marshal(rendered)
+	redisClient.SET("render:"+hash, string(b), renderExpire)
+}
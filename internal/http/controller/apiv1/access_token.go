@@ -0,0 +1,62 @@
+package apiv1
+
+import (
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+	"github.com/studygolang/studygolang/internal/model"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+type AccessTokenController struct{}
+
+func (self AccessTokenController) RegisterRoute(g *echo.Group) {
+	g.GET("/user/tokens", self.List)
+	g.POST("/user/tokens", self.Create)
+	g.POST("/user/tokens/delete", self.Delete)
+	g.GET("/user/tokens/scopes", self.Scopes)
+}
+
+func (AccessTokenController) List(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	accessTokens := logic.DefaultAccessToken.FindByUid(context.EchoContext(ctx), meVal.Uid)
+	return success(ctx, map[string]interface{}{"list": accessTokens})
+}
+
+// Create 创建令牌；明文令牌只在这里返回一次
+func (AccessTokenController) Create(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	formParams, _ := ctx.FormParams()
+	token, accessToken, err := logic.DefaultAccessToken.Create(context.EchoContext(ctx), meVal, formParams)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{
+		"token":        token,
+		"access_token": accessToken,
+	})
+}
+
+func (AccessTokenController) Delete(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	id := goutils.MustInt(ctx.FormValue("id"))
+	err := logic.DefaultAccessToken.Revoke(context.EchoContext(ctx), meVal.Uid, id)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+func (AccessTokenController) Scopes(ctx echo.Context) error {
+	return success(ctx, model.AllScopes)
+}
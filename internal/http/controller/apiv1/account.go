@@ -1,40 +1,166 @@
 package apiv1
 
 import (
+	"errors"
+
 	"github.com/studygolang/studygolang/context"
 	. "github.com/studygolang/studygolang/internal/http"
+	"github.com/studygolang/studygolang/internal/http/middleware"
 	"github.com/studygolang/studygolang/internal/logic"
 	"github.com/studygolang/studygolang/internal/model"
+	"github.com/studygolang/studygolang/util"
 
+	"github.com/dchest/captcha"
 	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
 )
 
+// 登录需要验证码时返回的错误码
+const needCaptchaCode = 2
+
 type AccountController struct{}
 
 func (self AccountController) RegisterRoute(g *echo.Group) {
-	g.POST("/account/login", self.Login)
-	g.POST("/account/register", self.Register)
+	g.POST("/account/login", self.Login, middleware.RateLimit(logic.RateLimitLogin))
+	g.POST("/account/login/totp", self.LoginTotp, middleware.RateLimit(logic.RateLimitLogin))
+	g.POST("/account/login/totp/setup", self.LoginTotpSetup)
+	g.GET("/account/login/captcha", self.LoginCaptcha)
+	g.POST("/account/register", self.Register, middleware.RateLimit(logic.RateLimitRegister))
 	g.GET("/account/logout", self.Logout)
 	g.GET("/user/current", self.CurrentUser)
-	g.POST("/account/changepwd", self.ChangePwd)
+	g.POST("/account/changepwd", self.ChangePwd, middleware.NoAccessToken())
 	g.GET("/user/heartbeat", self.Heartbeat)
+	g.GET("/account/binds", self.Binds)
+	g.POST("/account/unbind", self.Unbind, middleware.NoAccessToken())
+	g.POST("/account/bind/avatar", self.BindAvatar)
 }
 
 func (AccountController) Login(ctx echo.Context) error {
 	username := ctx.FormValue("username")
 	passwd := ctx.FormValue("passwd")
 
+	ip := goutils.RemoteIp(Request(ctx))
+	needCaptcha, err := logic.DefaultLoginGuard.Check(context.EchoContext(ctx), username, ip)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	if needCaptcha {
+		captchaId := ctx.FormValue("captchaid")
+		if !captcha.VerifyString(captchaId, ctx.FormValue("captchaSolution")) {
+			util.SetCaptcha(captchaId)
+			return fail(ctx, "验证码错误，记得刷新验证码！", needCaptchaCode)
+		}
+	}
+
 	userLogin, err := logic.DefaultUser.Login(context.EchoContext(ctx), username, passwd)
 	if err != nil {
+		if logic.DefaultLoginGuard.IsFailError(err) {
+			logic.DefaultLoginGuard.RecordFail(context.EchoContext(ctx), username, ip)
+		}
 		return fail(ctx, err.Error())
 	}
+	logic.DefaultLoginGuard.RecordSuccess(context.EchoContext(ctx), username)
 
-	SetLoginCookie(ctx, userLogin.Username)
+	// 开启了（或被要求开启）两步验证，需要再提交验证码
+	if logic.DefaultTotp.NeedSecondStep(context.EchoContext(ctx), userLogin.Uid) {
+		ticket, err := logic.DefaultTotp.GenLoginTicket(userLogin.Username)
+		if err != nil {
+			return fail(ctx, "内部服务错误")
+		}
+		return success(ctx, map[string]interface{}{
+			"need_totp":    true,
+			"totp_enabled": logic.DefaultTotp.IsEnabled(context.EchoContext(ctx), userLogin.Uid),
+			"ticket":       ticket,
+		})
+	}
+
+	if err = SetLoginCookie(ctx, userLogin.Username); err != nil {
+		return fail(ctx, err.Error())
+	}
 
 	user := logic.DefaultUser.FindCurrentUser(context.EchoContext(ctx), userLogin.Username)
 	return success(ctx, user)
 }
 
+// LoginCaptcha 登录前查询是否需要验证码，需要时返回验证码 ID（图片地址为 /captcha/{captchaid}.png）
+func (AccountController) LoginCaptcha(ctx echo.Context) error {
+	ip := goutils.RemoteIp(Request(ctx))
+	needCaptcha, err := logic.DefaultLoginGuard.Check(context.EchoContext(ctx), ctx.QueryParam("username"), ip)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+
+	data := map[string]interface{}{"need_captcha": needCaptcha}
+	if needCaptcha {
+		data["captchaid"] = captcha.NewLen(util.CaptchaLen)
+	}
+	return success(ctx, data)
+}
+
+// LoginTotp 登录第二步：校验两步验证码（或恢复码）。
+// 被要求开启但尚未开启的用户，在这一步完成开启，并返回恢复码
+func (AccountController) LoginTotp(ctx echo.Context) error {
+	ticket := ctx.FormValue("ticket")
+	username, err := logic.DefaultTotp.CheckLoginTicket(ticket)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+
+	user := logic.DefaultUser.FindOne(context.EchoContext(ctx), "username", username)
+	if user.Uid == 0 {
+		return fail(ctx, "用户不存在")
+	}
+
+	if err = logic.DefaultLoginGuard.CheckTotp(context.EchoContext(ctx), user.Uid); err != nil {
+		return fail(ctx, err.Error())
+	}
+
+	var recoveryCodes []string
+	code := ctx.FormValue("code")
+	if logic.DefaultTotp.IsEnabled(context.EchoContext(ctx), user.Uid) {
+		err = logic.DefaultTotp.Verify(context.EchoContext(ctx), user.Uid, code)
+	} else {
+		recoveryCodes, err = logic.DefaultTotp.Enable(context.EchoContext(ctx), user.Uid, code)
+	}
+	if err != nil {
+		if errors.Is(err, logic.ErrTotpCode) {
+			ip := goutils.RemoteIp(Request(ctx))
+			logic.DefaultLoginGuard.RecordTotpFail(context.EchoContext(ctx), user.Uid, username, ip)
+		}
+		return fail(ctx, err.Error())
+	}
+
+	logic.DefaultTotp.DelLoginTicket(ticket)
+	if err = SetLoginCookie(ctx, username); err != nil {
+		return fail(ctx, err.Error())
+	}
+
+	me := logic.DefaultUser.FindCurrentUser(context.EchoContext(ctx), username)
+	return success(ctx, map[string]interface{}{
+		"user":           me,
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// LoginTotpSetup 被要求开启两步验证的用户，登录时获取密钥
+func (AccountController) LoginTotpSetup(ctx echo.Context) error {
+	username, err := logic.DefaultTotp.CheckLoginTicket(ctx.FormValue("ticket"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+
+	user := logic.DefaultUser.FindOne(context.EchoContext(ctx), "username", username)
+	if user.Uid == 0 {
+		return fail(ctx, "用户不存在")
+	}
+
+	secret, uri, err := logic.DefaultTotp.Setup(context.EchoContext(ctx), user.Uid, username)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{"secret": secret, "uri": uri})
+}
+
 func (AccountController) Register(ctx echo.Context) error {
 	formParams, _ := ctx.FormParams()
 	errMsg, err := logic.DefaultUser.CreateUser(context.EchoContext(ctx), formParams)
@@ -42,14 +168,14 @@ func (AccountController) Register(ctx echo.Context) error {
 		return fail(ctx, errMsg)
 	}
 
-	SetLoginCookie(ctx, ctx.FormValue("username"))
+	if err = SetLoginCookie(ctx, ctx.FormValue("username")); err != nil {
+		return fail(ctx, err.Error())
+	}
 	return success(ctx, nil)
 }
 
 func (AccountController) Logout(ctx echo.Context) error {
-	session := GetCookieSession(ctx)
-	session.Options.MaxAge = -1
-	session.Save(Request(ctx), ResponseWriter(ctx))
+	DelLoginCookie(ctx)
 	return success(ctx, nil)
 }
 
@@ -88,5 +214,50 @@ func (AccountController) ChangePwd(ctx echo.Context) error {
 	if err != nil {
 		return fail(ctx, errMsg)
 	}
+
+	// 修改密码会注销所有会话，当前设备重新登录
+	if err = SetLoginCookie(ctx, me.Username); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Binds 已绑定的第三方账号
+func (AccountController) Binds(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	bindUsers := logic.DefaultUser.FindBindUsers(context.EchoContext(ctx), meVal.Uid)
+	return success(ctx, map[string]interface{}{
+		"list":      bindUsers,
+		"providers": logic.OAuthProviders(),
+	})
+}
+
+func (AccountController) Unbind(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	bindId := goutils.MustInt(ctx.FormValue("bind_id"))
+	err := logic.DefaultThirdUser.UnBindUser(context.EchoContext(ctx), bindId, meVal)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// BindAvatar 使用第三方账号的头像
+func (AccountController) BindAvatar(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	bindId := goutils.MustInt(ctx.FormValue("bind_id"))
+	err := logic.DefaultThirdUser.ImportAvatar(context.EchoContext(ctx), bindId, meVal)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
 	return success(ctx, nil)
 }
@@ -42,8 +42,17 @@ func (ArticleController) Detail(ctx echo.Context) error {
 	if article.Id == 0 {
 		return fail(ctx, "文章不存在")
 	}
+	// 定时发布的文章，到时间前只有能编辑的人可见
+	if article.Status == model.ArticleStatusScheduled && !logic.CanEdit(context.EchoContext(ctx), me(ctx), article) {
+		return fail(ctx, "文章不存在")
+	}
 	logic.Views.Incr(Request(ctx), model.TypeArticle, id)
-	return success(ctx, map[string]interface{}{"article": article})
+	rendered := logic.DefaultRender.Article(context.EchoContext(ctx), article)
+	return success(ctx, map[string]interface{}{
+		"article":      article,
+		"content_html": rendered.HTML,
+		"toc":          rendered.Toc,
+	})
 }
 
 func (ArticleController) Create(ctx echo.Context) error {
@@ -54,7 +63,7 @@ func (ArticleController) Create(ctx echo.Context) error {
 	formParams, _ := ctx.FormParams()
 	_, err := logic.DefaultArticle.Publish(context.EchoContext(ctx), meVal, formParams)
 	if err != nil {
-		return fail(ctx, err.Error())
+		return failErr(ctx, err)
 	}
 	return success(ctx, nil)
 }
@@ -80,7 +89,7 @@ func (ArticleController) Modify(ctx echo.Context) error {
 	formParams, _ := ctx.FormParams()
 	errMsg, err := logic.DefaultArticle.Modify(context.EchoContext(ctx), meVal, formParams)
 	if err != nil {
-		return fail(ctx, errMsg)
+		return failErr(ctx, err, errMsg)
 	}
 	return success(ctx, nil)
 }
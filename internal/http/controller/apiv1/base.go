@@ -5,6 +5,7 @@ import (
 
 	"github.com/studygolang/studygolang/context"
 	"github.com/studygolang/studygolang/internal/logic"
+	"github.com/studygolang/studygolang/internal/model"
 
 	echo "github.com/labstack/echo/v4"
 	"github.com/polaris1119/logger"
@@ -36,3 +37,38 @@ func fail(ctx echo.Context, msg string, codes ...int) error {
 	}
 	return ctx.JSON(http.StatusOK, result)
 }
+
+// 发布策略拒绝时返回的错误码，data 中是结构化的拒绝原因
+const policyRejectCode = 3
+
+// failErr 输出错误。发布策略拒绝的，带上结构化的原因；否则使用 msgs 中的提示（没有则用 err 本身）
+func failErr(ctx echo.Context, err error, msgs ...string) error {
+	if rejection, ok := err.(*logic.PolicyRejection); ok {
+		return ctx.JSON(http.StatusOK, map[string]interface{}{
+			"code": policyRejectCode,
+			"msg":  rejection.Msg,
+			"data": rejection,
+		})
+	}
+
+	if len(msgs) > 0 && msgs[0] != "" {
+		return fail(ctx, msgs[0])
+	}
+	return fail(ctx, err.Error())
+}
+
+// checkWriteScope 同一接口可以操作多种内容（如草稿）时，路由上的权限范围不够，
+// 令牌请求需要再按内容类型检查写权限。没有权限时输出错误并返回 false
+func checkWriteScope(ctx echo.Context, objtype int) bool {
+	accessToken, ok := ctx.Get("access_token").(*model.AccessToken)
+	if !ok {
+		return true
+	}
+
+	scope := model.WriteScope(objtype)
+	if accessToken.HasScope(scope) {
+		return true
+	}
+	ctx.JSON(http.StatusForbidden, map[string]interface{}{"code": http.StatusForbidden, "msg": "令牌缺少权限：" + scope})
+	return false
+}
@@ -39,7 +39,11 @@ func (BookController) Detail(ctx echo.Context) error {
 		return fail(ctx, "图书不存在")
 	}
 	logic.Views.Incr(Request(ctx), model.TypeBook, id)
-	return success(ctx, map[string]interface{}{"book": book})
+	return success(ctx, map[string]interface{}{
+		"book":           book,
+		"desc_html":      logic.DefaultRender.Markdown(context.EchoContext(ctx), book.Desc).HTML,
+		"catalogue_html": logic.DefaultRender.Markdown(context.EchoContext(ctx), book.Catalogue).HTML,
+	})
 }
 
 func (BookController) Create(ctx echo.Context) error {
@@ -50,19 +54,12 @@ func (BookController) Create(ctx echo.Context) error {
 	formParams, _ := ctx.FormParams()
 	err := logic.DefaultGoBook.Publish(context.EchoContext(ctx), meVal, formParams)
 	if err != nil {
-		return fail(ctx, err.Error())
+		return failErr(ctx, err)
 	}
 	return success(ctx, nil)
 }
 
 func (BookController) Delete(ctx echo.Context) error {
-	meVal := me(ctx)
-	if meVal.Uid == 0 {
-		return fail(ctx, "请先登录")
-	}
-	if !meVal.IsRoot {
-		return fail(ctx, "无权操作")
-	}
 	id := goutils.MustInt(ctx.FormValue("id"))
 	err := logic.DefaultGoBook.Delete(context.EchoContext(ctx), id)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"net/url"
 
 	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/http/middleware"
 	"github.com/studygolang/studygolang/internal/logic"
 
 	echo "github.com/labstack/echo/v4"
@@ -14,7 +15,8 @@ type CommentController struct{}
 
 func (self CommentController) RegisterRoute(g *echo.Group) {
 	g.GET("/object/comments", self.CommentList)
-	g.POST("/comment/:objid", self.Create)
+	g.GET("/comment/tree", self.Tree)
+	g.POST("/comment/:objid", self.Create, middleware.RateLimit(logic.RateLimitComment))
 	g.POST("/comment/delete", self.Delete)
 	g.GET("/at/users", self.AtUsers)
 }
@@ -22,6 +24,10 @@ func (self CommentController) RegisterRoute(g *echo.Group) {
 func (CommentController) CommentList(ctx echo.Context) error {
 	objid := goutils.MustInt(ctx.QueryParam("objid"))
 	objtype := goutils.MustInt(ctx.QueryParam("objtype"))
+	if !logic.CanViewObject(context.EchoContext(ctx), objtype, objid) {
+		return fail(ctx, "评论不存在")
+	}
+
 	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
 	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
 	comments := logic.DefaultComment.FindAll(context.EchoContext(ctx), paginator, "", "objid=? AND objtype=?", objid, objtype)
@@ -35,6 +41,30 @@ func (CommentController) CommentList(ctx echo.Context) error {
 	})
 }
 
+// Tree 对象的评论树。parent 为 0 时分页的是顶层评论，否则为该评论下的回复（加载更多、继续这个讨论）；
+// sort 为 oldest（默认）、newest 或 liked；depth 为展开的层数
+func (CommentController) Tree(ctx echo.Context) error {
+	objid := goutils.MustInt(ctx.QueryParam("objid"))
+	objtype := goutils.MustInt(ctx.QueryParam("objtype"))
+	parentCid := goutils.MustInt(ctx.QueryParam("parent"))
+	depth := goutils.MustInt(ctx.QueryParam("depth"))
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+
+	paginator := logic.NewPaginatorWithPerPage(curPage, logic.CommentTreePerNum)
+	nodes, parent, err := logic.DefaultComment.FindTree(context.EchoContext(ctx), objid, objtype, parentCid, ctx.QueryParam("sort"), depth, paginator)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{
+		"list":     nodes,
+		"parent":   parent,
+		"total":    paginator.GetTotal(),
+		"page":     curPage,
+		"per_page": logic.CommentTreePerNum,
+	})
+}
+
+// Create 发表评论，回复某条评论时带上 parent_cid
 func (CommentController) Create(ctx echo.Context) error {
 	meVal := me(ctx)
 	if meVal.Uid == 0 {
@@ -48,9 +78,10 @@ func (CommentController) Create(ctx echo.Context) error {
 	form := url.Values{}
 	form.Set("objtype", ctx.FormValue("objtype"))
 	form.Set("content", content)
+	form.Set("parent_cid", ctx.FormValue("parent_cid"))
 	_, err := logic.DefaultComment.Publish(context.EchoContext(ctx), meVal.Uid, objid, form)
 	if err != nil {
-		return fail(ctx, err.Error())
+		return failErr(ctx, err)
 	}
 	return success(ctx, nil)
 }
@@ -0,0 +1,115 @@
+package apiv1
+
+import (
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+type DraftController struct{}
+
+func (self DraftController) RegisterRoute(g *echo.Group) {
+	g.GET("/drafts", self.List)
+	g.GET("/draft", self.Detail)
+	g.POST("/draft/save", self.Save)
+	g.POST("/draft/delete", self.Delete)
+	g.POST("/draft/publish", self.Publish)
+}
+
+// List 我的草稿，objtype 可选
+func (DraftController) List(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
+	drafts := logic.DefaultDraft.FindMine(context.EchoContext(ctx), meVal, goutils.MustInt(ctx.QueryParam("objtype")), paginator)
+	return success(ctx, map[string]interface{}{
+		"list":  drafts,
+		"total": paginator.GetTotal(),
+		"page":  curPage,
+	})
+}
+
+// Detail 继续编辑草稿：按 id，或者按 objtype、objid 获取修改已有内容的草稿
+func (DraftController) Detail(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	id := goutils.MustInt(ctx.QueryParam("id"))
+	objtype := goutils.MustInt(ctx.QueryParam("objtype"))
+	objid := goutils.MustInt(ctx.QueryParam("objid"))
+	draft, err := logic.DefaultDraft.FindOne(context.EchoContext(ctx), meVal, id, objtype, objid)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, draft)
+}
+
+// Save 自动保存。首次保存新内容时 id 传 0，之后传返回的 id；修改已有内容时传 objid
+func (DraftController) Save(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	formParams, err := ctx.FormParams()
+	if err != nil {
+		return fail(ctx, "参数解析失败")
+	}
+	id := goutils.MustInt(formParams.Get("id"))
+	objtype := goutils.MustInt(formParams.Get("objtype"))
+	objid := goutils.MustInt(formParams.Get("objid"))
+
+	draft, err := logic.DefaultDraft.Save(context.EchoContext(ctx), meVal, id, objtype, objid, formParams)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, draft)
+}
+
+// Delete 丢弃草稿
+func (DraftController) Delete(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	if err := logic.DefaultDraft.Delete(context.EchoContext(ctx), meVal, goutils.MustInt(ctx.FormValue("id"))); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Publish 从草稿发布，和直接发布一样经过发布策略检查，需要验证码时带上 captchaid、captchaSolution
+func (DraftController) Publish(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	formParams, err := ctx.FormParams()
+	if err != nil {
+		return fail(ctx, "参数解析失败")
+	}
+	id := goutils.MustInt(formParams.Get("id"))
+	draft, err := logic.DefaultDraft.FindOne(context.EchoContext(ctx), meVal, id, 0, 0)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	if !checkWriteScope(ctx, draft.Objtype) {
+		return nil
+	}
+
+	objid, err := logic.DefaultDraft.Publish(context.EchoContext(ctx), meVal, id, formParams)
+	if err != nil {
+		return failErr(ctx, err)
+	}
+	return success(ctx, map[string]interface{}{"objid": objid})
+}
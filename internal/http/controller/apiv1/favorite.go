@@ -0,0 +1,189 @@
+package apiv1
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+	"github.com/studygolang/studygolang/internal/model"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+// FavoriteController 收藏夹。收藏、取消收藏见 InteractController
+type FavoriteController struct{}
+
+func (self FavoriteController) RegisterRoute(g *echo.Group) {
+	g.GET("/favorites", self.ReadList)
+	g.GET("/favorite/folders", self.Folders)
+	g.GET("/favorite/folder", self.Folder)
+	g.POST("/favorite/folder/new", self.CreateFolder)
+	g.POST("/favorite/folder/modify", self.ModifyFolder)
+	g.POST("/favorite/folder/delete", self.DeleteFolder)
+	g.POST("/favorite/move", self.Move)
+	g.POST("/favorite/note", self.Note)
+	g.GET("/favorite/export", self.Export)
+}
+
+// ReadList 我的收藏。folder_id 可选，默认所有收藏夹；q 在标题和备注中搜索
+func (FavoriteController) ReadList(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+	folderId := goutils.MustInt(ctx.QueryParam("folder_id"), -1)
+	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
+	favorites := logic.DefaultFavorite.FindFavorites(context.EchoContext(ctx), meVal.Uid, folderId, ctx.QueryParam("q"), paginator)
+	return success(ctx, map[string]interface{}{
+		"list":     favorites,
+		"total":    paginator.GetTotal(),
+		"page":     curPage,
+		"per_page": perPage,
+	})
+}
+
+// Folders 收藏夹列表。传 uid 时为该用户公开的收藏夹，否则为我的所有收藏夹
+func (FavoriteController) Folders(ctx echo.Context) error {
+	meVal := me(ctx)
+	uid := goutils.MustInt(ctx.QueryParam("uid"))
+	if uid == 0 || uid == meVal.Uid {
+		if meVal.Uid == 0 {
+			return fail(ctx, "请先登录")
+		}
+		folders := logic.DefaultFavorite.FindFolders(context.EchoContext(ctx), meVal.Uid, false)
+		return success(ctx, map[string]interface{}{"list": folders})
+	}
+
+	folders := logic.DefaultFavorite.FindFolders(context.EchoContext(ctx), uid, true)
+	return success(ctx, map[string]interface{}{"list": folders})
+}
+
+// Folder 收藏夹页面：收藏夹及其中的收藏。公开的收藏夹所有人可看
+func (FavoriteController) Folder(ctx echo.Context) error {
+	meVal := me(ctx)
+	folder, err := logic.DefaultFavorite.FindFolder(context.EchoContext(ctx), meVal.Uid, goutils.MustInt(ctx.QueryParam("id")))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
+	favorites := logic.DefaultFavorite.FindFavorites(context.EchoContext(ctx), folder.Uid, folder.Id, "", paginator)
+	return success(ctx, map[string]interface{}{
+		"folder":   folder,
+		"user":     logic.DefaultUser.FindOne(context.EchoContext(ctx), "_id", folder.Uid),
+		"list":     favorites,
+		"total":    paginator.GetTotal(),
+		"page":     curPage,
+		"per_page": perPage,
+	})
+}
+
+// CreateFolder 新建收藏夹，public 是否公开
+func (FavoriteController) CreateFolder(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	public := goutils.MustBool(ctx.FormValue("public"))
+	folder, err := logic.DefaultFavorite.CreateFolder(context.EchoContext(ctx), meVal.Uid, ctx.FormValue("name"), ctx.FormValue("intro"), public)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{"folder": folder})
+}
+
+// ModifyFolder 修改收藏夹 id 的名称、介绍和是否公开
+func (FavoriteController) ModifyFolder(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	id := goutils.MustInt(ctx.FormValue("id"))
+	public := goutils.MustBool(ctx.FormValue("public"))
+	err := logic.DefaultFavorite.ModifyFolder(context.EchoContext(ctx), meVal.Uid, id, ctx.FormValue("name"), ctx.FormValue("intro"), public)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// DeleteFolder 删除收藏夹 id，其中的收藏移到默认收藏夹
+func (FavoriteController) DeleteFolder(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	if err := logic.DefaultFavorite.DeleteFolder(context.EchoContext(ctx), meVal.Uid, goutils.MustInt(ctx.FormValue("id"))); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Move 把收藏移到收藏夹 folder_id。items 为逗号分隔的 objtype:objid
+func (FavoriteController) Move(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	items := make([]*model.Favorite, 0)
+	for _, item := range strings.Split(ctx.FormValue("items"), ",") {
+		pair := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(pair) != 2 {
+			continue
+		}
+		items = append(items, &model.Favorite{Objtype: goutils.MustInt(pair[0]), Objid: goutils.MustInt(pair[1])})
+	}
+
+	folderId := goutils.MustInt(ctx.FormValue("folder_id"))
+	num, err := logic.DefaultFavorite.Move(context.EchoContext(ctx), meVal.Uid, folderId, items)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{"num": num})
+}
+
+// Note 修改收藏的备注
+func (FavoriteController) Note(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	objtype := goutils.MustInt(ctx.FormValue("objtype"))
+	objid := goutils.MustInt(ctx.FormValue("objid"))
+	if err := logic.DefaultFavorite.SetNote(context.EchoContext(ctx), meVal.Uid, objtype, objid, ctx.FormValue("note")); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Export 下载我的收藏。format 为 html（Netscape 书签格式，浏览器可导入，默认）或 json；
+// folder_id 可选，默认所有收藏夹
+func (FavoriteController) Export(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	folderId := goutils.MustInt(ctx.QueryParam("folder_id"), -1)
+	folders, err := logic.DefaultFavorite.Export(context.EchoContext(ctx), meVal.Uid, folderId)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+
+	if ctx.QueryParam("format") == "json" {
+		ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="favorites.json"`)
+		return ctx.JSON(http.StatusOK, folders)
+	}
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="favorites.html"`)
+	return ctx.Blob(http.StatusOK, echo.MIMETextHTMLCharsetUTF8, logic.DefaultFavorite.ExportHTML(folders))
+}
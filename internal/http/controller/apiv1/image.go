@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/http/middleware"
 	"github.com/studygolang/studygolang/internal/logic"
 	"github.com/studygolang/studygolang/internal/model"
 
@@ -16,7 +17,7 @@ import (
 type ImageController struct{}
 
 func (self ImageController) RegisterRoute(g *echo.Group) {
-	g.POST("/image/upload", self.Upload)
+	g.POST("/image/upload", self.Upload, middleware.RateLimit(logic.RateLimitUpload))
 }
 
 func (ImageController) Upload(ctx echo.Context) error {
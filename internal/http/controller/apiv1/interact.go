@@ -2,6 +2,7 @@ package apiv1
 
 import (
 	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/http/middleware"
 	"github.com/studygolang/studygolang/internal/logic"
 	"github.com/studygolang/studygolang/internal/model"
 
@@ -12,7 +13,7 @@ import (
 type InteractController struct{}
 
 func (self InteractController) RegisterRoute(g *echo.Group) {
-	g.POST("/like/:objid", self.Like)
+	g.POST("/like/:objid", self.Like, middleware.RateLimit(logic.RateLimitLike))
 	g.GET("/like/:objid", self.HadLike)
 	g.POST("/favorite/:objid", self.Favorite)
 	g.GET("/favorite/:objid", self.HadFavorite)
@@ -70,7 +71,9 @@ func (InteractController) Favorite(ctx echo.Context) error {
 		return success(ctx, map[string]interface{}{"favorited": false})
 	}
 
-	err := logic.DefaultFavorite.Save(context.EchoContext(ctx), meVal.Uid, objid, objtype)
+	// folder_id 不传时收藏到默认收藏夹
+	folderId := goutils.MustInt(ctx.FormValue("folder_id"))
+	err := logic.DefaultFavorite.Save(context.EchoContext(ctx), meVal.Uid, objid, objtype, folderId, ctx.FormValue("note"))
 	if err != nil {
 		return fail(ctx, err.Error())
 	}
@@ -2,6 +2,7 @@ package apiv1
 
 import (
 	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/http/middleware"
 	"github.com/studygolang/studygolang/internal/logic"
 	"github.com/studygolang/studygolang/internal/model"
 
@@ -15,7 +16,7 @@ func (self MessageController) RegisterRoute(g *echo.Group) {
 	g.GET("/message/system", self.SysMsgList)
 	g.GET("/message/inbox", self.InboxList)
 	g.GET("/message/outbox", self.OutboxList)
-	g.POST("/message/send", self.Send)
+	g.POST("/message/send", self.Send, middleware.RateLimit(logic.RateLimitMessage))
 	g.POST("/message/delete", self.Delete)
 }
 
@@ -0,0 +1,61 @@
+package apiv1
+
+import (
+	"strings"
+
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+	"github.com/studygolang/studygolang/internal/model"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+type ModerationController struct{}
+
+func (self ModerationController) RegisterRoute(g *echo.Group) {
+	g.GET("/moderation/items", self.List, adminScope)
+	g.POST("/moderation/approve", self.Approve, adminScope)
+	g.POST("/moderation/reject", self.Reject, adminScope)
+}
+
+// List 审核队列。state 默认待审核，-1 表示全部；source、objtype 可选
+func (ModerationController) List(ctx echo.Context) error {
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+	state := goutils.MustInt(ctx.QueryParam("state"), model.ModerationStatePending)
+	objtype := goutils.MustInt(ctx.QueryParam("objtype"), -1)
+
+	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
+	items := logic.DefaultModeration.FindAll(context.EchoContext(ctx), paginator, state, ctx.QueryParam("source"), objtype)
+	return success(ctx, map[string]interface{}{
+		"list":    items,
+		"total":   paginator.GetTotal(),
+		"page":    curPage,
+		"sources": model.ModerationSourceMap,
+	})
+}
+
+// Approve 审核通过，ids 为逗号分隔的多个 id
+func (self ModerationController) Approve(ctx echo.Context) error {
+	return self.audit(ctx, true)
+}
+
+// Reject 审核拒绝，reason 必填，会以系统消息发给作者
+func (self ModerationController) Reject(ctx echo.Context) error {
+	return self.audit(ctx, false)
+}
+
+func (ModerationController) audit(ctx echo.Context, approve bool) error {
+	ids := make([]int, 0)
+	for _, id := range strings.Split(ctx.FormValue("ids"), ",") {
+		if id := goutils.MustInt(strings.TrimSpace(id)); id > 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	num, err := logic.DefaultModeration.Audit(context.EchoContext(ctx), me(ctx), ids, approve, strings.TrimSpace(ctx.FormValue("reason")))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{"num": num})
+}
@@ -0,0 +1,137 @@
+package apiv1
+
+import (
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+type ModeratorController struct{}
+
+func (self ModeratorController) RegisterRoute(g *echo.Group) {
+	g.GET("/node/:nid/moderators", self.NodeModerators)
+	g.POST("/node/moderator/assign", self.Assign, adminScope)
+	g.POST("/node/moderator/revoke", self.Revoke, adminScope)
+	g.GET("/moderator/nodes", self.MyNodes)
+	g.GET("/moderator/logs", self.Logs)
+	g.POST("/moderator/topic/move", self.MoveTopic)
+	g.POST("/moderator/topic/lock", self.LockTopic)
+	g.POST("/moderator/topic/top", self.NodeTop)
+	g.POST("/moderator/topic/delete", self.DeleteTopic)
+	g.POST("/moderator/comment/delete", self.DeleteComment)
+}
+
+// NodeModerators 节点的版主列表
+func (ModeratorController) NodeModerators(ctx echo.Context) error {
+	nid := goutils.MustInt(ctx.Param("nid"))
+	moderators := logic.DefaultNodeModerator.FindByNid(context.EchoContext(ctx), nid)
+	return success(ctx, map[string]interface{}{"list": moderators})
+}
+
+// Assign 任命版主
+func (ModeratorController) Assign(ctx echo.Context) error {
+	nid := goutils.MustInt(ctx.FormValue("nid"))
+	err := logic.DefaultNodeModerator.Assign(context.EchoContext(ctx), me(ctx), nid, ctx.FormValue("username"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Revoke 撤销版主
+func (ModeratorController) Revoke(ctx echo.Context) error {
+	nid := goutils.MustInt(ctx.FormValue("nid"))
+	uid := goutils.MustInt(ctx.FormValue("uid"))
+	err := logic.DefaultNodeModerator.Revoke(context.EchoContext(ctx), me(ctx), nid, uid)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// MyNodes 我担任版主的节点
+func (ModeratorController) MyNodes(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	moderators := logic.DefaultNodeModerator.FindByUid(context.EchoContext(ctx), meVal.Uid)
+	return success(ctx, map[string]interface{}{"list": moderators})
+}
+
+// Logs 版主操作记录。版主只能查看自己管理的节点，nid 为空时只有管理员可以查看全部
+func (ModeratorController) Logs(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	nid := goutils.MustInt(ctx.QueryParam("nid"))
+	if !logic.DefaultNodeModerator.CanModerate(context.EchoContext(ctx), meVal, nid) {
+		return fail(ctx, "无权查看")
+	}
+
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
+	logs := logic.DefaultNodeModerator.FindLogs(context.EchoContext(ctx), nid, paginator)
+	return success(ctx, map[string]interface{}{
+		"list":  logs,
+		"total": paginator.GetTotal(),
+		"page":  curPage,
+	})
+}
+
+// MoveTopic 移动主题到其他节点
+func (ModeratorController) MoveTopic(ctx echo.Context) error {
+	tid := goutils.MustInt(ctx.FormValue("tid"))
+	nid := goutils.MustInt(ctx.FormValue("nid"))
+	err := logic.DefaultNodeModerator.MoveTopic(context.EchoContext(ctx), me(ctx), tid, nid, ctx.FormValue("reason"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// LockTopic 锁定（lock=1）或解锁主题
+func (ModeratorController) LockTopic(ctx echo.Context) error {
+	tid := goutils.MustInt(ctx.FormValue("tid"))
+	lock := ctx.FormValue("lock") == "1"
+	err := logic.DefaultNodeModerator.LockTopic(context.EchoContext(ctx), me(ctx), tid, lock, ctx.FormValue("reason"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// NodeTop 节点内置顶（top=1）或取消置顶
+func (ModeratorController) NodeTop(ctx echo.Context) error {
+	tid := goutils.MustInt(ctx.FormValue("tid"))
+	top := ctx.FormValue("top") == "1"
+	err := logic.DefaultNodeModerator.SetNodeTop(context.EchoContext(ctx), me(ctx), tid, top, ctx.FormValue("reason"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// DeleteTopic 删除主题
+func (ModeratorController) DeleteTopic(ctx echo.Context) error {
+	tid := goutils.MustInt(ctx.FormValue("tid"))
+	err := logic.DefaultNodeModerator.DeleteTopic(context.EchoContext(ctx), me(ctx), tid, ctx.FormValue("reason"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// DeleteComment 删除主题下的评论
+func (ModeratorController) DeleteComment(ctx echo.Context) error {
+	cid := goutils.MustInt(ctx.FormValue("cid"))
+	err := logic.DefaultNodeModerator.DeleteComment(context.EchoContext(ctx), me(ctx), cid, ctx.FormValue("reason"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
@@ -0,0 +1,51 @@
+package apiv1
+
+import (
+	"strings"
+
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+// PollController 主题中的投票。发布主题时带上 poll_options（每行一个选项）等字段即创建投票
+type PollController struct{}
+
+func (self PollController) RegisterRoute(g *echo.Group) {
+	g.GET("/poll", self.Detail)
+	g.POST("/poll/vote", self.Vote)
+}
+
+// Detail 主题 tid 的投票及结果（结果投票后可见的，没投票时不返回票数）
+func (PollController) Detail(ctx echo.Context) error {
+	tid := goutils.MustInt(ctx.QueryParam("tid"))
+	poll := logic.DefaultPoll.FindByTid(context.EchoContext(ctx), me(ctx), tid)
+	if poll == nil {
+		return fail(ctx, "投票不存在")
+	}
+	return success(ctx, map[string]interface{}{"poll": poll})
+}
+
+// Vote 投票或改票，choices 为逗号分隔的选项 id
+func (PollController) Vote(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	choices := make([]int, 0)
+	for _, choice := range strings.Split(ctx.FormValue("choices"), ",") {
+		if choice = strings.TrimSpace(choice); choice != "" {
+			choices = append(choices, goutils.MustInt(choice, -1))
+		}
+	}
+
+	pollID := goutils.MustInt(ctx.FormValue("poll_id"))
+	poll, err := logic.DefaultPoll.Vote(context.EchoContext(ctx), meVal, pollID, choices)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{"poll": poll})
+}
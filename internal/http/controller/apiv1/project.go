@@ -39,7 +39,10 @@ func (ProjectController) Detail(ctx echo.Context) error {
 		return fail(ctx, "项目不存在")
 	}
 	logic.Views.Incr(Request(ctx), model.TypeProject, project.Id)
-	return success(ctx, map[string]interface{}{"project": project})
+	return success(ctx, map[string]interface{}{
+		"project":   project,
+		"desc_html": logic.DefaultRender.Markdown(context.EchoContext(ctx), project.Desc).HTML,
+	})
 }
 
 func (ProjectController) Delete(ctx echo.Context) error {
@@ -63,7 +66,7 @@ func (ProjectController) Create(ctx echo.Context) error {
 	formParams, _ := ctx.FormParams()
 	err := logic.DefaultProject.Publish(context.EchoContext(ctx), meVal, formParams)
 	if err != nil {
-		return fail(ctx, err.Error())
+		return failErr(ctx, err)
 	}
 	return success(ctx, nil)
 }
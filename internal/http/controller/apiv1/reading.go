@@ -36,5 +36,8 @@ func (ReadingController) Detail(ctx echo.Context) error {
 	if reading == nil || reading.Id == 0 {
 		return fail(ctx, "晨读不存在")
 	}
-	return success(ctx, map[string]interface{}{"reading": reading})
+	return success(ctx, map[string]interface{}{
+		"reading":      reading,
+		"content_html": logic.DefaultRender.Markdown(context.EchoContext(ctx), reading.Content).HTML,
+	})
 }
@@ -0,0 +1,57 @@
+package apiv1
+
+import (
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+	"github.com/studygolang/studygolang/internal/model"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+type ReportController struct{}
+
+func (self ReportController) RegisterRoute(g *echo.Group) {
+	g.GET("/report/reasons", self.Reasons)
+	g.POST("/report", self.Report)
+	g.GET("/reports", self.List, adminScope)
+}
+
+// Reasons 举报原因
+func (ReportController) Reasons(ctx echo.Context) error {
+	return success(ctx, map[string]interface{}{"reasons": model.ReportReasonMap})
+}
+
+// Report 举报主题、评论、博文或收到的私信
+func (ReportController) Report(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	objtype := goutils.MustInt(ctx.FormValue("objtype"))
+	objid := goutils.MustInt(ctx.FormValue("objid"))
+	if objid == 0 {
+		return fail(ctx, "参数错误")
+	}
+
+	err := logic.DefaultReport.Report(context.EchoContext(ctx), meVal, objtype, objid, ctx.FormValue("reason"), ctx.FormValue("remark"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// List 某个内容未处理的举报，供审核时参考
+func (ReportController) List(ctx echo.Context) error {
+	objtype := goutils.MustInt(ctx.QueryParam("objtype"))
+	objid := goutils.MustInt(ctx.QueryParam("objid"))
+	if objid == 0 {
+		return fail(ctx, "参数错误")
+	}
+
+	return success(ctx, map[string]interface{}{
+		"list":    logic.DefaultReport.FindByObject(context.EchoContext(ctx), objtype, objid),
+		"reasons": model.ReportReasonMap,
+	})
+}
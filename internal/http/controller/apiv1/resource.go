@@ -38,7 +38,11 @@ func (ResourceController) Detail(ctx echo.Context) error {
 		return fail(ctx, "资源不存在")
 	}
 	logic.Views.Incr(Request(ctx), model.TypeResource, id)
-	return success(ctx, map[string]interface{}{"resource": resource})
+	content, _ := resource["content"].(string)
+	return success(ctx, map[string]interface{}{
+		"resource":     resource,
+		"content_html": logic.DefaultRender.Markdown(context.EchoContext(ctx), content).HTML,
+	})
 }
 
 func (ResourceController) Delete(ctx echo.Context) error {
@@ -62,7 +66,7 @@ func (ResourceController) Create(ctx echo.Context) error {
 	formParams, _ := ctx.FormParams()
 	err := logic.DefaultResource.Publish(context.EchoContext(ctx), meVal, formParams)
 	if err != nil {
-		return fail(ctx, err.Error())
+		return failErr(ctx, err)
 	}
 	return success(ctx, nil)
 }
@@ -0,0 +1,87 @@
+package apiv1
+
+import (
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/http/middleware"
+	"github.com/studygolang/studygolang/internal/logic"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+type RevisionController struct{}
+
+func (self RevisionController) RegisterRoute(g *echo.Group) {
+	// 比较版本比较耗资源，限制频率
+	g.GET("/revisions", self.List, middleware.RateLimit(logic.RateLimitRevision))
+	g.GET("/revision", self.Detail, middleware.RateLimit(logic.RateLimitRevision))
+	g.GET("/revision/diff", self.Diff, middleware.RateLimit(logic.RateLimitRevision))
+	g.POST("/revision/rollback", self.Rollback)
+}
+
+// List 主题、文章或 wiki 的版本列表，objtype、objid 必填
+func (RevisionController) List(ctx echo.Context) error {
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+	objtype := goutils.MustInt(ctx.QueryParam("objtype"))
+	objid := goutils.MustInt(ctx.QueryParam("objid"))
+
+	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
+	revisions, err := logic.DefaultRevision.FindAll(context.EchoContext(ctx), me(ctx), objtype, objid, paginator)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{
+		"list":  revisions,
+		"total": paginator.GetTotal(),
+		"page":  curPage,
+	})
+}
+
+// Detail 某个版本的完整内容
+func (RevisionController) Detail(ctx echo.Context) error {
+	objtype := goutils.MustInt(ctx.QueryParam("objtype"))
+	objid := goutils.MustInt(ctx.QueryParam("objid"))
+	version := goutils.MustInt(ctx.QueryParam("version"))
+
+	revision, err := logic.DefaultRevision.FindOne(context.EchoContext(ctx), me(ctx), objtype, objid, version)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, revision)
+}
+
+// Diff 比较两个版本，mode=word 时按词比较，默认按行
+func (RevisionController) Diff(ctx echo.Context) error {
+	objtype := goutils.MustInt(ctx.QueryParam("objtype"))
+	objid := goutils.MustInt(ctx.QueryParam("objid"))
+	from := goutils.MustInt(ctx.QueryParam("from"))
+	to := goutils.MustInt(ctx.QueryParam("to"))
+
+	diff, err := logic.DefaultRevision.Diff(context.EchoContext(ctx), me(ctx), objtype, objid, from, to, ctx.QueryParam("mode"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, diff)
+}
+
+// Rollback 回滚到某个版本，需要有编辑权限
+func (RevisionController) Rollback(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	formParams, _ := ctx.FormParams()
+	objtype := goutils.MustInt(formParams.Get("objtype"))
+	objid := goutils.MustInt(formParams.Get("objid"))
+	version := goutils.MustInt(formParams.Get("version"))
+	if !checkWriteScope(ctx, objtype) {
+		return nil
+	}
+
+	err := logic.DefaultRevision.Rollback(context.EchoContext(ctx), meVal, objtype, objid, version, formParams)
+	if err != nil {
+		return failErr(ctx, err)
+	}
+	return success(ctx, nil)
+}
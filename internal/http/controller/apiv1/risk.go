@@ -0,0 +1,63 @@
+package apiv1
+
+import (
+	"time"
+
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+	"github.com/studygolang/studygolang/internal/model"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+type RiskController struct{}
+
+func (self RiskController) RegisterRoute(g *echo.Group) {
+	g.GET("/risk/ips", self.List, adminScope)
+	g.GET("/risk/ip/users", self.SeenUsers, adminScope)
+	g.POST("/risk/ip/add", self.Add, adminScope)
+	g.POST("/risk/ip/remove", self.Remove, adminScope)
+}
+
+// List IP 黑白名单。list 可选；kw 为 IP 或网段时查找包含它的条目，否则按网段、原因搜索
+func (RiskController) List(ctx echo.Context) error {
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
+	riskIPs := logic.DefaultRisk.FindAll(context.EchoContext(ctx), paginator, ctx.QueryParam("list"), ctx.QueryParam("kw"))
+	return success(ctx, map[string]interface{}{
+		"list":  riskIPs,
+		"total": paginator.GetTotal(),
+		"page":  curPage,
+		"lists": model.RiskListMap,
+	})
+}
+
+// SeenUsers 最近从某个 IP 或网段访问过的用户
+func (RiskController) SeenUsers(ctx echo.Context) error {
+	users, err := logic.DefaultRisk.FindSeenUsers(context.EchoContext(ctx), ctx.QueryParam("ip"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{"list": users})
+}
+
+// Add 加入黑名单或白名单，ip 可以是网段；ttl 为有效期（秒），0 表示永久
+func (RiskController) Add(ctx echo.Context) error {
+	ttl := time.Duration(goutils.MustInt(ctx.FormValue("ttl"))) * time.Second
+	err := logic.DefaultRisk.Add(context.EchoContext(ctx), me(ctx), ctx.FormValue("list"),
+		ctx.FormValue("ip"), ctx.FormValue("reason"), ttl)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Remove 从名单中移除
+func (RiskController) Remove(ctx echo.Context) error {
+	id := goutils.MustInt(ctx.FormValue("id"))
+	if err := logic.DefaultRisk.Remove(context.EchoContext(ctx), id); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
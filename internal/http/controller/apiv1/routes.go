@@ -1,23 +1,85 @@
 package apiv1
 
-import echo "github.com/labstack/echo/v4"
+import (
+	"github.com/studygolang/studygolang/internal/http/middleware"
+	"github.com/studygolang/studygolang/internal/model"
+
+	echo "github.com/labstack/echo/v4"
+)
 
 func RegisterRoutes(g *echo.Group) {
-	new(IndexController).RegisterRoute(g)
-	new(AccountController).RegisterRoute(g)
-	new(TopicController).RegisterRoute(g)
-	new(ArticleController).RegisterRoute(g)
-	new(ResourceController).RegisterRoute(g)
-	new(ProjectController).RegisterRoute(g)
-	new(BookController).RegisterRoute(g)
-	new(WikiController).RegisterRoute(g)
-	new(ReadingController).RegisterRoute(g)
-	new(UserController).RegisterRoute(g)
-	new(CommentController).RegisterRoute(g)
-	new(InteractController).RegisterRoute(g)
-	new(SidebarController).RegisterRoute(g)
-	new(SearchController).RegisterRoute(g)
-	new(MessageController).RegisterRoute(g)
-	new(MiscController).RegisterRoute(g)
-	new(ImageController).RegisterRoute(g)
+	g.Use(middleware.Permission(routeAuthorities))
+
+	new(IndexController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeRead))
+	new(AccountController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeUserWrite))
+	new(TopicController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeTopicsWrite))
+	new(ArticleController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeArticlesWrite))
+	new(ResourceController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeArticlesWrite))
+	new(ProjectController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeArticlesWrite))
+	new(BookController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeArticlesWrite))
+	new(WikiController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeArticlesWrite))
+	new(RevisionController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeArticlesWrite))
+	new(DraftController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeArticlesWrite))
+	new(ScheduleController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeArticlesWrite))
+	new(ModeratorController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeTopicsWrite))
+	new(TimelineController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeRead))
+	new(ReadingController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeRead))
+	new(UserController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeUserWrite))
+	new(CommentController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeCommentsWrite))
+	new(InteractController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeCommentsWrite))
+	new(FavoriteController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeCommentsWrite))
+	new(PollController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeCommentsWrite))
+	new(TagController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeCommentsWrite))
+	new(SidebarController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeRead))
+	new(SearchController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeRead))
+	new(MessageController).RegisterRoute(scoped(g, model.ScopeMessages, model.ScopeMessages))
+	new(MiscController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeUserWrite))
+	new(SensitiveController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeAdmin))
+	new(ModerationController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeAdmin))
+	new(ReportController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeCommentsWrite))
+	new(RiskController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeAdmin))
+	new(ImageController).RegisterRoute(scoped(g, model.ScopeRead, model.ScopeArticlesWrite))
+	new(AccessTokenController).RegisterRoute(g.Group("", middleware.NoAccessToken()))
+	new(SessionController).RegisterRoute(g.Group("", middleware.NoAccessToken()))
+	new(TotpController).RegisterRoute(g.Group("", middleware.NoAccessToken()))
+}
+
+// routePrefix v1 接口的路由前缀，和 main 中的 Group 一致
+const routePrefix = "/api/v1"
+
+// routeAuthorities v1 接口（方法 + 路由）对应的权限，即 authority 表中的 route。
+// 在后台给角色分配这些权限后，该角色的用户即可调用对应接口
+var routeAuthorities = map[string]string{
+	"POST " + routePrefix + "/topic/set_top":         "/admin/community/topic/modify",
+	"POST " + routePrefix + "/node/modify":           "/admin/community/node/modify",
+	"POST " + routePrefix + "/node/delete":           "/admin/community/node/del",
+	"POST " + routePrefix + "/node/moderator/assign": "/admin/community/node/modify",
+	"POST " + routePrefix + "/node/moderator/revoke": "/admin/community/node/modify",
+	"POST " + routePrefix + "/user/admin/status":     "/admin/user/user/modify",
+	"POST " + routePrefix + "/user/admin/delete":     "/admin/user/user/del",
+	"POST " + routePrefix + "/book/delete":           "/admin/community/book/del",
+	"GET " + routePrefix + "/sensitive/words":        "/admin/community/sensitive/list",
+	"POST " + routePrefix + "/sensitive/test":        "/admin/community/sensitive/list",
+	"POST " + routePrefix + "/sensitive/word/save":   "/admin/community/sensitive/modify",
+	"POST " + routePrefix + "/sensitive/word/delete": "/admin/community/sensitive/modify",
+	"GET " + routePrefix + "/moderation/items":       "/admin/community/moderation/list",
+	"POST " + routePrefix + "/moderation/approve":    "/admin/community/moderation/audit",
+	"POST " + routePrefix + "/moderation/reject":     "/admin/community/moderation/audit",
+	"GET " + routePrefix + "/reports":                "/admin/community/moderation/list",
+	"GET " + routePrefix + "/risk/ips":               "/admin/user/risk/list",
+	"GET " + routePrefix + "/risk/ip/users":          "/admin/user/risk/list",
+	"POST " + routePrefix + "/risk/ip/add":           "/admin/user/risk/modify",
+	"POST " + routePrefix + "/risk/ip/remove":        "/admin/user/risk/modify",
+	"POST " + routePrefix + "/tag/modify":            "/admin/community/tag/modify",
+	"POST " + routePrefix + "/tag/rename":            "/admin/community/tag/modify",
+	"POST " + routePrefix + "/tag/merge":             "/admin/community/tag/modify",
+	"POST " + routePrefix + "/tag/rebuild":           "/admin/community/tag/modify",
+}
+
+// adminScope 单个路由要求令牌拥有 admin 权限
+var adminScope = middleware.TokenScope(model.ScopeAdmin, model.ScopeAdmin)
+
+// scoped 按路由组限制个人访问令牌的权限范围
+func scoped(g *echo.Group, readScope, writeScope string) *echo.Group {
+	return g.Group("", middleware.TokenScope(readScope, writeScope))
 }
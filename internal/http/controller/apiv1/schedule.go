@@ -0,0 +1,67 @@
+package apiv1
+
+import (
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+// ScheduleController 定时发布。发布时带上 publish_at（如 2026-01-02 15:04）即为定时发布
+type ScheduleController struct{}
+
+func (self ScheduleController) RegisterRoute(g *echo.Group) {
+	g.GET("/scheduled", self.List)
+	g.POST("/schedule/modify", self.Modify)
+	g.POST("/schedule/cancel", self.Cancel)
+}
+
+// List 我的待发布主题和文章
+func (ScheduleController) List(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	items := logic.DefaultSchedule.FindMine(context.EchoContext(ctx), meVal)
+	return success(ctx, map[string]interface{}{"list": items})
+}
+
+// Modify 修改发布时间
+func (ScheduleController) Modify(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	objtype := goutils.MustInt(ctx.FormValue("objtype"))
+	objid := goutils.MustInt(ctx.FormValue("objid"))
+	if !checkWriteScope(ctx, objtype) {
+		return nil
+	}
+	err := logic.DefaultSchedule.Reschedule(context.EchoContext(ctx), meVal, objtype, objid, ctx.FormValue("publish_at"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Cancel 取消定时发布，内容转为草稿
+func (ScheduleController) Cancel(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	objtype := goutils.MustInt(ctx.FormValue("objtype"))
+	objid := goutils.MustInt(ctx.FormValue("objid"))
+	if !checkWriteScope(ctx, objtype) {
+		return nil
+	}
+	draftID, err := logic.DefaultSchedule.Cancel(context.EchoContext(ctx), meVal, objtype, objid)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{"draft_id": draftID})
+}
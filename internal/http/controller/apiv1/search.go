@@ -8,6 +8,7 @@ import (
 
 	"github.com/studygolang/studygolang/context"
 	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/http/middleware"
 	"github.com/studygolang/studygolang/internal/logic"
 	"github.com/studygolang/studygolang/internal/model"
 
@@ -21,7 +22,7 @@ import (
 type SearchController struct{}
 
 func (self SearchController) RegisterRoute(g *echo.Group) {
-	g.GET("/search", self.Search)
+	g.GET("/search", self.Search, middleware.RateLimit(logic.RateLimitSearch))
 }
 
 func (SearchController) Search(ctx echo.Context) error {
@@ -75,7 +76,11 @@ func mongoSearch(ctx stdctx.Context, q string, page, limit int) []map[string]int
 	skip := int64((page - 1) * limit)
 	each := int64(limit)
 
-	topicFilter := bson.M{"title": bson.M{"$regex": escaped}, "flag": bson.M{"$lt": model.FlagAuditDelete}}
+	topicFilter := bson.M{
+		"title":      bson.M{"$regex": escaped},
+		"flag":       bson.M{"$lt": model.FlagAuditDelete},
+		"permission": bson.M{"$nin": []int{model.PermissionFollow, model.PermissionOnlyMe}},
+	}
 	cursor, err := db.GetCollection("topics").Find(ctx, topicFilter, options.Find().SetSort(bson.M{"_id": -1}).SetSkip(skip).SetLimit(each).SetProjection(bson.M{"_id": 1, "title": 1, "content": 1}))
 	if err == nil {
 		defer cursor.Close(ctx)
@@ -95,7 +100,7 @@ func mongoSearch(ctx stdctx.Context, q string, page, limit int) []map[string]int
 		}
 	}
 
-	articleFilter := bson.M{"title": bson.M{"$regex": escaped}}
+	articleFilter := bson.M{"title": bson.M{"$regex": escaped}, "status": bson.M{"$lt": model.ArticleStatusOffline}}
 	cursor2, err := db.GetCollection("articles").Find(ctx, articleFilter, options.Find().SetSort(bson.M{"_id": -1}).SetSkip(skip).SetLimit(each).SetProjection(bson.M{"_id": 1, "title": 1, "txt": 1}))
 	if err == nil {
 		defer cursor2.Close(ctx)
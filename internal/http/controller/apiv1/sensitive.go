@@ -0,0 +1,58 @@
+package apiv1
+
+import (
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+	"github.com/studygolang/studygolang/internal/model"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+type SensitiveController struct{}
+
+func (self SensitiveController) RegisterRoute(g *echo.Group) {
+	g.GET("/sensitive/words", self.List, adminScope)
+	g.POST("/sensitive/word/save", self.Save, adminScope)
+	g.POST("/sensitive/word/delete", self.Delete, adminScope)
+	g.POST("/sensitive/test", self.Test, adminScope)
+}
+
+// List 敏感词列表，可按分类和关键词过滤
+func (SensitiveController) List(ctx echo.Context) error {
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
+	words := logic.DefaultSensitive.FindAll(context.EchoContext(ctx), paginator, ctx.QueryParam("category"), ctx.QueryParam("kw"))
+	return success(ctx, map[string]interface{}{
+		"list":       words,
+		"total":      paginator.GetTotal(),
+		"page":       curPage,
+		"categories": model.SensitiveCategoryMap,
+	})
+}
+
+// Save 新增或修改敏感词，id 为空时新增。保存后立即生效
+func (SensitiveController) Save(ctx echo.Context) error {
+	id := goutils.MustInt(ctx.FormValue("id"))
+	err := logic.DefaultSensitive.Save(context.EchoContext(ctx), me(ctx), id,
+		ctx.FormValue("word"), ctx.FormValue("category"), ctx.FormValue("action"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Delete 删除敏感词
+func (SensitiveController) Delete(ctx echo.Context) error {
+	id := goutils.MustInt(ctx.FormValue("id"))
+	if err := logic.DefaultSensitive.Delete(context.EchoContext(ctx), id); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Test 用当前词库检测一段文本，返回命中的词、处理方式和替换后的文本
+func (SensitiveController) Test(ctx echo.Context) error {
+	result := logic.DefaultSensitive.Check(context.EchoContext(ctx), ctx.FormValue("text"))
+	return success(ctx, result)
+}
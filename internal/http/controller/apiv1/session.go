@@ -0,0 +1,63 @@
+package apiv1
+
+import (
+	"github.com/studygolang/studygolang/context"
+	. "github.com/studygolang/studygolang/internal/http"
+	"github.com/studygolang/studygolang/internal/logic"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+type SessionController struct{}
+
+func (self SessionController) RegisterRoute(g *echo.Group) {
+	g.GET("/user/sessions", self.List)
+	g.POST("/user/sessions/delete", self.Delete)
+	g.POST("/user/sessions/clear", self.Clear)
+}
+
+// List 我的登录会话（设备）列表
+func (SessionController) List(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	sessions := logic.DefaultUserSession.FindByUid(context.EchoContext(ctx), meVal.Uid, CurrentSid(ctx))
+	return success(ctx, map[string]interface{}{"list": sessions})
+}
+
+// Delete 注销某个会话，让对应设备下线
+func (SessionController) Delete(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	id := goutils.MustInt(ctx.FormValue("id"))
+	err := logic.DefaultUserSession.Revoke(context.EchoContext(ctx), meVal.Uid, id)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Clear 注销所有会话；keep_current=1 时保留当前会话
+func (SessionController) Clear(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	exceptSid := ""
+	if ctx.FormValue("keep_current") == "1" {
+		exceptSid = CurrentSid(ctx)
+	}
+	err := logic.DefaultUserSession.RevokeAll(context.EchoContext(ctx), meVal.Uid, exceptSid)
+	if err != nil {
+		return fail(ctx, "内部服务错误")
+	}
+	if exceptSid == "" {
+		DelLoginCookie(ctx)
+	}
+	return success(ctx, nil)
+}
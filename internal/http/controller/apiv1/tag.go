@@ -0,0 +1,137 @@
+package apiv1
+
+import (
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+// 自动补全最多返回的标签数
+const tagAutocompleteLimit = 10
+
+// TagController 标签：热门标签、自动补全、标签下的内容和关注；合并、重命名等由管理员操作
+type TagController struct{}
+
+func (self TagController) RegisterRoute(g *echo.Group) {
+	g.GET("/tags", self.ReadList)
+	g.GET("/tags/autocomplete", self.Autocomplete)
+	g.GET("/tags/followed", self.Followed)
+	g.GET("/tags/:name", self.Detail)
+	g.POST("/tag/follow", self.Follow)
+	g.POST("/tag/unfollow", self.Unfollow)
+	g.POST("/tag/modify", self.Modify, adminScope)
+	g.POST("/tag/rename", self.Rename, adminScope)
+	g.POST("/tag/merge", self.Merge, adminScope)
+	g.POST("/tag/rebuild", self.Rebuild, adminScope)
+}
+
+// ReadList 热门标签
+func (TagController) ReadList(ctx echo.Context) error {
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
+	tags := logic.DefaultTag.FindAll(context.EchoContext(ctx), paginator)
+	return success(ctx, map[string]interface{}{
+		"list":     tags,
+		"total":    paginator.GetTotal(),
+		"page":     curPage,
+		"per_page": perPage,
+	})
+}
+
+// Autocomplete 按前缀 q 补全标签，同义词也能匹配
+func (TagController) Autocomplete(ctx echo.Context) error {
+	tags := logic.DefaultTag.Autocomplete(context.EchoContext(ctx), ctx.QueryParam("q"), tagAutocompleteLimit)
+	return success(ctx, map[string]interface{}{"list": tags})
+}
+
+// Followed 我关注的标签
+func (TagController) Followed(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	tags := logic.DefaultTag.FindFollowed(context.EchoContext(ctx), meVal.Uid)
+	return success(ctx, map[string]interface{}{"list": tags})
+}
+
+// Detail 标签及其下的内容。name 可以是同义词；objtype 可选，默认所有类型
+func (TagController) Detail(ctx echo.Context) error {
+	tag := logic.DefaultTag.FindByName(context.EchoContext(ctx), ctx.Param("name"))
+	if tag == nil {
+		return fail(ctx, "标签不存在")
+	}
+
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+	objtype := goutils.MustInt(ctx.QueryParam("objtype"), -1)
+	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
+	list := logic.DefaultTag.FindObjects(context.EchoContext(ctx), tag, objtype, paginator)
+	return success(ctx, map[string]interface{}{
+		"tag":      tag,
+		"list":     list,
+		"total":    paginator.GetTotal(),
+		"page":     curPage,
+		"per_page": perPage,
+		"followed": logic.DefaultTag.HadFollow(context.EchoContext(ctx), me(ctx).Uid, tag.Id),
+	})
+}
+
+// Follow 关注标签 name
+func (TagController) Follow(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	if err := logic.DefaultTag.Follow(context.EchoContext(ctx), meVal.Uid, ctx.FormValue("name")); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Unfollow 取消关注标签 name
+func (TagController) Unfollow(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	if err := logic.DefaultTag.Unfollow(context.EchoContext(ctx), meVal.Uid, ctx.FormValue("name")); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Modify 修改标签 id 的介绍 intro 和同义词 aliases（逗号分隔）
+func (TagController) Modify(ctx echo.Context) error {
+	id := goutils.MustInt(ctx.FormValue("id"))
+	err := logic.DefaultTag.Modify(context.EchoContext(ctx), id, ctx.FormValue("intro"), ctx.FormValue("aliases"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Rename 把标签 id 重命名为 name，原名称保留为同义词
+func (TagController) Rename(ctx echo.Context) error {
+	id := goutils.MustInt(ctx.FormValue("id"))
+	if err := logic.DefaultTag.Rename(context.EchoContext(ctx), id, ctx.FormValue("name")); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Merge 把标签 from 合并到标签 to
+func (TagController) Merge(ctx echo.Context) error {
+	fromId := goutils.MustInt(ctx.FormValue("from"))
+	toId := goutils.MustInt(ctx.FormValue("to"))
+	if err := logic.DefaultTag.Merge(context.EchoContext(ctx), fromId, toId); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Rebuild 在后台为所有内容重建标签，用于导入已有内容的标签
+func (TagController) Rebuild(ctx echo.Context) error {
+	go logic.DefaultTag.Rebuild()
+	return success(ctx, nil)
+}
@@ -0,0 +1,32 @@
+package apiv1
+
+import (
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+type TimelineController struct{}
+
+func (self TimelineController) RegisterRoute(g *echo.Group) {
+	g.GET("/timeline", self.Timeline)
+}
+
+// Timeline 首页时间线：关注的人、节点、专栏的新内容和评论。
+// cursor 为上一页返回的 next_cursor，next_cursor 为 0 表示没有更多了
+func (TimelineController) Timeline(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	cursor := goutils.MustInt(ctx.QueryParam("cursor"))
+	limit := goutils.MustInt(ctx.QueryParam("limit"), perPage)
+	events, nextCursor := logic.DefaultTimeline.FindByUser(context.EchoContext(ctx), meVal, cursor, limit)
+	return success(ctx, map[string]interface{}{
+		"list":        events,
+		"next_cursor": nextCursor,
+	})
+}
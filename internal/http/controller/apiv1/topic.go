@@ -22,16 +22,24 @@ func (self TopicController) RegisterRoute(g *echo.Group) {
 	g.POST("/topics/modify", self.Modify)
 	g.POST("/topics/delete", self.Delete)
 	g.POST("/topic/set_top", self.SetTop)
-	g.POST("/node/modify", self.NodeModify)
-	g.POST("/node/delete", self.NodeDelete)
+	g.POST("/topic/accept_answer", self.AcceptAnswer)
+	g.POST("/topic/bounty", self.Bounty)
+	g.POST("/node/modify", self.NodeModify, adminScope)
+	g.POST("/node/delete", self.NodeDelete, adminScope)
+	g.POST("/node/follow", self.NodeFollow)
+	g.POST("/node/unfollow", self.NodeUnfollow)
 }
 
+// TopicList 主题列表，tab=unanswered 只看未解决的问题
 func (TopicController) TopicList(ctx echo.Context) error {
 	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
-	tab := ctx.QueryParam("tab")
+	querystring, args := ctx.QueryParam("tab"), []interface{}(nil)
+	if querystring == "unanswered" {
+		querystring, args = logic.DefaultQuestion.UnansweredFilter()
+	}
 	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
-	topics := logic.DefaultTopic.FindAll(context.EchoContext(ctx), paginator, "", tab)
-	total := logic.DefaultTopic.Count(context.EchoContext(ctx), tab)
+	topics := logic.DefaultTopic.FindAll(context.EchoContext(ctx), paginator, "", querystring, args...)
+	total := logic.DefaultTopic.Count(context.EchoContext(ctx), querystring, args...)
 	return success(ctx, map[string]interface{}{
 		"list":     topics,
 		"total":    total,
@@ -57,17 +65,27 @@ func (TopicController) Detail(ctx echo.Context) error {
 		return fail(ctx, "主题不存在")
 	}
 	logic.Views.Incr(Request(ctx), model.TypeTopic, tid)
+	content, _ := topic["content"].(string)
+	rendered := logic.DefaultRender.Markdown(context.EchoContext(ctx), content)
 	return success(ctx, map[string]interface{}{
-		"topic":   topic,
-		"replies": replies,
+		"topic":        topic,
+		"replies":      replies,
+		"content_html": rendered.HTML,
+		"toc":          rendered.Toc,
 	})
 }
 
 func (TopicController) NodeTopics(ctx echo.Context) error {
 	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
 	nid := goutils.MustInt(ctx.Param("nid"))
+	querystring, args := "nid=?", []interface{}{nid}
+	if ctx.QueryParam("tab") == "unanswered" {
+		unanswered, unansweredArgs := logic.DefaultQuestion.UnansweredFilter()
+		querystring, args = querystring+" AND "+unanswered, append(args, unansweredArgs...)
+	}
 	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
-	topics := logic.DefaultTopic.FindAll(context.EchoContext(ctx), paginator, "", "", nid)
+	// 版主在节点内置顶的主题排在前面
+	topics := logic.DefaultTopic.FindAll(context.EchoContext(ctx), paginator, "node_top DESC,tid DESC", querystring, args...)
 	return success(ctx, map[string]interface{}{
 		"list": topics,
 		"page": curPage,
@@ -87,7 +105,7 @@ func (TopicController) Create(ctx echo.Context) error {
 	formParams, _ := ctx.FormParams()
 	tid, err := logic.DefaultTopic.Publish(context.EchoContext(ctx), meVal, formParams)
 	if err != nil {
-		return fail(ctx, err.Error())
+		return failErr(ctx, err)
 	}
 	return success(ctx, map[string]interface{}{"tid": tid})
 }
@@ -100,7 +118,7 @@ func (TopicController) Modify(ctx echo.Context) error {
 	formParams, _ := ctx.FormParams()
 	errMsg, err := logic.DefaultTopic.Modify(context.EchoContext(ctx), meVal, formParams)
 	if err != nil {
-		return fail(ctx, errMsg)
+		return failErr(ctx, err, errMsg)
 	}
 	return success(ctx, nil)
 }
@@ -127,11 +145,39 @@ func (TopicController) SetTop(ctx echo.Context) error {
 	return success(ctx, nil)
 }
 
-func (TopicController) NodeModify(ctx echo.Context) error {
+// AcceptAnswer 问答模式的主题采纳回复 cid 为答案
+func (TopicController) AcceptAnswer(ctx echo.Context) error {
 	meVal := me(ctx)
-	if !meVal.IsRoot {
-		return fail(ctx, "无权操作")
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
 	}
+
+	tid := goutils.MustInt(ctx.FormValue("tid"))
+	cid := goutils.MustInt(ctx.FormValue("cid"))
+	if err := logic.DefaultQuestion.AcceptAnswer(context.EchoContext(ctx), meVal, tid, cid); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Bounty 提问者给问答主题悬赏铜币（amount），期限 days 天
+func (TopicController) Bounty(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	tid := goutils.MustInt(ctx.FormValue("tid"))
+	amount := goutils.MustInt(ctx.FormValue("amount"))
+	days := goutils.MustInt(ctx.FormValue("days"))
+	bounty, err := logic.DefaultBounty.Offer(context.EchoContext(ctx), meVal, tid, amount, days)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{"bounty": bounty})
+}
+
+func (TopicController) NodeModify(ctx echo.Context) error {
 	formParams, _ := ctx.FormParams()
 	err := logic.DefaultNode.Modify(context.EchoContext(ctx), formParams)
 	if err != nil {
@@ -141,10 +187,6 @@ func (TopicController) NodeModify(ctx echo.Context) error {
 }
 
 func (TopicController) NodeDelete(ctx echo.Context) error {
-	meVal := me(ctx)
-	if !meVal.IsRoot {
-		return fail(ctx, "无权操作")
-	}
 	nid := goutils.MustInt(ctx.FormValue("nid"))
 	err := logic.DefaultNode.Delete(context.EchoContext(ctx), nid)
 	if err != nil {
@@ -159,3 +201,31 @@ func me(ctx echo.Context) *model.Me {
 	}
 	return &model.Me{}
 }
+
+// NodeFollow 关注节点，节点下的新主题会出现在时间线中
+func (TopicController) NodeFollow(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	nid := goutils.MustInt(ctx.FormValue("nid"))
+	if err := logic.DefaultUserFollow.FollowNode(context.EchoContext(ctx), meVal.Uid, nid); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// NodeUnfollow 取消关注节点
+func (TopicController) NodeUnfollow(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	nid := goutils.MustInt(ctx.FormValue("nid"))
+	if err := logic.DefaultUserFollow.UnfollowNode(context.EchoContext(ctx), meVal.Uid, nid); err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
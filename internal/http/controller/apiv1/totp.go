@@ -0,0 +1,88 @@
+package apiv1
+
+import (
+	"github.com/studygolang/studygolang/context"
+	"github.com/studygolang/studygolang/internal/logic"
+
+	echo "github.com/labstack/echo/v4"
+)
+
+type TotpController struct{}
+
+func (self TotpController) RegisterRoute(g *echo.Group) {
+	g.GET("/user/totp", self.Status)
+	g.POST("/user/totp/setup", self.Setup)
+	g.POST("/user/totp/enable", self.Enable)
+	g.POST("/user/totp/disable", self.Disable)
+	g.POST("/user/totp/recovery_codes", self.RecoveryCodes)
+}
+
+// Status 两步验证开启状态
+func (TotpController) Status(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	data := map[string]interface{}{
+		"enabled":  false,
+		"required": logic.DefaultTotp.IsRequired(context.EchoContext(ctx), meVal.Uid),
+	}
+	userTotp := logic.DefaultTotp.FindOne(context.EchoContext(ctx), meVal.Uid)
+	if userTotp != nil && userTotp.Enabled {
+		data["enabled"] = true
+		data["enabled_at"] = userTotp.EnabledAt
+		data["recovery_codes_left"] = len(userTotp.RecoveryCodes)
+	}
+	return success(ctx, data)
+}
+
+// Setup 生成密钥和二维码 URI，用验证码调用 Enable 后才真正开启
+func (TotpController) Setup(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	secret, uri, err := logic.DefaultTotp.Setup(context.EchoContext(ctx), meVal.Uid, meVal.Username)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{"secret": secret, "uri": uri})
+}
+
+func (TotpController) Enable(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	recoveryCodes, err := logic.DefaultTotp.Enable(context.EchoContext(ctx), meVal.Uid, ctx.FormValue("code"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{"recovery_codes": recoveryCodes})
+}
+
+func (TotpController) Disable(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	err := logic.DefaultTotp.Disable(context.EchoContext(ctx), meVal.Uid, ctx.FormValue("code"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// RecoveryCodes 重新生成恢复码
+func (TotpController) RecoveryCodes(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	recoveryCodes, err := logic.DefaultTotp.RegenRecoveryCodes(context.EchoContext(ctx), meVal.Uid, ctx.FormValue("code"))
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, map[string]interface{}{"recovery_codes": recoveryCodes})
+}
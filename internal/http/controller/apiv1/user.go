@@ -1,8 +1,12 @@
 package apiv1
 
 import (
+	stdctx "context"
+	"strings"
+
 	"github.com/studygolang/studygolang/context"
 	"github.com/studygolang/studygolang/internal/logic"
+	"github.com/studygolang/studygolang/internal/model"
 
 	echo "github.com/labstack/echo/v4"
 	"github.com/polaris1119/goutils"
@@ -18,10 +22,15 @@ func (self UserController) RegisterRoute(g *echo.Group) {
 	g.GET("/user/:username/projects", self.Projects)
 	g.GET("/users", self.UserList)
 	g.GET("/users/active", self.ActiveUsers)
-	g.POST("/user/admin/status", self.AdminChangeStatus)
-	g.POST("/user/admin/delete", self.AdminDelete)
+	g.POST("/user/admin/status", self.AdminChangeStatus, adminScope)
+	g.POST("/user/admin/delete", self.AdminDelete, adminScope)
 	g.GET("/users/newest", self.NewestUsers)
 	g.POST("/user/modify", self.Modify)
+	g.GET("/me/permissions", self.Permissions)
+	g.GET("/user/:username/following", self.Following)
+	g.GET("/user/:username/followers", self.Followers)
+	g.POST("/user/follow", self.Follow)
+	g.POST("/user/unfollow", self.Unfollow)
 }
 
 func (UserController) Profile(ctx echo.Context) error {
@@ -30,7 +39,8 @@ func (UserController) Profile(ctx echo.Context) error {
 	if user == nil || user.Uid == 0 {
 		return fail(ctx, "用户不存在")
 	}
-	return success(ctx, map[string]interface{}{"user": user})
+	followed := logic.DefaultUserFollow.IsFollowing(context.EchoContext(ctx), me(ctx).Uid, user.Uid)
+	return success(ctx, map[string]interface{}{"user": user, "followed": followed})
 }
 
 func (UserController) Topics(ctx echo.Context) error {
@@ -112,12 +122,11 @@ func (UserController) Modify(ctx echo.Context) error {
 }
 
 func (UserController) AdminChangeStatus(ctx echo.Context) error {
-	meVal := me(ctx)
-	if !meVal.IsRoot {
-		return fail(ctx, "无权操作")
-	}
 	uid := goutils.MustInt(ctx.FormValue("uid"))
 	status := goutils.MustInt(ctx.FormValue("status"))
+	if err := logic.DefaultUser.CheckManage(context.EchoContext(ctx), me(ctx), uid); err != nil {
+		return fail(ctx, err.Error())
+	}
 	err := logic.DefaultUser.UpdateUserStatus(context.EchoContext(ctx), uid, status)
 	if err != nil {
 		return fail(ctx, err.Error())
@@ -126,14 +135,88 @@ func (UserController) AdminChangeStatus(ctx echo.Context) error {
 }
 
 func (UserController) AdminDelete(ctx echo.Context) error {
+	uid := goutils.MustInt(ctx.FormValue("uid"))
+	if err := logic.DefaultUser.CheckManage(context.EchoContext(ctx), me(ctx), uid); err != nil {
+		return fail(ctx, err.Error())
+	}
+	err := logic.DefaultUser.DeleteUserContent(context.EchoContext(ctx), uid)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Permissions 当前用户可以调用的受权限控制的接口，前端据此隐藏无权执行的操作
+func (UserController) Permissions(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+
+	routes := make(map[string]bool, len(routeAuthorities))
+	for route, authority := range routeAuthorities {
+		routes[strings.TrimPrefix(route, routePrefix)] = logic.DefaultAuthority.HasRoleAuthority(meVal, authority)
+	}
+
+	return success(ctx, map[string]interface{}{
+		"is_root":     meVal.IsRoot,
+		"is_admin":    meVal.IsAdmin,
+		"routes":      routes,
+		"authorities": logic.DefaultAuthority.RoleAuthorityRoutes(meVal),
+	})
+}
+
+// Following 用户关注的人
+func (UserController) Following(ctx echo.Context) error {
+	return userFollowList(ctx, logic.DefaultUserFollow.FindFollowing)
+}
+
+// Followers 用户的粉丝
+func (UserController) Followers(ctx echo.Context) error {
+	return userFollowList(ctx, logic.DefaultUserFollow.FindFollowers)
+}
+
+// Follow 关注用户
+func (UserController) Follow(ctx echo.Context) error {
 	meVal := me(ctx)
-	if !meVal.IsRoot {
-		return fail(ctx, "无权操作")
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
 	}
 	uid := goutils.MustInt(ctx.FormValue("uid"))
-	err := logic.DefaultUser.DeleteUserContent(context.EchoContext(ctx), uid)
+	err := logic.DefaultUserFollow.Follow(context.EchoContext(ctx), meVal.Uid, uid)
+	if err != nil {
+		return fail(ctx, err.Error())
+	}
+	return success(ctx, nil)
+}
+
+// Unfollow 取消关注
+func (UserController) Unfollow(ctx echo.Context) error {
+	meVal := me(ctx)
+	if meVal.Uid == 0 {
+		return fail(ctx, "请先登录")
+	}
+	uid := goutils.MustInt(ctx.FormValue("uid"))
+	err := logic.DefaultUserFollow.Unfollow(context.EchoContext(ctx), meVal.Uid, uid)
 	if err != nil {
 		return fail(ctx, err.Error())
 	}
 	return success(ctx, nil)
 }
+
+func userFollowList(ctx echo.Context, find func(stdctx.Context, int, *logic.Paginator) []*model.User) error {
+	username := ctx.Param("username")
+	user := logic.DefaultUser.FindOne(context.EchoContext(ctx), "username", username)
+	if user == nil || user.Uid == 0 {
+		return fail(ctx, "用户不存在")
+	}
+
+	curPage := goutils.MustInt(ctx.QueryParam("p"), 1)
+	paginator := logic.NewPaginatorWithPerPage(curPage, perPage)
+	users := find(context.EchoContext(ctx), user.Uid, paginator)
+	return success(ctx, map[string]interface{}{
+		"list":  users,
+		"total": paginator.GetTotal(),
+		"page":  curPage,
+	})
+}
@@ -65,7 +65,12 @@ func (WikiController) Detail(ctx echo.Context) error {
 		return fail(ctx, "Wiki不存在")
 	}
 
-	result := map[string]interface{}{"wiki": wiki}
+	rendered := logic.DefaultRender.Markdown(context.EchoContext(ctx), wiki.Content)
+	result := map[string]interface{}{
+		"wiki":         wiki,
+		"content_html": rendered.HTML,
+		"toc":          rendered.Toc,
+	}
 	if wiki.Uid > 0 {
 		userMap := logic.DefaultUser.FindUserInfos(context.EchoContext(ctx), []int{wiki.Uid})
 		if u, ok := userMap[wiki.Uid]; ok {
@@ -86,7 +91,7 @@ func (WikiController) Create(ctx echo.Context) error {
 	}
 	err = logic.DefaultWiki.Create(context.EchoContext(ctx), meVal, formParams)
 	if err != nil {
-		return fail(ctx, err.Error())
+		return failErr(ctx, err)
 	}
 	return success(ctx, nil)
 }
@@ -102,7 +107,7 @@ func (WikiController) Modify(ctx echo.Context) error {
 	}
 	err = logic.DefaultWiki.Modify(context.EchoContext(ctx), meVal, formParams)
 	if err != nil {
-		return fail(ctx, err.Error())
+		return failErr(ctx, err)
 	}
 	return success(ctx, nil)
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/studygolang/studygolang/context"
 	"github.com/studygolang/studygolang/global"
 	. "github.com/studygolang/studygolang/internal/http"
+	"github.com/studygolang/studygolang/internal/http/middleware"
 	"github.com/studygolang/studygolang/internal/logic"
 
 	echo "github.com/labstack/echo/v4"
@@ -29,9 +30,9 @@ type ImageController struct{}
 
 func (self ImageController) RegisterRoute(g *echo.Group) {
 	// todo 这三个upload差不多啊
-	g.POST("/image/upload", self.Upload)
-	g.POST("/image/paste_upload", self.PasteUpload)
-	g.POST("/image/quick_upload", self.QuickUpload)
+	g.POST("/image/upload", self.Upload, middleware.RateLimit(logic.RateLimitUpload))
+	g.POST("/image/paste_upload", self.PasteUpload, middleware.RateLimit(logic.RateLimitUpload))
+	g.POST("/image/quick_upload", self.QuickUpload, middleware.RateLimit(logic.RateLimitUpload))
 	g.Match([]string{"GET", "POST"}, "/image/transfer", self.Transfer)
 }
 
@@ -7,6 +7,8 @@
 package controller
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
 
 	"github.com/studygolang/studygolang/context"
@@ -21,26 +23,65 @@ type OAuthController struct{}
 
 // 注册路由
 func (self OAuthController) RegisterRoute(g *echo.Group) {
-	g.GET("/oauth/github/callback", self.GithubCallback)
-	g.GET("/oauth/github/login", self.GithubLogin)
+	g.GET("/oauth/providers", self.Providers)
+	g.GET("/oauth/:provider/login", self.Login)
+	g.GET("/oauth/:provider/callback", self.Callback)
+	g.POST("/oauth/email", self.Email)
+	g.GET("/oauth/email/confirm", self.EmailConfirm)
+}
 
-	g.GET("/oauth/gitea/callback", self.GiteaCallback)
-	g.GET("/oauth/gitea/login", self.GiteaLogin)
+// Providers 已启用的第三方登录方式
+func (OAuthController) Providers(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, map[string]interface{}{"code": 0, "msg": "ok", "data": logic.OAuthProviders()})
 }
 
-func (OAuthController) GithubLogin(ctx echo.Context) error {
+func (OAuthController) Login(ctx echo.Context) error {
+	providerName := ctx.Param("provider")
 	uri := ctx.QueryParam("uri")
-	url := logic.DefaultThirdUser.GithubAuthCodeUrl(context.EchoContext(ctx), uri)
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ctx.Redirect(http.StatusSeeOther, "/?login_error="+providerName)
+	}
+	state := hex.EncodeToString(buf)
+
+	url, err := logic.DefaultThirdUser.AuthCodeUrl(context.EchoContext(ctx), providerName, uri, state)
+	if err != nil {
+		return ctx.Redirect(http.StatusSeeOther, "/?login_error="+providerName)
+	}
+
+	// 回调时校验 state，防止 CSRF；换取 token 时需要同样的回调地址
+	session := GetCookieSession(ctx)
+	session.Values["oauth_state"] = state
+	session.Values["oauth_redirect"] = uri
+	session.Save(Request(ctx), ResponseWriter(ctx))
+
 	return ctx.Redirect(http.StatusSeeOther, url)
 }
 
-func (OAuthController) GithubCallback(ctx echo.Context) error {
+func (OAuthController) Callback(ctx echo.Context) error {
+	providerName := ctx.Param("provider")
 	code := ctx.FormValue("code")
+	loginErrorURL := "/?login_error=" + providerName
+
+	session := GetCookieSession(ctx)
+	state, _ := session.Values["oauth_state"].(string)
+	redirectURI, _ := session.Values["oauth_redirect"].(string)
+	delete(session.Values, "oauth_state")
+	delete(session.Values, "oauth_redirect")
+	session.Save(Request(ctx), ResponseWriter(ctx))
+
+	if state == "" || state != ctx.FormValue("state") {
+		return ctx.Redirect(http.StatusSeeOther, loginErrorURL)
+	}
 
 	me, ok := ctx.Get("user").(*model.Me)
 	if ok {
-		// 已登录用户，绑定 github
-		logic.DefaultThirdUser.BindGithub(context.EchoContext(ctx), code, me)
+		// 已登录用户，绑定第三方账号
+		err := logic.DefaultThirdUser.BindOAuth(context.EchoContext(ctx), providerName, redirectURI, code, me)
+		if err != nil {
+			return ctx.Redirect(http.StatusSeeOther, "/account/edit?bind_error="+providerName+"#connection")
+		}
 
 		redirectURL := ctx.QueryParam("redirect_url")
 		if redirectURL == "" {
@@ -49,13 +90,28 @@ func (OAuthController) GithubCallback(ctx echo.Context) error {
 		return ctx.Redirect(http.StatusSeeOther, redirectURL)
 	}
 
-	user, err := logic.DefaultThirdUser.LoginFromGithub(context.EchoContext(ctx), code)
+	user, ticket, err := logic.DefaultThirdUser.LoginFromOAuth(context.EchoContext(ctx), providerName, redirectURI, code)
+	if err == logic.ErrOAuthNeedEmail {
+		// 第三方账号没有公开邮箱，跳转到填写邮箱页面完成注册
+		return ctx.Redirect(http.StatusSeeOther, "/account/oauth/email?ticket="+ticket)
+	}
 	if err != nil || user.Uid == 0 {
-		return ctx.Redirect(http.StatusSeeOther, "/?login_error=github")
+		return ctx.Redirect(http.StatusSeeOther, loginErrorURL)
+	}
+
+	// 开启了两步验证，跳转到输入验证码页面
+	if logic.DefaultTotp.NeedSecondStep(context.EchoContext(ctx), user.Uid) {
+		ticket, err := logic.DefaultTotp.GenLoginTicket(user.Username)
+		if err != nil {
+			return ctx.Redirect(http.StatusSeeOther, loginErrorURL)
+		}
+		return ctx.Redirect(http.StatusSeeOther, "/account/login/totp?ticket="+ticket)
 	}
 
 	// 登录成功，种cookie
-	SetLoginCookie(ctx, user.Username)
+	if err = SetLoginCookie(ctx, user.Username); err != nil {
+		return ctx.Redirect(http.StatusSeeOther, loginErrorURL)
+	}
 
 	if user.Balance == 0 {
 		return ctx.Redirect(http.StatusSeeOther, "/balance")
@@ -64,35 +120,26 @@ func (OAuthController) GithubCallback(ctx echo.Context) error {
 	return ctx.Redirect(http.StatusSeeOther, "/")
 }
 
-func (OAuthController) GiteaLogin(ctx echo.Context) error {
-	uri := ctx.QueryParam("uri")
-	url := logic.DefaultThirdUser.GiteaAuthCodeUrl(context.EchoContext(ctx), uri)
-	return ctx.Redirect(http.StatusSeeOther, url)
+// Email 第三方账号没有公开邮箱时，提交邮箱，发送确认邮件
+func (OAuthController) Email(ctx echo.Context) error {
+	err := logic.DefaultThirdUser.CompleteOAuthSignup(context.EchoContext(ctx), ctx.FormValue("ticket"), ctx.FormValue("email"), CheckIsHttps(ctx))
+	if err != nil {
+		return ctx.JSON(http.StatusOK, map[string]interface{}{"code": 1, "msg": err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, map[string]interface{}{"code": 0, "msg": "确认邮件已发送，请点击邮件中的链接完成注册"})
 }
 
-func (OAuthController) GiteaCallback(ctx echo.Context) error {
-	code := ctx.FormValue("code")
-
-	me, ok := ctx.Get("user").(*model.Me)
-	if ok {
-		// 已登录用户，绑定 github
-		logic.DefaultThirdUser.BindGitea(context.EchoContext(ctx), code, me)
-
-		redirectURL := ctx.QueryParam("redirect_url")
-		if redirectURL == "" {
-			redirectURL = "/account/edit#connection"
-		}
-		return ctx.Redirect(http.StatusSeeOther, redirectURL)
+// EmailConfirm 点击确认邮件中的链接，完成注册并登录
+func (OAuthController) EmailConfirm(ctx echo.Context) error {
+	user, err := logic.DefaultThirdUser.ConfirmOAuthSignup(context.EchoContext(ctx), ctx.QueryParam("ticket"), ctx.QueryParam("code"))
+	if err != nil {
+		return ctx.Redirect(http.StatusSeeOther, "/?login_error=email")
 	}
 
-	user, err := logic.DefaultThirdUser.LoginFromGitea(context.EchoContext(ctx), code)
-	if err != nil || user.Uid == 0 {
-		return ctx.Redirect(http.StatusSeeOther, "/?login_error=gitea")
+	if err = SetLoginCookie(ctx, user.Username); err != nil {
+		return ctx.Redirect(http.StatusSeeOther, "/?login_error=email")
 	}
 
-	// 登录成功，种cookie
-	SetLoginCookie(ctx, user.Username)
-
 	if user.Balance == 0 {
 		return ctx.Redirect(http.StatusSeeOther, "/balance")
 	}
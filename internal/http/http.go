@@ -10,25 +10,50 @@ import (
 	"github.com/polaris1119/config"
 	"github.com/polaris1119/goutils"
 
+	mycontext "github.com/studygolang/studygolang/context"
 	"github.com/studygolang/studygolang/internal/logic"
 )
 
 var Store = sessions.NewCookieStore([]byte(config.ConfigFile.MustValue("global", "cookie_secret")))
 
-func SetLoginCookie(ctx echo.Context, username string) {
+// SetLoginCookie 创建服务端会话，cookie 中只保存会话 ID
+func SetLoginCookie(ctx echo.Context, username string) error {
 	Store.Options.HttpOnly = true
 
 	session := GetCookieSession(ctx)
-	if ctx.FormValue("remember_me") != "1" {
+	remember := ctx.FormValue("remember_me") == "1"
+	if !remember {
 		session.Options = &sessions.Options{
 			Path:     "/",
 			HttpOnly: true,
 		}
 	}
-	session.Values["username"] = username
+
 	req := Request(ctx)
-	resp := ResponseWriter(ctx)
-	session.Save(req, resp)
+	ip := goutils.RemoteIp(req)
+	sid, err := logic.DefaultUserSession.Create(mycontext.EchoContext(ctx), username, req.UserAgent(), ip, remember)
+	if err != nil {
+		return err
+	}
+
+	// 旧版 cookie 直接保存 username，已不再使用
+	delete(session.Values, "username")
+	session.Values["sid"] = sid
+	return session.Save(req, ResponseWriter(ctx))
+}
+
+// DelLoginCookie 退出登录：注销服务端会话并删除 cookie
+func DelLoginCookie(ctx echo.Context) {
+	session := GetCookieSession(ctx)
+	logic.DefaultUserSession.RevokeBySid(mycontext.EchoContext(ctx), CurrentSid(ctx))
+	session.Options.MaxAge = -1
+	session.Save(Request(ctx), ResponseWriter(ctx))
+}
+
+// CurrentSid 获取 cookie 中的会话 ID
+func CurrentSid(ctx echo.Context) string {
+	sid, _ := GetCookieSession(ctx).Values["sid"].(string)
+	return sid
 }
 
 func GetCookieSession(ctx echo.Context) *sessions.Session {
@@ -94,6 +119,15 @@ func GenToken(uid int) string {
 	return buffer.String()
 }
 
+// BearerToken 获取 Authorization: Bearer 头中的令牌
+func BearerToken(ctx echo.Context) string {
+	auth := ctx.Request().Header.Get(echo.HeaderAuthorization)
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
 func AccessControl(ctx echo.Context) {
 	ctx.Response().Header().Add("Access-Control-Allow-Origin", "*")
 }
@@ -55,10 +55,23 @@ func AutoLogin() echo.MiddlewareFunc {
 				}
 			}
 
-			session := GetCookieSession(ctx)
-			username, ok := session.Values["username"]
-			if ok {
-				getCurrentUser(username)
+			if sid := CurrentSid(ctx); sid != "" {
+				// 服务端会话，已注销或过期的会话视为未登录
+				ip := goutils.RemoteIp(Request(ctx))
+				userSession, err := logic.DefaultUserSession.Authenticate(mycontext.EchoContext(ctx), sid, ip)
+				if err == nil {
+					ctx.Set("session", userSession)
+					getCurrentUser(userSession.Uid)
+				}
+			} else if bearer := BearerToken(ctx); bearer != "" {
+				// 个人访问令牌（脚本调用 API）
+				ip := goutils.RemoteIp(Request(ctx))
+				accessToken, err := logic.DefaultAccessToken.Authenticate(mycontext.EchoContext(ctx), bearer, ip)
+				if err != nil {
+					return ctx.JSON(http.StatusUnauthorized, map[string]interface{}{"code": http.StatusUnauthorized, "msg": err.Error()})
+				}
+				ctx.Set("access_token", accessToken)
+				getCurrentUser(accessToken.Uid)
 			} else {
 				// App（手机） 登录
 				uid, ok := ParseToken(ctx.FormValue("token"))
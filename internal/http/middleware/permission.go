@@ -0,0 +1,42 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/studygolang/studygolang/internal/logic"
+	"github.com/studygolang/studygolang/internal/model"
+
+	echo "github.com/labstack/echo/v4"
+)
+
+// Permission 用于 echo 框架的接口权限控制。
+// routeAuthorities 的 key 是 "方法 路由"（如 "POST /api/v1/node/modify"），value 是 authority 表中的 route；
+// 不在其中的路由不做限制，在其中的，要求当前用户的角色拥有对应权限（站长总是拥有）
+func Permission(routeAuthorities map[string]string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			authority, ok := routeAuthorities[ctx.Request().Method+" "+ctx.Path()]
+			if ok {
+				user, _ := ctx.Get("user").(*model.Me)
+				if user == nil || user.Uid == 0 {
+					return ctx.JSON(http.StatusUnauthorized, map[string]interface{}{"code": http.StatusUnauthorized, "msg": "请先登录"})
+				}
+
+				if !logic.DefaultAuthority.HasRoleAuthority(user, authority) {
+					return ctx.JSON(http.StatusForbidden, map[string]interface{}{"code": http.StatusForbidden, "msg": "无权操作"})
+				}
+			}
+
+			if err := next(ctx); err != nil {
+				return err
+			}
+
+			return nil
+		}
+	}
+}
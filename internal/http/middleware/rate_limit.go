@@ -0,0 +1,62 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	mycontext "github.com/studygolang/studygolang/context"
+	. "github.com/studygolang/studygolang/internal/http"
+	"github.com/studygolang/studygolang/internal/logic"
+	"github.com/studygolang/studygolang/internal/model"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/polaris1119/goutils"
+)
+
+// RateLimit 用于 echo 框架，按用户和 IP 限制 action 的请求频率（令牌桶）。
+// 超过限制时返回 429，并通过 Retry-After 告知多久后重试；管理员不受限制
+func RateLimit(action string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			uid := 0
+			if user, ok := ctx.Get("user").(*model.Me); ok {
+				if user.IsAdmin {
+					return next(ctx)
+				}
+				uid = user.Uid
+			}
+
+			ip := goutils.RemoteIp(Request(ctx))
+			result := logic.DefaultRateLimit.Allow(mycontext.EchoContext(ctx), action, uid, ip)
+			if result == nil {
+				return next(ctx)
+			}
+
+			header := ctx.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("X-RateLimit-Reset", strconv.FormatInt(result.Reset.Unix(), 10))
+
+			if !result.Allowed {
+				retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+				header.Set("Retry-After", strconv.Itoa(retryAfter))
+				return ctx.JSON(http.StatusTooManyRequests, map[string]interface{}{
+					"code": http.StatusTooManyRequests,
+					"msg":  "操作太频繁，请 " + strconv.Itoa(retryAfter) + " 秒后再试",
+				})
+			}
+
+			if err := next(ctx); err != nil {
+				return err
+			}
+
+			return nil
+		}
+	}
+}
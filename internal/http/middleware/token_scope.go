@@ -0,0 +1,58 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/studygolang/studygolang/internal/model"
+
+	echo "github.com/labstack/echo/v4"
+)
+
+// TokenScope 用于 echo 框架，校验个人访问令牌的权限范围。
+// 通过 cookie 登录的请求不受影响；令牌请求中，GET 需要 readScope 权限，其他方法需要 writeScope 权限
+func TokenScope(readScope, writeScope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			accessToken, ok := ctx.Get("access_token").(*model.AccessToken)
+			if ok {
+				scope := writeScope
+				method := ctx.Request().Method
+				if method == http.MethodGet || method == http.MethodHead {
+					scope = readScope
+				}
+
+				if !accessToken.HasScope(scope) {
+					return ctx.JSON(http.StatusForbidden, map[string]interface{}{"code": http.StatusForbidden, "msg": "令牌缺少权限：" + scope})
+				}
+			}
+
+			if err := next(ctx); err != nil {
+				return err
+			}
+
+			return nil
+		}
+	}
+}
+
+// NoAccessToken 用于 echo 框架，禁止通过个人访问令牌访问（如令牌管理本身）
+func NoAccessToken() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if _, ok := ctx.Get("access_token").(*model.AccessToken); ok {
+				return ctx.JSON(http.StatusForbidden, map[string]interface{}{"code": http.StatusForbidden, "msg": "该操作不允许使用令牌"})
+			}
+
+			if err := next(ctx); err != nil {
+				return err
+			}
+
+			return nil
+		}
+	}
+}
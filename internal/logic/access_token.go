@@ -0,0 +1,218 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/goutils"
+	"github.com/polaris1119/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// 每个用户最多拥有的令牌数
+	maxAccessTokenNum = 20
+	// 令牌最长有效期（天）
+	maxAccessTokenDays = 365
+)
+
+var ErrAccessTokenInvalid = errors.New("令牌无效或已过期")
+
+type AccessTokenLogic struct{}
+
+var DefaultAccessToken = AccessTokenLogic{}
+
+// Create 创建个人访问令牌，返回的明文令牌只在创建时出现一次
+func (self AccessTokenLogic) Create(ctx context.Context, me *model.Me, form url.Values) (string, *model.AccessToken, error) {
+	objLog := GetLogger(ctx)
+
+	name := strings.TrimSpace(form.Get("name"))
+	if name == "" {
+		return "", nil, errors.New("令牌名称不能为空")
+	}
+
+	scopes, err := self.parseScopes(form["scopes"])
+	if err != nil {
+		return "", nil, err
+	}
+	if !me.IsAdmin {
+		for _, scope := range scopes {
+			if scope == model.ScopeAdmin {
+				return "", nil, errors.New("只有管理员才能创建 admin 权限的令牌")
+			}
+		}
+	}
+
+	total, err := db.GetCollection("access_token").CountDocuments(ctx, bson.M{"uid": me.Uid})
+	if err != nil {
+		objLog.Errorln("AccessTokenLogic Create count error:", err)
+		return "", nil, errors.New("内部服务错误")
+	}
+	if total >= maxAccessTokenNum {
+		return "", nil, errors.New("令牌数量已达上限，请先删除不用的令牌")
+	}
+
+	days := goutils.MustInt(form.Get("expire_days"))
+	if days < 0 || days > maxAccessTokenDays {
+		return "", nil, errors.New("有效期不合法")
+	}
+
+	plain, err := genAccessToken()
+	if err != nil {
+		objLog.Errorln("AccessTokenLogic Create gen token error:", err)
+		return "", nil, errors.New("内部服务错误")
+	}
+
+	accessToken := &model.AccessToken{
+		Uid:       me.Uid,
+		Name:      name,
+		TokenHash: hashAccessToken(plain),
+		TokenTail: plain[len(plain)-4:],
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+	if days > 0 {
+		accessToken.ExpireAt = accessToken.CreatedAt.Add(time.Duration(days) * 24 * time.Hour)
+	}
+
+	accessToken.Id, err = db.NextID("access_token")
+	if err != nil {
+		objLog.Errorln("AccessTokenLogic Create NextID error:", err)
+		return "", nil, errors.New("内部服务错误")
+	}
+
+	_, err = db.GetCollection("access_token").InsertOne(ctx, accessToken)
+	if err != nil {
+		objLog.Errorln("AccessTokenLogic Create insert error:", err)
+		return "", nil, errors.New("内部服务错误")
+	}
+
+	return plain, accessToken, nil
+}
+
+// FindByUid 获取用户的所有令牌
+func (AccessTokenLogic) FindByUid(ctx context.Context, uid int) []*model.AccessToken {
+	objLog := GetLogger(ctx)
+
+	accessTokens := make([]*model.AccessToken, 0)
+	cursor, err := db.GetCollection("access_token").Find(ctx, bson.M{"uid": uid}, options.Find().SetSort(bson.M{"_id": -1}))
+	if err != nil {
+		objLog.Errorln("AccessTokenLogic FindByUid error:", err)
+		return accessTokens
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &accessTokens); err != nil {
+		objLog.Errorln("AccessTokenLogic FindByUid decode error:", err)
+	}
+	return accessTokens
+}
+
+// Revoke 删除（吊销）某个令牌
+func (AccessTokenLogic) Revoke(ctx context.Context, uid, id int) error {
+	objLog := GetLogger(ctx)
+
+	result, err := db.GetCollection("access_token").DeleteOne(ctx, bson.M{"_id": id, "uid": uid})
+	if err != nil {
+		objLog.Errorln("AccessTokenLogic Revoke error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.DeletedCount == 0 {
+		return NotFoundErr
+	}
+	return nil
+}
+
+// Authenticate 校验明文令牌，成功则记录最后使用时间和 IP
+func (AccessTokenLogic) Authenticate(ctx context.Context, plain, ip string) (*model.AccessToken, error) {
+	if !strings.HasPrefix(plain, model.AccessTokenPrefix) {
+		return nil, ErrAccessTokenInvalid
+	}
+
+	accessToken := &model.AccessToken{}
+	err := db.GetCollection("access_token").FindOne(ctx, bson.M{"token_hash": hashAccessToken(plain)}).Decode(accessToken)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			logger.Errorln("AccessTokenLogic Authenticate error:", err)
+		}
+		return nil, ErrAccessTokenInvalid
+	}
+
+	if accessToken.Expired() {
+		return nil, ErrAccessTokenInvalid
+	}
+
+	go func() {
+		_, err := db.GetCollection("access_token").UpdateOne(context.Background(), bson.M{"_id": accessToken.Id}, bson.M{"$set": bson.M{
+			"last_used_at": time.Now(),
+			"last_used_ip": ip,
+		}})
+		if err != nil {
+			logger.Errorln("AccessTokenLogic record last used error:", err)
+		}
+	}()
+
+	return accessToken, nil
+}
+
+func (AccessTokenLogic) parseScopes(values []string) ([]string, error) {
+	scopes := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		// 支持 scopes=read,topics:write 和多个 scopes 参数两种形式
+		for _, scope := range strings.Split(value, ",") {
+			scope = strings.TrimSpace(scope)
+			if scope == "" || seen[scope] {
+				continue
+			}
+
+			valid := false
+			for _, s := range model.AllScopes {
+				if s == scope {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return nil, errors.New("不支持的权限范围：" + scope)
+			}
+
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if len(scopes) == 0 {
+		return nil, errors.New("至少需要选择一个权限范围")
+	}
+	return scopes, nil
+}
+
+func genAccessToken() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return model.AccessTokenPrefix + hex.EncodeToString(buf), nil
+}
+
+func hashAccessToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
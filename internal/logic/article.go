@@ -366,6 +366,15 @@ func (self ArticleLogic) ParseZhihuArticle(ctx context.Context, articleUrl strin
 func (self ArticleLogic) Publish(ctx context.Context, me *model.Me, form url.Values) (int, error) {
 	objLog := GetLogger(ctx)
 
+	if err := CheckPublish(ctx, NewPublishAction(ctx, me, PublishKindCreate, model.TypeArticle, form)); err != nil {
+		return 0, err
+	}
+
+	publishAt, err := DefaultSchedule.parsePublishAt(form.Get("publish_at"))
+	if err != nil {
+		return 0, err
+	}
+
 	var uid = me.Uid
 
 	article := &model.Article{
@@ -413,6 +422,14 @@ func (self ArticleLogic) Publish(ctx context.Context, me *model.Me, form url.Val
 		}
 	}
 
+	if !publishAt.IsZero() {
+		// 定时发布：ctime 先记为发布时间，到时间后再改为实际发布的时间
+		article.Status = model.ArticleStatusScheduled
+		article.PublishAt = publishAt
+		article.PubDate = times.Format("Y-m-d H:i:s", publishAt)
+		article.Ctime = model.OftenTime(publishAt)
+	}
+
 	article.BeforeInsert()
 	newID, err := db.NextID("articles")
 	if err != nil {
@@ -458,6 +475,11 @@ func (self ArticleLogic) Publish(ctx context.Context, me *model.Me, form url.Val
 		return 0, err
 	}
 
+	if article.Status == model.ArticleStatusScheduled {
+		// 到时间后再发动态、通知观察者
+		return article.Id, nil
+	}
+
 	article.AfterLoad()
 	article.AfterInsert()
 
@@ -563,7 +585,7 @@ func (ArticleLogic) FindLastList(beginTime string, limit int) ([]*model.Article,
 	ctx := context.Background()
 	filter := bson.M{
 		"ctime":  bson.M{"$gt": beginTime},
-		"status": bson.M{"$ne": model.ArticleStatusOffline},
+		"status": bson.M{"$lt": model.ArticleStatusOffline},
 	}
 	findOpts := options.Find().
 		SetSort(bson.D{{"cmtnum", -1}, {"likenum", -1}, {"viewnum", -1}}).
@@ -685,7 +707,10 @@ func (self ArticleLogic) FindTaGCTTArticles(ctx context.Context, translator stri
 		return nil
 	}
 
-	articleCursor, err := db.GetCollection("articles").Find(ctx, bson.M{"_id": bson.M{"$in": articleIds}})
+	articleCursor, err := db.GetCollection("articles").Find(ctx, bson.M{
+		"_id":    bson.M{"$in": articleIds},
+		"status": bson.M{"$lt": model.ArticleStatusOffline},
+	})
 	if err != nil {
 		objLog.Errorln("ArticleLogic FindTaGCTTArticles article error:", err)
 		return nil
@@ -1205,7 +1230,7 @@ func (ArticleLogic) FindByIdAndPreNext(ctx context.Context, id int) (curArticle
 
 	filter := bson.M{
 		"_id":    bson.M{"$gte": id - 5, "$lte": id + 5},
-		"status": bson.M{"$ne": model.ArticleStatusOffline},
+		"status": bson.M{"$lt": model.ArticleStatusOffline},
 	}
 
 	cursor, findErr := db.GetCollection("articles").Find(ctx, filter)
@@ -1294,6 +1319,12 @@ func (ArticleLogic) FindArticleGCTT(ctx context.Context, article *model.Article)
 
 // Modify 修改文章信息
 func (ArticleLogic) Modify(ctx context.Context, user *model.Me, form url.Values) (errMsg string, err error) {
+	err = CheckPublish(ctx, NewPublishAction(ctx, user, PublishKindModify, model.TypeArticle, form))
+	if err != nil {
+		errMsg = err.Error()
+		return
+	}
+
 	idInt := goutils.MustInt(form.Get("id"))
 
 	article := &model.Article{}
@@ -1307,11 +1338,17 @@ func (ArticleLogic) Modify(ctx context.Context, user *model.Me, form url.Values)
 	}
 	article.AfterLoad()
 
-	if !CanEdit(user, article) {
+	if !CanEdit(ctx, user, article) {
 		err = NotModifyAuthorityErr
 		return
 	}
 
+	summary, err := DefaultRevision.checkSummary(form.Get("summary"))
+	if err != nil {
+		errMsg = err.Error()
+		return
+	}
+
 	form.Set("op_user", user.Username)
 
 	fields := []string{
@@ -1335,6 +1372,15 @@ func (ArticleLogic) Modify(ctx context.Context, user *model.Me, form url.Values)
 		return
 	}
 
+	title, content := article.Title, article.Content
+	if val, ok := setDoc["title"]; ok {
+		title = val.(string)
+	}
+	if val, ok := setDoc["content"]; ok {
+		content = val.(string)
+	}
+	DefaultRevision.record(ctx, model.TypeArticle, idInt, DefaultRevision.snapshot(article), user.Uid, title, content, summary, 0)
+
 	go modifyObservable.NotifyObservers(user.Uid, model.TypeArticle, idInt)
 
 	return
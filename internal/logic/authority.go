@@ -125,6 +125,53 @@ func (self AuthorityLogic) HasAuthority(user *model.Me, route string) bool {
 	return false
 }
 
+// HasRoleAuthority 判断用户是否有某个权限，直接使用 me 中的角色和内存中的角色权限，不读库
+func (self AuthorityLogic) HasRoleAuthority(user *model.Me, route string) bool {
+	if user == nil || user.Uid == 0 {
+		return false
+	}
+	if user.IsRoot {
+		return true
+	}
+
+	aidMap := self.roleAuthority(user.RoleIds)
+
+	authLocker.RLock()
+	defer authLocker.RUnlock()
+
+	for _, authority := range Authorities {
+		if route == authority.Route && aidMap[authority.Aid] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RoleAuthorityRoutes 用户拥有的所有权限（route），同样只使用内存数据
+func (self AuthorityLogic) RoleAuthorityRoutes(user *model.Me) []string {
+	routes := make([]string, 0, 8)
+	if user == nil || user.Uid == 0 {
+		return routes
+	}
+
+	aidMap := self.roleAuthority(user.RoleIds)
+
+	authLocker.RLock()
+	defer authLocker.RUnlock()
+
+	for _, authority := range Authorities {
+		if authority.Route == "" {
+			continue
+		}
+		if user.IsRoot || aidMap[authority.Aid] {
+			routes = append(routes, authority.Route)
+		}
+	}
+
+	return routes
+}
+
 func (AuthorityLogic) FindAuthoritiesByPage(ctx context.Context, conds map[string]string, curPage, limit int) ([]*model.Authority, int) {
 	objLog := GetLogger(ctx)
 
@@ -246,3 +293,17 @@ func (AuthorityLogic) userAuthority(user *model.Me) (map[int]bool, error) {
 
 	return aidMap, nil
 }
+
+func (AuthorityLogic) roleAuthority(roleIds []int) map[int]bool {
+	roleAuthLocker.RLock()
+	defer roleAuthLocker.RUnlock()
+
+	aidMap := make(map[int]bool)
+	for _, roleId := range roleIds {
+		for _, aid := range RoleAuthorities[roleId] {
+			aidMap[aid] = true
+		}
+	}
+
+	return aidMap
+}
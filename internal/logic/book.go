@@ -27,6 +27,7 @@ import (
 const (
 	WsMsgNotify = iota // 通知消息
 	WsMsgOnline        // 发送在线用户数（和需要时也发历史最高）
+	WsMsgPoll          // 投票结果有变化
 )
 
 const MessageQueueLen = 3
@@ -0,0 +1,291 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 每次最多结算的到期悬赏数，剩下的下次再结算
+const bountySettleBatch = 100
+
+// 没有配置时悬赏的默认规则
+var defaultBountySetting = map[string]int{
+	model.KeyBountyMin:        50,
+	model.KeyBountyMaxDays:    30,
+	model.KeyBountyExpireRule: model.BountyExpireLiked,
+	model.KeyBountyMinLikes:   2,
+}
+
+var (
+	errBountyBalance = errors.New("铜币不够")
+	// 已经被其他请求（采纳、定时任务）结算，用于回滚事务
+	errBountySettled = errors.New("悬赏已经结算")
+)
+
+type BountyLogic struct{}
+
+var DefaultBounty = BountyLogic{}
+
+// Offer 提问者给问答主题 tid 悬赏 amount 个铜币，期限 days 天。铜币先从提问者账户扣除，由系统托管
+func (self BountyLogic) Offer(ctx context.Context, me *model.Me, tid, amount, days int) (*model.Bounty, error) {
+	objLog := GetLogger(ctx)
+
+	topic := &model.Topic{}
+	err := db.GetCollection("topics").FindOne(ctx, bson.M{"_id": tid}).Decode(topic)
+	if err != nil || topic.Flag > model.FlagNormal {
+		return nil, errors.New("主题不存在")
+	}
+	if topic.Uid != me.Uid {
+		return nil, errors.New("只有提问者可以悬赏")
+	}
+	if !topic.Question {
+		return nil, errors.New("只有问答模式的主题可以悬赏")
+	}
+	if topic.AnswerCid != 0 {
+		return nil, errors.New("已经采纳了答案，不能再悬赏")
+	}
+
+	if minAmount := self.setting(model.KeyBountyMin); amount < minAmount {
+		return nil, fmt.Errorf("悬赏至少 %d 个铜币", minAmount)
+	}
+	if maxDays := self.setting(model.KeyBountyMaxDays); days < 1 || days > maxDays {
+		return nil, fmt.Errorf("悬赏期限为 1 到 %d 天", maxDays)
+	}
+
+	now := time.Now()
+	bounty := &model.Bounty{
+		Tid:       tid,
+		Uid:       me.Uid,
+		Amount:    amount,
+		Deadline:  now.Add(time.Duration(days) * 24 * time.Hour),
+		Status:    model.BountyStatusOpen,
+		CreatedAt: now,
+	}
+	bounty.Id, err = db.NextID("bounty")
+	if err != nil {
+		objLog.Errorln("BountyLogic Offer NextID error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+
+	session, err := db.GetClient().StartSession()
+	if err != nil {
+		objLog.Errorln("BountyLogic Offer StartSession error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// tid 有唯一索引，同一主题并发悬赏时只有一个成功
+		if _, txErr := db.GetCollection("bounty").InsertOne(sessCtx, bounty); txErr != nil {
+			return nil, txErr
+		}
+
+		desc := fmt.Sprintf(`悬赏问题 › <a href="/topics/%d">%s</a>`, tid, topic.Title)
+		return nil, self.changeBalance(sessCtx, me.Uid, model.MissionTypeBounty, -amount, desc)
+	})
+	if err != nil {
+		if err == errBountyBalance {
+			return nil, err
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("该主题已经悬赏过了")
+		}
+		objLog.Errorln("BountyLogic Offer transaction error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+
+	return bounty, nil
+}
+
+// FindByTid 主题的悬赏，没有时返回 nil
+func (BountyLogic) FindByTid(ctx context.Context, tid int) *model.Bounty {
+	bounty := &model.Bounty{}
+	err := db.GetCollection("bounty").FindOne(ctx, bson.M{"tid": tid}).Decode(bounty)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			GetLogger(ctx).Errorln("BountyLogic FindByTid error:", err)
+		}
+		return nil
+	}
+	return bounty
+}
+
+// isOpen 主题是否有进行中的悬赏
+func (self BountyLogic) isOpen(ctx context.Context, tid int) bool {
+	bounty := self.FindByTid(ctx, tid)
+	return bounty != nil && bounty.Status == model.BountyStatusOpen
+}
+
+// SettleExpired 结算到期的悬赏，定时执行
+func (self BountyLogic) SettleExpired() {
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.D{{Key: "deadline", Value: 1}}).SetLimit(bountySettleBatch)
+	cursor, err := db.GetCollection("bounty").Find(ctx, bson.M{
+		"status":   model.BountyStatusOpen,
+		"deadline": bson.M{"$lte": time.Now()},
+	}, opts)
+	if err != nil {
+		logger.Errorln("BountyLogic SettleExpired find error:", err)
+		return
+	}
+	bounties := make([]*model.Bounty, 0)
+	err = cursor.All(ctx, &bounties)
+	cursor.Close(ctx)
+	if err != nil {
+		logger.Errorln("BountyLogic SettleExpired decode error:", err)
+		return
+	}
+
+	for _, bounty := range bounties {
+		if err = self.settle(ctx, bounty); err != nil {
+			logger.Errorln("BountyLogic SettleExpired settle error:", err, "bounty:", bounty.Id)
+		}
+	}
+}
+
+// settleTopic 主题采纳答案后，结算它进行中的悬赏
+func (self BountyLogic) settleTopic(ctx context.Context, tid int) {
+	bounty := self.FindByTid(ctx, tid)
+	if bounty == nil || bounty.Status != model.BountyStatusOpen {
+		return
+	}
+	if err := self.settle(ctx, bounty); err != nil {
+		// 没结算成功的，到期时由定时任务按采纳的答案结算
+		GetLogger(ctx).Errorln("BountyLogic settleTopic error:", err, "bounty:", bounty.Id)
+	}
+}
+
+// settle 结算悬赏：采纳了答案的给回答者；到期未采纳的按规则给最多赞的回答或退回提问者。
+// 主题已删除或者得到悬赏的是提问者自己，都退回。
+// 状态用条件更新抢占，和转账在同一事务中，采纳和定时任务并发时只结算一次
+func (self BountyLogic) settle(ctx context.Context, bounty *model.Bounty) error {
+	topic := &model.Topic{}
+	db.GetCollection("topics").FindOne(ctx, bson.M{"_id": bounty.Tid}).Decode(topic)
+
+	status, winner := model.BountyStatusRefunded, (*model.Comment)(nil)
+	if topic.Tid != 0 && topic.Flag <= model.FlagNormal {
+		if topic.AnswerCid != 0 {
+			if comment, err := DefaultComment.FindById(topic.AnswerCid); err == nil {
+				status, winner = model.BountyStatusAccepted, comment
+			}
+		} else if time.Now().Before(bounty.Deadline) {
+			return nil
+		} else if self.setting(model.KeyBountyExpireRule) == model.BountyExpireLiked {
+			if comment := self.topLiked(ctx, bounty); comment != nil {
+				status, winner = model.BountyStatusLiked, comment
+			}
+		}
+	}
+	if winner != nil && winner.Uid == bounty.Uid {
+		status, winner = model.BountyStatusRefunded, nil
+	}
+
+	change := bson.M{"status": status, "settled_at": time.Now()}
+	if winner != nil {
+		change["winner_cid"] = winner.Cid
+		change["winner_uid"] = winner.Uid
+	}
+
+	session, err := db.GetClient().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		result, txErr := db.GetCollection("bounty").UpdateOne(sessCtx,
+			bson.M{"_id": bounty.Id, "status": model.BountyStatusOpen},
+			bson.M{"$set": change})
+		if txErr != nil {
+			return nil, txErr
+		}
+		if result.ModifiedCount == 0 {
+			return nil, errBountySettled
+		}
+
+		link := fmt.Sprintf(`<a href="/topics/%d">%s</a>`, bounty.Tid, topic.Title)
+		switch status {
+		case model.BountyStatusAccepted:
+			return nil, self.changeBalance(sessCtx, winner.Uid, model.MissionTypeBountyAward, bounty.Amount, "回答被采纳，获得问题 › "+link+" 的悬赏")
+		case model.BountyStatusLiked:
+			return nil, self.changeBalance(sessCtx, winner.Uid, model.MissionTypeBountyAward, bounty.Amount, "悬赏到期，最多赞的回答获得问题 › "+link+" 的悬赏")
+		}
+		return nil, self.changeBalance(sessCtx, bounty.Uid, model.MissionTypeBountyRefund, bounty.Amount, "问题 › "+link+" 的悬赏未发放，退回")
+	})
+	if err == errBountySettled {
+		return nil
+	}
+	return err
+}
+
+// topLiked 到期时赞最多的回答（不含提问者自己的），赞数相同时先回复的优先
+func (self BountyLogic) topLiked(ctx context.Context, bounty *model.Bounty) *model.Comment {
+	filter := DefaultComment.addFlagFilter(bson.M{
+		"objid":   bounty.Tid,
+		"objtype": model.TypeTopic,
+		"uid":     bson.M{"$ne": bounty.Uid},
+		"likenum": bson.M{"$gte": self.setting(model.KeyBountyMinLikes)},
+	})
+	opts := options.FindOne().SetSort(bson.D{{Key: "likenum", Value: -1}, {Key: "_id", Value: 1}})
+
+	comment := &model.Comment{}
+	err := db.GetCollection("comments").FindOne(ctx, filter, opts).Decode(comment)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			GetLogger(ctx).Errorln("BountyLogic topLiked error:", err)
+		}
+		return nil
+	}
+	return comment
+}
+
+// changeBalance 在事务中增减用户铜币并记账。扣减时余额不够返回 errBountyBalance
+func (BountyLogic) changeBalance(ctx context.Context, uid, typ, num int, desc string) error {
+	filter := bson.M{"_id": uid}
+	if num < 0 {
+		filter["balance"] = bson.M{"$gte": -num}
+	}
+
+	user := &model.User{}
+	err := db.GetCollection("user_info").FindOneAndUpdate(ctx, filter,
+		bson.M{"$inc": bson.M{"balance": num}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errBountyBalance
+		}
+		return err
+	}
+
+	return DefaultUserRich.add(ctx, &model.UserBalanceDetail{
+		Uid:       uid,
+		Type:      typ,
+		Num:       num,
+		Balance:   user.Balance,
+		Desc:      desc,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (BountyLogic) setting(key string) int {
+	if val, ok := UserSetting[key]; ok {
+		return val
+	}
+	return defaultBountySetting[key]
+}
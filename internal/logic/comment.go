@@ -13,11 +13,13 @@ import (
 	"math"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/studygolang/studygolang/db"
 	"github.com/studygolang/studygolang/internal/model"
+	"github.com/studygolang/studygolang/util"
 
 	"github.com/fatih/structs"
 	"github.com/polaris1119/goutils"
@@ -34,13 +36,16 @@ type CommentLogic struct{}
 
 var DefaultComment = CommentLogic{}
 
+// 回复某一楼层
+var floorReg = regexp.MustCompile(`#(\d+)楼`)
+
 // FindObjComments 获得某个对象的所有评论
 // owner: 被评论对象属主
 func (self CommentLogic) FindObjComments(ctx context.Context, objid, objtype int, owner, lastCommentUid int) (comments []map[string]interface{}, ownerUser, lastReplyUser *model.User) {
 	objLog := GetLogger(ctx)
 
 	coll := db.GetCollection("comments")
-	filter := bson.M{"objid": objid, "objtype": objtype, "content": bson.M{"$ne": ""}}
+	filter := self.addFlagFilter(bson.M{"objid": objid, "objtype": objtype, "content": bson.M{"$ne": ""}})
 
 	cursor, err := coll.Find(ctx, filter)
 	if err != nil {
@@ -82,7 +87,7 @@ func (self CommentLogic) FindObjectComments(ctx context.Context, objid, objtype,
 	objLog := GetLogger(ctx)
 
 	coll := db.GetCollection("comments")
-	filter := bson.M{"objid": objid, "objtype": objtype}
+	filter := self.addFlagFilter(bson.M{"objid": objid, "objtype": objtype})
 
 	total, err := coll.CountDocuments(ctx, filter)
 	if err != nil {
@@ -153,7 +158,7 @@ func (self CommentLogic) FindComment(ctx context.Context, cid, objid, objtype in
 	self.decodeCmtContentForShow(ctx, comment, false)
 
 	findOpts := options.Find().SetLimit(2)
-	cursor, err := coll.Find(ctx, bson.M{"objid": objid, "objtype": objtype, "_id": bson.M{"$ne": cid}}, findOpts)
+	cursor, err := coll.Find(ctx, self.addFlagFilter(bson.M{"objid": objid, "objtype": objtype, "_id": bson.M{"$ne": cid}}), findOpts)
 	if err != nil {
 		objLog.Errorln("CommentLogic FindComment Find more error:", err)
 		return comment, nil
@@ -190,7 +195,7 @@ func (CommentLogic) Total(objtypes ...int) int64 {
 // 如果 uid!=0，表示获取某人的评论；
 // 如果 objtype!=-1，表示获取某类型的评论；
 func (self CommentLogic) FindRecent(ctx context.Context, uid, objtype, limit int) []*model.Comment {
-	filter := bson.M{"content": bson.M{"$ne": ""}}
+	filter := self.addFlagFilter(bson.M{"content": bson.M{"$ne": ""}})
 	if uid != 0 {
 		filter["uid"] = uid
 	}
@@ -249,6 +254,17 @@ func (self CommentLogic) Publish(ctx context.Context, uid, objid int, form url.V
 	objLog := GetLogger(ctx)
 
 	objtype := goutils.MustInt(form.Get("objtype"))
+
+	// 被版主锁定的主题不能回复
+	if objtype == model.TypeTopic && DefaultTopic.findByTid(objid).CloseReply {
+		return nil, errors.New("该主题已被锁定，不能回复")
+	}
+
+	action := NewPublishAction(ctx, publishUser(ctx, uid), PublishKindComment, objtype, form)
+	action.Title = ""
+	if err := CheckPublish(ctx, action); err != nil {
+		return nil, err
+	}
 	comment := &model.Comment{
 		Objid:   objid,
 		Objtype: objtype,
@@ -256,6 +272,9 @@ func (self CommentLogic) Publish(ctx context.Context, uid, objid int, form url.V
 		Content: form.Get("content"),
 		Ctime:   model.OftenTime(time.Now()),
 	}
+	if action.Review {
+		comment.Flag = model.FlagPending
+	}
 
 	coll := db.GetCollection("comments")
 
@@ -275,6 +294,11 @@ func (self CommentLogic) Publish(ctx context.Context, uid, objid int, form url.V
 		return tmpCmt, nil
 	}
 
+	parent, err := self.findParent(ctx, objid, objtype, form)
+	if err != nil {
+		return nil, err
+	}
+
 	// 入评论库
 	cid, idErr := db.NextID("comments")
 	if idErr != nil {
@@ -282,6 +306,7 @@ func (self CommentLogic) Publish(ctx context.Context, uid, objid int, form url.V
 		return nil, idErr
 	}
 	comment.Cid = cid
+	setCommentParent(comment, parent)
 
 	_, err = coll.InsertOne(ctx, comment)
 	if err != nil {
@@ -290,6 +315,22 @@ func (self CommentLogic) Publish(ctx context.Context, uid, objid int, form url.V
 	}
 	self.decodeCmtContentForShow(ctx, comment, true)
 
+	if action.Review {
+		// 审核通过后再更新被评论对象、发通知
+		DefaultModeration.hold(ctx, action, model.TypeComment, comment.Cid, uid, nil)
+		return comment, nil
+	}
+
+	self.published(ctx, comment, form)
+
+	return comment, nil
+}
+
+// published 评论发布（或审核通过）后：通知被评论者更新、通知观察者、发系统消息
+func (self CommentLogic) published(ctx context.Context, comment *model.Comment, form url.Values) {
+	objLog := GetLogger(ctx)
+
+	objid, objtype, uid := comment.Objid, comment.Objtype, comment.Uid
 	if commenter, ok := commenters[objtype]; ok {
 		now := time.Now()
 
@@ -301,9 +342,15 @@ func (self CommentLogic) Publish(ctx context.Context, uid, objid int, form url.V
 
 	go commentObservable.NotifyObservers(uid, objtype, comment.Cid)
 
-	go self.sendSystemMsg(ctx, uid, objid, objtype, comment.Cid, form)
+	// 回复评论的，没有指定要通知的人时通知被回复者
+	if parent := self.incrReplies(ctx, comment.ParentCid, 1); parent != nil && form.Get("uid") == "" {
+		if form == nil {
+			form = url.Values{}
+		}
+		form.Set("uid", strconv.Itoa(parent.Uid))
+	}
 
-	return comment, nil
+	go self.sendSystemMsg(ctx, uid, objid, objtype, comment.Cid, form)
 }
 
 func (CommentLogic) sendSystemMsg(ctx context.Context, uid, objid, objtype, cid int, form url.Values) {
@@ -339,13 +386,37 @@ func (CommentLogic) sendSystemMsg(ctx context.Context, uid, objid, objtype, cid
 func (CommentLogic) Modify(ctx context.Context, cid int, content string) (errMsg string, err error) {
 	objLog := GetLogger(ctx)
 
-	_, err = db.GetCollection("comments").UpdateOne(ctx, bson.M{"_id": cid}, bson.M{"$set": bson.M{"content": content}})
+	comment := &model.Comment{}
+	err = db.GetCollection("comments").FindOne(ctx, bson.M{"_id": cid}).Decode(comment)
+	if err != nil {
+		objLog.Errorf("获取评论 【%d】 失败：%s", cid, err)
+		errMsg = "评论不存在"
+		return
+	}
+
+	action := NewPublishAction(ctx, publishUser(ctx, comment.Uid), PublishKindModify, model.TypeComment, nil)
+	action.Content = content
+	if err = CheckPublish(ctx, action); err != nil {
+		errMsg = err.Error()
+		return
+	}
+	content = action.Content
+
+	change := bson.M{"content": content}
+	if action.Review {
+		change["flag"] = model.FlagPending
+	}
+	_, err = db.GetCollection("comments").UpdateOne(ctx, bson.M{"_id": cid}, bson.M{"$set": change})
 	if err != nil {
 		objLog.Errorf("更新评论内容 【%d】 失败：%s", cid, err)
 		errMsg = "对不起，服务器内部错误，请稍后再试！"
 		return
 	}
 
+	if action.Review {
+		DefaultModeration.hold(ctx, action, model.TypeComment, cid, comment.Uid, &revisionSnapshot{content: comment.Content})
+	}
+
 	return
 }
 
@@ -403,15 +474,18 @@ func (CommentLogic) FindById(cid int) (*model.Comment, error) {
 }
 
 func (CommentLogic) decodeCmtContent(ctx context.Context, comment *model.Comment) string {
-	// 安全过滤
-	content := template.HTMLEscapeString(comment.Content)
+	// 先渲染 markdown 并做安全过滤，再在文本中处理 @ 和楼层；
+	// 之前生成的链接会被 markdown 当作内嵌 HTML，出现在代码中的也会被替换
+	content := DefaultRender.Markdown(ctx, comment.Content).HTML
+
 	// @别人
-	content = parseAtUser(ctx, content)
+	content = linkAtUser(ctx, content)
 
 	// 回复某一楼层
-	reg := regexp.MustCompile(`#(\d+)楼`)
 	url := fmt.Sprintf("%s%d#comment", model.PathUrlMap[comment.Objtype], comment.Objid)
-	content = reg.ReplaceAllString(content, `<a href="`+url+`$1" title="$1">#$1<span>楼</span></a>`)
+	content = util.LinkifyText(content, floorReg, func(match []string) string {
+		return `<a href="` + url + match[1] + `" title="` + match[1] + `">#` + match[1] + `<span>楼</span></a>`
+	})
 
 	comment.Content = content
 
@@ -420,19 +494,17 @@ func (CommentLogic) decodeCmtContent(ctx context.Context, comment *model.Comment
 
 // decodeCmtContentForShow 采用引用的方式显示对其他楼层的回复
 func (CommentLogic) decodeCmtContentForShow(ctx context.Context, comment *model.Comment, isEscape bool) {
-	// 安全过滤
-	content := template.HTMLEscapeString(comment.Content)
+	content := strings.TrimSpace(comment.Content)
 
 	// 回复某一楼层
-	reg := regexp.MustCompile(`#(\d+)楼 @([a-zA-Z0-9_-]+)`)
-	matches := reg.FindStringSubmatch(content)
+	matches := replyFloorReg.FindStringSubmatch(content)
 	if len(matches) > 2 {
 		comment.ReplyFloor = goutils.MustInt(matches[1])
 		content = strings.TrimSpace(content[len(matches[0]):])
 	}
 
-	// @别人
-	content = parseAtUser(ctx, content)
+	// 渲染 markdown 并做安全过滤，再处理 @别人
+	content = linkAtUser(ctx, DefaultRender.Markdown(ctx, content).HTML)
 
 	comment.Content = content
 }
@@ -447,7 +519,7 @@ func (self CommentLogic) FindAll(ctx context.Context, paginator *Paginator, orde
 	objLog := GetLogger(ctx)
 
 	coll := db.GetCollection("comments")
-	filter := bson.M{"content": bson.M{"$ne": ""}}
+	filter := self.addFlagFilter(bson.M{"content": bson.M{"$ne": ""}})
 	if querystring != "" {
 		for k, v := range buildFilter(querystring, args...) {
 			filter[k] = v
@@ -519,16 +591,17 @@ func (CommentLogic) EnrichWithUsers(ctx context.Context, comments []*model.Comme
 	for i, c := range comments {
 		m := structs.Map(c)
 		m["user"] = userMap[c.Uid]
+		m["content_html"] = DefaultRender.Markdown(ctx, c.Content).HTML
 		result[i] = m
 	}
 	return result
 }
 
 // Count 获取评论数
-func (CommentLogic) Count(ctx context.Context, querystring string, args ...interface{}) int64 {
+func (self CommentLogic) Count(ctx context.Context, querystring string, args ...interface{}) int64 {
 	objLog := GetLogger(ctx)
 
-	filter := bson.M{"content": bson.M{"$ne": ""}}
+	filter := self.addFlagFilter(bson.M{"content": bson.M{"$ne": ""}})
 	if querystring != "" {
 		for k, v := range buildFilter(querystring, args...) {
 			filter[k] = v
@@ -543,6 +616,12 @@ func (CommentLogic) Count(ctx context.Context, querystring string, args ...inter
 	return total
 }
 
+// addFlagFilter 过滤掉已删除和待审核的评论（老数据没有 flag 字段，不能用 $lt）
+func (CommentLogic) addFlagFilter(filter bson.M) bson.M {
+	filter["flag"] = bson.M{"$nin": []int{model.FlagAuditDelete, model.FlagUserDelete, model.FlagPending}}
+	return filter
+}
+
 func (CommentLogic) filterDelObjectCmt(comments []*model.Comment) []*model.Comment {
 	resultCmts := make([]*model.Comment, 0, len(comments))
 	for _, comment := range comments {
@@ -602,9 +681,21 @@ func (CommentLogic) Delete(ctx context.Context, cid, uid int, isRoot bool) error
 	if comment.Uid != uid && !isRoot {
 		return errors.New("无权删除")
 	}
-	_, err = db.GetCollection("comments").DeleteOne(ctx, bson.M{"_id": cid})
-	if err != nil {
-		return err
+	// 有回复的只标记删除，评论树中显示为已删除，保留下面的回复
+	if comment.Replies > 0 {
+		_, err = db.GetCollection("comments").UpdateOne(ctx, bson.M{"_id": cid}, bson.M{"$set": bson.M{"flag": model.FlagUserDelete}})
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err = db.GetCollection("comments").DeleteOne(ctx, bson.M{"_id": cid})
+		if err != nil {
+			return err
+		}
+		// 待审核的还没有计入回复数
+		if comment.Flag != model.FlagPending {
+			DefaultComment.incrReplies(ctx, comment.ParentCid, -1)
+		}
 	}
 
 	go decrementCommentCount(comment.Objid, comment.Objtype)
@@ -0,0 +1,431 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/goutils"
+	"github.com/polaris1119/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 评论树的排序方式
+const (
+	CommentSortOldest = "oldest"
+	CommentSortNewest = "newest"
+	CommentSortLiked  = "liked" // 赞最多的在前
+)
+
+const (
+	// CommentTreePerNum 评论树每页的顶层评论数（指定 parent 时为它的直接回复数）
+	CommentTreePerNum = 20
+	// 默认展开的层数（含顶层），更深的由「继续这个讨论」加载
+	commentTreeDepth    = 3
+	commentTreeMaxDepth = 8
+	// 每条评论下最多显示的回复数，其余的由「加载更多」加载
+	commentTreeChildNum = 5
+	// 一次最多加载的回复数，避免超长的讨论一次取太多
+	commentTreeMaxLoad = 1000
+	// 迁移老评论时每批处理的数量
+	commentUpgradeBatch = 500
+)
+
+// 老的回复方式：内容以「#楼层 @用户名」开头
+var replyFloorReg = regexp.MustCompile(`^#(\d+)楼 @([a-zA-Z0-9_-]+)`)
+
+// CommentNode 评论树的节点
+type CommentNode struct {
+	*model.Comment
+	User *model.User `json:"user"`
+	// Deleted 已删除但还有回复的评论，只显示占位，不返回内容和作者
+	Deleted  bool           `json:"deleted"`
+	Children []*CommentNode `json:"children"`
+	// More 没显示的直接回复数，请求 parent 为该评论即可加载
+	More int `json:"more"`
+	// Continue 到了展开的最深层但还有回复（继续这个讨论），请求 parent 为该评论即可加载
+	Continue bool `json:"continue"`
+}
+
+// FindTree 对象的评论树。parentCid 为 0 时分页的是顶层评论，否则是该评论的直接回复；
+// 每条评论往下展开 depth 层（含自己）。返回的 parent 为 parentCid 对应的评论。看不到被评论的对象时返回评论不存在
+func (self CommentLogic) FindTree(ctx context.Context, objid, objtype, parentCid int, sortBy string, depth int, paginator *Paginator) (nodes []*CommentNode, parent *model.Comment, err error) {
+	objLog := GetLogger(ctx)
+	coll := db.GetCollection("comments")
+
+	if !CanViewObject(ctx, objtype, objid) {
+		return nil, nil, errors.New("评论不存在")
+	}
+
+	if depth <= 0 {
+		depth = commentTreeDepth
+	} else if depth > commentTreeMaxDepth {
+		depth = commentTreeMaxDepth
+	}
+
+	filter := bson.M{"objid": objid, "objtype": objtype}
+	baseDepth := 0
+	if parentCid > 0 {
+		parent = &model.Comment{}
+		err = coll.FindOne(ctx, bson.M{"_id": parentCid, "objid": objid, "objtype": objtype}).Decode(parent)
+		if err != nil || parent.Flag == model.FlagPending {
+			return nil, nil, errors.New("评论不存在")
+		}
+		if parent.Flag == model.FlagAuditDelete || parent.Flag == model.FlagUserDelete {
+			parent.Content = ""
+		} else {
+			self.decodeTreeContent(ctx, parent)
+		}
+		filter["parent_cid"] = parentCid
+		baseDepth = parent.Depth + 1
+	} else {
+		// 迁移前的老评论没有 parent_cid
+		filter["parent_cid"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	self.addTreeFlagFilter(filter)
+
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		objLog.Errorln("CommentLogic FindTree count error:", err)
+		return nil, nil, errors.New("内部服务错误")
+	}
+	paginator.SetTotal(total)
+
+	opts := options.Find().
+		SetSort(commentTreeSort(sortBy)).
+		SetSkip(int64(paginator.Offset())).
+		SetLimit(int64(paginator.PerPage()))
+	roots := make([]*model.Comment, 0)
+	if err = findComments(ctx, filter, opts, &roots); err != nil {
+		objLog.Errorln("CommentLogic FindTree error:", err)
+		return nil, nil, errors.New("内部服务错误")
+	}
+
+	// 按 path 前缀一次取出所有顶层评论往下 depth 层的回复
+	maxDepth := baseDepth + depth - 1
+	descendants := make([]*model.Comment, 0)
+	if len(roots) > 0 && depth > 1 {
+		prefixes := make(bson.A, 0, len(roots))
+		for _, root := range roots {
+			if root.Path != "" && root.Replies > 0 {
+				prefixes = append(prefixes, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(root.Path)})
+			}
+		}
+		if len(prefixes) > 0 {
+			descFilter := bson.M{
+				"objid":   objid,
+				"objtype": objtype,
+				"path":    bson.M{"$in": prefixes},
+				"depth":   bson.M{"$gt": baseDepth, "$lte": maxDepth},
+				"flag":    bson.M{"$ne": model.FlagPending},
+			}
+			descOpts := options.Find().SetSort(bson.D{{Key: "path", Value: 1}}).SetLimit(commentTreeMaxLoad)
+			if err = findComments(ctx, descFilter, descOpts, &descendants); err != nil {
+				objLog.Errorln("CommentLogic FindTree descendants error:", err)
+				return nil, nil, errors.New("内部服务错误")
+			}
+		}
+	}
+
+	uids := make([]int, 0, len(roots)+len(descendants))
+	nodeMap := make(map[int]*CommentNode, len(roots)+len(descendants))
+	nodes = make([]*CommentNode, 0, len(roots))
+	for _, root := range roots {
+		node := &CommentNode{Comment: root}
+		nodeMap[root.Cid] = node
+		nodes = append(nodes, node)
+		uids = append(uids, root.Uid)
+	}
+	// 按 path 排序，父评论一定在前面
+	for _, comment := range descendants {
+		parentNode, ok := nodeMap[comment.ParentCid]
+		if !ok {
+			continue
+		}
+		node := &CommentNode{Comment: comment}
+		nodeMap[comment.Cid] = node
+		parentNode.Children = append(parentNode.Children, node)
+		uids = append(uids, comment.Uid)
+	}
+
+	userMap := DefaultUser.FindUserInfos(ctx, uids)
+	less := commentTreeLess(sortBy)
+	nodes = self.buildTree(ctx, nodes, userMap, less, maxDepth)
+	return nodes, parent, nil
+}
+
+// buildTree 填充节点的内容和作者，排序、截断子节点，去掉没有可见回复的已删除评论
+func (self CommentLogic) buildTree(ctx context.Context, nodes []*CommentNode, userMap map[int]*model.User, less func(a, b *model.Comment) bool, maxDepth int) []*CommentNode {
+	result := make([]*CommentNode, 0, len(nodes))
+	for _, node := range nodes {
+		sort.SliceStable(node.Children, func(i, j int) bool {
+			return less(node.Children[i].Comment, node.Children[j].Comment)
+		})
+		// 去掉的已删除回复也计入了 Replies，不能算作没显示的
+		removed := len(node.Children)
+		node.Children = self.buildTree(ctx, node.Children, userMap, less, maxDepth)
+		removed -= len(node.Children)
+
+		if node.Depth >= maxDepth {
+			node.Continue = node.Replies > 0
+		} else {
+			loaded := len(node.Children)
+			if loaded > commentTreeChildNum {
+				node.Children = node.Children[:commentTreeChildNum]
+			}
+			if node.Replies-removed > loaded {
+				loaded = node.Replies - removed
+			}
+			node.More = loaded - len(node.Children)
+		}
+
+		if node.Flag == model.FlagAuditDelete || node.Flag == model.FlagUserDelete {
+			if len(node.Children) == 0 && !node.Continue && node.More == 0 {
+				continue
+			}
+			node.Deleted = true
+			node.Comment.Content = ""
+			node.Comment.Uid = 0
+		} else {
+			self.decodeTreeContent(ctx, node.Comment)
+			node.User = userMap[node.Uid]
+		}
+		if node.Children == nil {
+			node.Children = []*CommentNode{}
+		}
+		result = append(result, node)
+	}
+	return result
+}
+
+// decodeTreeContent 评论树中已经能看出回复关系，去掉老的「#楼层 @用户名」前缀
+func (self CommentLogic) decodeTreeContent(ctx context.Context, comment *model.Comment) {
+	if comment.ParentCid > 0 {
+		comment.Content = strings.TrimSpace(replyFloorReg.ReplaceAllString(strings.TrimSpace(comment.Content), ""))
+	}
+	self.decodeCmtContent(ctx, comment)
+}
+
+// addTreeFlagFilter 和 addFlagFilter 一样，但保留有回复的已删除评论，它们在评论树中显示为占位
+func (CommentLogic) addTreeFlagFilter(filter bson.M) bson.M {
+	deleted := []int{model.FlagAuditDelete, model.FlagUserDelete}
+	filter["$or"] = bson.A{
+		bson.M{"flag": bson.M{"$nin": append(deleted, model.FlagPending)}},
+		bson.M{"flag": bson.M{"$in": deleted}, "replies": bson.M{"$gt": 0}},
+	}
+	return filter
+}
+
+// findParent 发表评论时找到回复的评论：表单中的 parent_cid，或者老的「#楼层 @用户名」回复方式。直接评论对象时返回 nil
+func (self CommentLogic) findParent(ctx context.Context, objid, objtype int, form url.Values) (*model.Comment, error) {
+	coll := db.GetCollection("comments")
+
+	parent := &model.Comment{}
+	if parentCid := goutils.MustInt(form.Get("parent_cid")); parentCid > 0 {
+		err := coll.FindOne(ctx, self.addFlagFilter(bson.M{"_id": parentCid, "objid": objid, "objtype": objtype})).Decode(parent)
+		if err != nil {
+			return nil, errors.New("回复的评论不存在")
+		}
+		return parent, nil
+	}
+
+	matches := replyFloorReg.FindStringSubmatch(strings.TrimSpace(form.Get("content")))
+	if len(matches) < 2 {
+		return nil, nil
+	}
+	filter := self.addFlagFilter(bson.M{"objid": objid, "objtype": objtype, "floor": goutils.MustInt(matches[1])})
+	if err := coll.FindOne(ctx, filter).Decode(parent); err != nil {
+		return nil, nil
+	}
+	return parent, nil
+}
+
+// incrReplies 更新评论 cid 的回复数，返回更新后的评论。cid 为 0 或不存在时返回 nil
+func (CommentLogic) incrReplies(ctx context.Context, cid, num int) *model.Comment {
+	if cid == 0 {
+		return nil
+	}
+
+	comment := &model.Comment{}
+	err := db.GetCollection("comments").FindOneAndUpdate(ctx, bson.M{"_id": cid},
+		bson.M{"$inc": bson.M{"replies": num}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(comment)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			GetLogger(ctx).Errorln("CommentLogic incrReplies error:", err)
+		}
+		return nil
+	}
+	return comment
+}
+
+// UpgradeTree 把老评论迁移到评论树：按「#楼层 @用户名」找到回复的评论，补上 parent_cid、path 和 depth。
+// 迁移期间评论树已经可用，新回复挂在还没迁移的老评论下时，path 和 depth 是按老评论为顶层算的，
+// 老评论迁移后由 upgradeDescendants 一并修正。可以重复执行，已迁移的不会再处理
+func (CommentLogic) UpgradeTree() {
+	ctx := context.Background()
+	coll := db.GetCollection("comments")
+
+	lastCid := 0
+	for {
+		comments := make([]*model.Comment, 0)
+		opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(commentUpgradeBatch)
+		filter := bson.M{"_id": bson.M{"$gt": lastCid}, "path": bson.M{"$exists": false}}
+		if err := findComments(ctx, filter, opts, &comments); err != nil {
+			logger.Errorln("CommentLogic UpgradeTree find error:", err)
+			return
+		}
+
+		for _, comment := range comments {
+			lastCid = comment.Cid
+
+			var parent *model.Comment
+			if matches := replyFloorReg.FindStringSubmatch(strings.TrimSpace(comment.Content)); len(matches) > 1 {
+				parent = &model.Comment{}
+				err := coll.FindOne(ctx, bson.M{
+					"objid":   comment.Objid,
+					"objtype": comment.Objtype,
+					"floor":   goutils.MustInt(matches[1]),
+					"_id":     bson.M{"$lt": comment.Cid},
+				}).Decode(parent)
+				if err != nil {
+					parent = nil
+				}
+			}
+			setCommentParent(comment, parent)
+
+			result, err := coll.UpdateOne(ctx,
+				bson.M{"_id": comment.Cid, "path": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"parent_cid": comment.ParentCid, "path": comment.Path, "depth": comment.Depth}})
+			if err != nil {
+				logger.Errorln("CommentLogic UpgradeTree update error:", err)
+				return
+			}
+			if result.ModifiedCount == 0 || parent == nil {
+				continue
+			}
+			// 待审核的评论通过后才计入回复数
+			if comment.Flag != model.FlagPending {
+				coll.UpdateOne(ctx, bson.M{"_id": parent.Cid}, bson.M{"$inc": bson.M{"replies": 1}})
+			}
+			if err = upgradeDescendants(ctx, comment); err != nil {
+				logger.Errorln("CommentLogic UpgradeTree descendants error:", err)
+				return
+			}
+		}
+		if len(comments) < commentUpgradeBatch {
+			break
+		}
+	}
+	logger.Infoln("CommentLogic UpgradeTree finished")
+}
+
+// upgradeDescendants 老评论迁移前，它下面的新回复按它是顶层评论（path 为 commentPathSeg(cid)）算的位置，
+// 迁移后改成它的 path 开头，depth 也加上它的 depth
+func upgradeDescendants(ctx context.Context, comment *model.Comment) error {
+	oldPath := commentPathSeg(comment.Cid)
+	if comment.Path == oldPath {
+		return nil
+	}
+
+	descendants := make([]*model.Comment, 0)
+	filter := bson.M{
+		"objid":   comment.Objid,
+		"objtype": comment.Objtype,
+		"path":    primitive.Regex{Pattern: "^" + regexp.QuoteMeta(oldPath)},
+	}
+	if err := findComments(ctx, filter, options.Find(), &descendants); err != nil {
+		return err
+	}
+
+	coll := db.GetCollection("comments")
+	for _, desc := range descendants {
+		path, depth := desc.Path, desc.Depth
+		rebaseCommentPath(desc, oldPath, comment)
+		_, err := coll.UpdateOne(ctx,
+			bson.M{"_id": desc.Cid, "path": path, "depth": depth},
+			bson.M{"$set": bson.M{"path": desc.Path, "depth": desc.Depth}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebaseCommentPath 把 path 以 oldPath 开头的回复移到 ancestor 新的位置下面
+func rebaseCommentPath(comment *model.Comment, oldPath string, ancestor *model.Comment) {
+	comment.Path = ancestor.Path + strings.TrimPrefix(comment.Path, oldPath)
+	comment.Depth += ancestor.Depth
+}
+
+// setCommentParent 根据回复的评论（可为 nil）设置评论在树中的位置，评论的 Cid 必须已经有值
+func setCommentParent(comment, parent *model.Comment) {
+	comment.ParentCid, comment.Depth = 0, 0
+	comment.Path = commentPathSeg(comment.Cid)
+	if parent == nil {
+		return
+	}
+
+	comment.ParentCid = parent.Cid
+	comment.Depth = parent.Depth + 1
+	parentPath := parent.Path
+	if parentPath == "" {
+		// 还没迁移的老评论
+		parentPath = commentPathSeg(parent.Cid)
+	}
+	comment.Path = parentPath + comment.Path
+}
+
+// commentPathSeg path 中的一段，定长保证按 path 排序即为先序遍历
+func commentPathSeg(cid int) string {
+	return fmt.Sprintf("%010d/", cid)
+}
+
+func commentTreeSort(sortBy string) bson.D {
+	switch sortBy {
+	case CommentSortNewest:
+		return bson.D{{Key: "_id", Value: -1}}
+	case CommentSortLiked:
+		return bson.D{{Key: "likenum", Value: -1}, {Key: "_id", Value: 1}}
+	}
+	return bson.D{{Key: "_id", Value: 1}}
+}
+
+func commentTreeLess(sortBy string) func(a, b *model.Comment) bool {
+	switch sortBy {
+	case CommentSortNewest:
+		return func(a, b *model.Comment) bool { return a.Cid > b.Cid }
+	case CommentSortLiked:
+		return func(a, b *model.Comment) bool {
+			if a.Likenum != b.Likenum {
+				return a.Likenum > b.Likenum
+			}
+			return a.Cid < b.Cid
+		}
+	}
+	return func(a, b *model.Comment) bool { return a.Cid < b.Cid }
+}
+
+func findComments(ctx context.Context, filter bson.M, opts *options.FindOptions, comments *[]*model.Comment) error {
+	cursor, err := db.GetCollection("comments").Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, comments)
+}
@@ -0,0 +1,214 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/studygolang/studygolang/internal/model"
+)
+
+func TestSetCommentParent(t *testing.T) {
+	tests := []struct {
+		name   string
+		cid    int
+		parent *model.Comment
+		want   model.Comment
+	}{
+		{
+			name: "顶层评论",
+			cid:  12,
+			want: model.Comment{Cid: 12, ParentCid: 0, Depth: 0, Path: "0000000012/"},
+		},
+		{
+			name:   "回复已迁移的评论",
+			cid:    30,
+			parent: &model.Comment{Cid: 20, Depth: 1, Path: "0000000012/0000000020/"},
+			want:   model.Comment{Cid: 30, ParentCid: 20, Depth: 2, Path: "0000000012/0000000020/0000000030/"},
+		},
+		{
+			name:   "回复还没迁移的老评论",
+			cid:    30,
+			parent: &model.Comment{Cid: 20},
+			want:   model.Comment{Cid: 30, ParentCid: 20, Depth: 1, Path: "0000000020/0000000030/"},
+		},
+	}
+	for _, tt := range tests {
+		// 之前的值应该被覆盖
+		comment := &model.Comment{Cid: tt.cid, ParentCid: 99, Depth: 5, Path: "x/"}
+		setCommentParent(comment, tt.parent)
+		if comment.ParentCid != tt.want.ParentCid || comment.Depth != tt.want.Depth || comment.Path != tt.want.Path {
+			t.Errorf("%s: got parent_cid=%d depth=%d path=%s, want parent_cid=%d depth=%d path=%s", tt.name,
+				comment.ParentCid, comment.Depth, comment.Path, tt.want.ParentCid, tt.want.Depth, tt.want.Path)
+		}
+	}
+}
+
+func TestRebaseCommentPath(t *testing.T) {
+	// 20 还没迁移时收到回复 30，30 又收到回复 40；迁移后 20 是 12 的回复
+	ancestor := &model.Comment{Cid: 20}
+	child := &model.Comment{Cid: 30}
+	setCommentParent(child, ancestor)
+	grandchild := &model.Comment{Cid: 40}
+	setCommentParent(grandchild, child)
+
+	oldPath := commentPathSeg(ancestor.Cid)
+	setCommentParent(ancestor, &model.Comment{Cid: 12, Path: "0000000012/"})
+	rebaseCommentPath(child, oldPath, ancestor)
+	rebaseCommentPath(grandchild, oldPath, ancestor)
+
+	// 和迁移后再回复的结果一样
+	wantChild := &model.Comment{Cid: 30}
+	setCommentParent(wantChild, ancestor)
+	wantGrandchild := &model.Comment{Cid: 40}
+	setCommentParent(wantGrandchild, wantChild)
+	for _, tt := range []struct{ got, want *model.Comment }{{child, wantChild}, {grandchild, wantGrandchild}} {
+		if tt.got.Path != tt.want.Path || tt.got.Depth != tt.want.Depth {
+			t.Errorf("cid %d: got path=%s depth=%d, want path=%s depth=%d", tt.got.Cid,
+				tt.got.Path, tt.got.Depth, tt.want.Path, tt.want.Depth)
+		}
+	}
+}
+
+func TestCommentTreeLess(t *testing.T) {
+	comments := []*model.Comment{
+		{Cid: 1, Likenum: 2},
+		{Cid: 2, Likenum: 5},
+		{Cid: 3, Likenum: 2},
+		{Cid: 4, Likenum: 0},
+	}
+	tests := []struct {
+		sortBy string
+		want   []int
+	}{
+		{"", []int{1, 2, 3, 4}},
+		{CommentSortOldest, []int{1, 2, 3, 4}},
+		{CommentSortNewest, []int{4, 3, 2, 1}},
+		// 赞一样多的按时间先后
+		{CommentSortLiked, []int{2, 1, 3, 4}},
+	}
+	for _, tt := range tests {
+		sorted := append([]*model.Comment{}, comments...)
+		less := commentTreeLess(tt.sortBy)
+		sort.Slice(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+		got := make([]int, len(sorted))
+		for i, comment := range sorted {
+			got[i] = comment.Cid
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("commentTreeLess(%q) = %v, want %v", tt.sortBy, got, tt.want)
+		}
+	}
+}
+
+func TestBuildTree(t *testing.T) {
+	newNode := func(cid, depth, replies, flag int, children ...*CommentNode) *CommentNode {
+		return &CommentNode{
+			Comment:  &model.Comment{Cid: cid, Uid: cid, Depth: depth, Replies: replies, Flag: flag, Content: "hello"},
+			Children: children,
+		}
+	}
+	manyChildren := func(n int) []*CommentNode {
+		children := make([]*CommentNode, n)
+		for i := range children {
+			// 倒序放入，检查会排序
+			children[i] = newNode(100+n-i, 1, 0, model.FlagNoAudit)
+		}
+		return children
+	}
+
+	tests := []struct {
+		name     string
+		node     *CommentNode
+		maxDepth int
+		// 期望的直接回复、More、Continue、Deleted；removed 表示整个节点被去掉
+		children []int
+		more     int
+		cont     bool
+		deleted  bool
+		removed  bool
+	}{
+		{
+			name:     "没有回复",
+			node:     newNode(1, 0, 0, model.FlagNoAudit),
+			maxDepth: 2,
+			children: []int{},
+		},
+		{
+			name:     "回复超过显示数时截断",
+			node:     newNode(1, 0, 7, model.FlagNoAudit, manyChildren(7)...),
+			maxDepth: 2,
+			children: []int{101, 102, 103, 104, 105},
+			more:     2,
+		},
+		{
+			name:     "回复数比取到的多（超出加载上限）",
+			node:     newNode(1, 0, 10, model.FlagNoAudit, manyChildren(3)...),
+			maxDepth: 2,
+			children: []int{101, 102, 103},
+			more:     7,
+		},
+		{
+			name:     "到了最深层还有回复",
+			node:     newNode(1, 2, 3, model.FlagNoAudit),
+			maxDepth: 2,
+			children: []int{},
+			cont:     true,
+		},
+		{
+			name:     "已删除但有回复的显示占位",
+			node:     newNode(1, 0, 1, model.FlagUserDelete, newNode(2, 1, 0, model.FlagNoAudit)),
+			maxDepth: 2,
+			children: []int{2},
+			deleted:  true,
+		},
+		{
+			name:     "已删除且回复都已删除的去掉",
+			node:     newNode(1, 0, 1, model.FlagAuditDelete, newNode(2, 1, 0, model.FlagUserDelete)),
+			maxDepth: 2,
+			removed:  true,
+		},
+	}
+
+	self := CommentLogic{}
+	userMap := map[int]*model.User{1: {Uid: 1, Username: "polaris"}}
+	for _, tt := range tests {
+		nodes := self.buildTree(context.Background(), []*CommentNode{tt.node}, userMap, commentTreeLess(CommentSortOldest), tt.maxDepth)
+		if tt.removed {
+			if len(nodes) != 0 {
+				t.Errorf("%s: node should be removed", tt.name)
+			}
+			continue
+		}
+		if len(nodes) != 1 {
+			t.Errorf("%s: got %d nodes, want 1", tt.name, len(nodes))
+			continue
+		}
+
+		node := nodes[0]
+		children := make([]int, len(node.Children))
+		for i, child := range node.Children {
+			children[i] = child.Cid
+		}
+		if !reflect.DeepEqual(children, tt.children) {
+			t.Errorf("%s: children = %v, want %v", tt.name, children, tt.children)
+		}
+		if node.More != tt.more || node.Continue != tt.cont || node.Deleted != tt.deleted {
+			t.Errorf("%s: more=%d continue=%v deleted=%v, want more=%d continue=%v deleted=%v", tt.name,
+				node.More, node.Continue, node.Deleted, tt.more, tt.cont, tt.deleted)
+		}
+		if tt.deleted {
+			if node.Content != "" || node.Uid != 0 || node.User != nil {
+				t.Errorf("%s: deleted node should hide content and author", tt.name)
+			}
+		} else if node.User != userMap[1] {
+			t.Errorf("%s: user not filled", tt.name)
+		}
+	}
+}
@@ -91,7 +91,7 @@ func parseAtUser(ctx context.Context, content string) string {
 }
 
 // CanEdit 判断能否编辑
-func CanEdit(me *model.Me, curModel interface{}) bool {
+func CanEdit(ctx context.Context, me *model.Me, curModel interface{}) bool {
 	if me == nil {
 		return false
 	}
@@ -107,6 +107,11 @@ func CanEdit(me *model.Me, curModel interface{}) bool {
 			return true
 		}
 
+		// 节点（含上级节点）版主，作者本人不需要查询
+		if me.Uid != entity.Uid && DefaultNodeModerator.IsModerator(ctx, me.Uid, entity.Nid) {
+			return true
+		}
+
 		if time.Now().Sub(time.Time(entity.Ctime)) > canEditTime {
 			return false
 		}
@@ -174,6 +179,14 @@ func CanEdit(me *model.Me, curModel interface{}) bool {
 		if me.IsAdmin && roleCanEdit(model.Administrator, me) {
 			return true
 		}
+
+		// 主题下的评论，主题所在节点（含上级节点）的版主
+		if me.Uid != entity.Uid && entity.Objtype == model.TypeTopic {
+			topic := DefaultTopic.findByTid(entity.Objid)
+			if DefaultNodeModerator.IsModerator(ctx, me.Uid, topic.Nid) {
+				return true
+			}
+		}
 		if time.Now().Sub(time.Time(entity.Ctime)) > canEditTime {
 			return false
 		}
@@ -283,7 +296,7 @@ func SpamRecord(ctx context.Context, user *model.Me, maxNum int) {
 		DefaultUser.UpdateUserStatus(ctx, user.Uid, model.UserStatusOutage)
 
 		// 将用户 IP 加入黑名单
-		DefaultRisk.AddBlackIPByUID(user.Uid)
+		DefaultRisk.AddBlackIPByUID(ctx, user.Uid, "半夜频繁发布，疑似垃圾广告")
 
 		DefaultUser.DeleteUserContent(ctx, user.Uid)
 
@@ -0,0 +1,293 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// 每人最多的草稿数
+	maxDraftNum = 50
+	// 草稿内容最大长度（字节）
+	maxDraftContentLen = 200 << 10
+	// Form 中最多的字段数和每个字段的最大长度
+	maxDraftFormFields = 20
+	maxDraftFieldLen   = 1000
+)
+
+// 不放进 Form 的字段：草稿自身的字段，以及请求中和内容无关的字段
+var draftReservedFields = map[string]bool{
+	"id": true, "tid": true, "objtype": true, "objid": true,
+	"title": true, "content": true, "token": true,
+	"captchaid": true, "captchaSolution": true,
+}
+
+// draftPublishFields 发布时从请求中带上的字段（验证码只能用一次，不保存在草稿中）
+var draftPublishFields = []string{"captchaid", "captchaSolution"}
+
+type DraftLogic struct{}
+
+var DefaultDraft = DraftLogic{}
+
+// Save 自动保存草稿。id 为 0 且 objid 为 0 时新建草稿；objid 不为 0 时是修改已有内容的草稿，
+// 同一内容只保留一份。form 中除 title、content 外的字段保存在 Form 中，发布时原样提交
+func (self DraftLogic) Save(ctx context.Context, me *model.Me, id, objtype, objid int, form url.Values) (*model.Draft, error) {
+	objLog := GetLogger(ctx)
+
+	if !self.supported(objtype) {
+		return nil, errors.New("不支持该类型的草稿")
+	}
+
+	draft := &model.Draft{
+		Uid:     me.Uid,
+		Objtype: objtype,
+		Objid:   objid,
+		Title:   form.Get("title"),
+		Content: form.Get("content"),
+		Form:    make(map[string]string),
+	}
+	if len(draft.Title)+len(draft.Content) > maxDraftContentLen {
+		return nil, errors.New("草稿内容太长")
+	}
+	for field := range form {
+		if draftReservedFields[field] {
+			continue
+		}
+		if len(draft.Form) >= maxDraftFormFields {
+			return nil, errors.New("草稿字段太多")
+		}
+		val := form.Get(field)
+		if len(val) > maxDraftFieldLen {
+			return nil, errors.New("草稿字段 " + field + " 太长")
+		}
+		draft.Form[field] = val
+	}
+
+	if objid > 0 {
+		object, err := DefaultRevision.findObject(ctx, me, objtype, objid)
+		if err != nil {
+			return nil, err
+		}
+		if !CanEdit(ctx, me, object) {
+			return nil, NotModifyAuthorityErr
+		}
+	}
+
+	now := time.Now()
+	change := bson.M{
+		"title":      draft.Title,
+		"content":    draft.Content,
+		"form":       draft.Form,
+		"updated_at": now,
+		"expire_at":  now.Add(self.ttl()),
+	}
+
+	coll := db.GetCollection("draft")
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"content": 0})
+	var filter bson.M
+	switch {
+	case id > 0:
+		filter = bson.M{"_id": id, "uid": me.Uid, "expire_at": bson.M{"$gt": now}}
+	case objid > 0:
+		filter = bson.M{"uid": me.Uid, "objtype": objtype, "objid": objid}
+		opts.SetUpsert(true)
+	default:
+		num, err := coll.CountDocuments(ctx, bson.M{"uid": me.Uid, "expire_at": bson.M{"$gt": now}})
+		if err != nil {
+			objLog.Errorln("DraftLogic Save count error:", err)
+			return nil, errors.New("内部服务错误")
+		}
+		if num >= maxDraftNum {
+			return nil, errors.New("草稿太多，请先清理不需要的草稿")
+		}
+
+		draft.Id, err = db.NextID("draft")
+		if err != nil {
+			objLog.Errorln("DraftLogic Save NextID error:", err)
+			return nil, errors.New("内部服务错误")
+		}
+		draft.CreatedAt = now
+		draft.UpdatedAt = now
+		draft.ExpireAt = change["expire_at"].(time.Time)
+		if _, err = coll.InsertOne(ctx, draft); err != nil {
+			objLog.Errorln("DraftLogic Save insert error:", err)
+			return nil, errors.New("内部服务错误")
+		}
+		draft.Content = ""
+		return draft, nil
+	}
+
+	update := bson.M{"$set": change}
+	if objid > 0 {
+		newID, err := db.NextID("draft")
+		if err != nil {
+			objLog.Errorln("DraftLogic Save NextID error:", err)
+			return nil, errors.New("内部服务错误")
+		}
+		update["$setOnInsert"] = bson.M{"_id": newID, "created_at": now}
+	}
+
+	saved := &model.Draft{}
+	err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(saved)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("草稿不存在或已过期")
+		}
+		objLog.Errorln("DraftLogic Save error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	return saved, nil
+}
+
+// FindMine 我的草稿（不含内容），最近修改的在前；objtype 为 0 时不过滤。
+// 过期的草稿由 TTL 索引删除，但删除有延迟，查询时要排除
+func (DraftLogic) FindMine(ctx context.Context, me *model.Me, objtype int, paginator *Paginator) []*model.Draft {
+	objLog := GetLogger(ctx)
+
+	filter := bson.M{"uid": me.Uid, "expire_at": bson.M{"$gt": time.Now()}}
+	if objtype > 0 {
+		filter["objtype"] = objtype
+	}
+
+	coll := db.GetCollection("draft")
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		objLog.Errorln("DraftLogic FindMine count error:", err)
+		return nil
+	}
+	paginator.SetTotal(total)
+
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetProjection(bson.M{"content": 0}).
+		SetSkip(int64(paginator.Offset())).SetLimit(int64(paginator.PerPage()))
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		objLog.Errorln("DraftLogic FindMine error:", err)
+		return nil
+	}
+	defer cursor.Close(ctx)
+
+	drafts := make([]*model.Draft, 0)
+	if err = cursor.All(ctx, &drafts); err != nil {
+		objLog.Errorln("DraftLogic FindMine decode error:", err)
+		return nil
+	}
+	return drafts
+}
+
+// FindOne 获取草稿用于继续编辑。id 为 0 时按 objtype、objid 查找修改已有内容的草稿
+func (DraftLogic) FindOne(ctx context.Context, me *model.Me, id, objtype, objid int) (*model.Draft, error) {
+	filter := bson.M{"_id": id, "uid": me.Uid}
+	if id == 0 {
+		filter = bson.M{"uid": me.Uid, "objtype": objtype, "objid": objid}
+	}
+	filter["expire_at"] = bson.M{"$gt": time.Now()}
+
+	draft := &model.Draft{}
+	err := db.GetCollection("draft").FindOne(ctx, filter).Decode(draft)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("草稿不存在或已过期")
+		}
+		GetLogger(ctx).Errorln("DraftLogic FindOne error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	return draft, nil
+}
+
+// Delete 丢弃草稿
+func (DraftLogic) Delete(ctx context.Context, me *model.Me, id int) error {
+	result, err := db.GetCollection("draft").DeleteOne(ctx, bson.M{"_id": id, "uid": me.Uid})
+	if err != nil {
+		GetLogger(ctx).Errorln("DraftLogic Delete error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("草稿不存在或已过期")
+	}
+	return nil
+}
+
+// Publish 从草稿发布：走正常的发布（或修改）流程，成功后删除草稿。返回内容的 id（新建 wiki 时为 0）。
+// reqForm 是发布请求的表单，其中的验证码会一起提交
+func (self DraftLogic) Publish(ctx context.Context, me *model.Me, id int, reqForm url.Values) (int, error) {
+	draft, err := self.FindOne(ctx, me, id, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	form := url.Values{}
+	for field, val := range draft.Form {
+		form.Set(field, val)
+	}
+	form.Set("title", draft.Title)
+	form.Set("content", draft.Content)
+	for _, field := range draftPublishFields {
+		if val := reqForm.Get(field); val != "" {
+			form.Set(field, val)
+		}
+	}
+
+	objid := draft.Objid
+	switch draft.Objtype {
+	case model.TypeTopic:
+		if objid > 0 {
+			form.Set("tid", strconv.Itoa(objid))
+		}
+		objid, err = DefaultTopic.Publish(ctx, me, form)
+	case model.TypeArticle:
+		if objid > 0 {
+			form.Set("id", strconv.Itoa(objid))
+			var errMsg string
+			if errMsg, err = DefaultArticle.Modify(ctx, me, form); err == nil && errMsg != "" {
+				err = errors.New(errMsg)
+			}
+		} else {
+			objid, err = DefaultArticle.Publish(ctx, me, form)
+		}
+	case model.TypeWiki:
+		if objid > 0 {
+			form.Set("id", strconv.Itoa(objid))
+			err = DefaultWiki.Modify(ctx, me, form)
+		} else {
+			err = DefaultWiki.Create(ctx, me, form)
+		}
+	default:
+		err = errors.New("不支持该类型的草稿")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	self.Delete(ctx, me, draft.Id)
+	return objid, nil
+}
+
+func (DraftLogic) supported(objtype int) bool {
+	return objtype == model.TypeTopic || objtype == model.TypeArticle || objtype == model.TypeWiki
+}
+
+// ttl 草稿的有效期，配置 [draft] ttl，默认 30 天
+func (DraftLogic) ttl() time.Duration {
+	ttl, err := time.ParseDuration(config.ConfigFile.MustValue("draft", "ttl", "720h"))
+	if err != nil || ttl <= 0 {
+		ttl = 720 * time.Hour
+	}
+	return ttl
+}
@@ -111,6 +111,26 @@ func (EmailLogic) genActivateSign(email, uuid string, ts int64) string {
 	return goutils.Md5(origStr)
 }
 
+// SendOAuthSignupMail 第三方登录注册时，发送确认邮箱的邮件，确认后才完成注册
+func (self EmailLogic) SendOAuthSignupMail(email, ticket, code string, isHttps ...bool) {
+	domain := "http://" + WebsiteSetting.Domain
+	if len(isHttps) > 0 && isHttps[0] {
+		domain = "https://" + WebsiteSetting.Domain
+	}
+
+	confirmUrl := fmt.Sprintf("%s/oauth/email/confirm?ticket=%s&code=%s", domain, ticket, code)
+
+	global.App.SetCopyright()
+
+	content := `
+尊敬的` + WebsiteSetting.Name + `用户：<br/><br/>
+感谢您选择了` + WebsiteSetting.Name + `，请点击下面的地址确认邮箱，完成在` + WebsiteSetting.Name + `的注册（有效期4小时）：<br/><br/>
+<a href="` + confirmUrl + `">` + confirmUrl + `</a><br/><br/>
+如果这个请求不是由您发起的，您可以安全地忽略这封邮件。<br/><br/>
+<div style="text-align:right;">&copy;` + global.App.Copyright + ` ` + WebsiteSetting.Name + `</div>`
+	self.SendAuthMail(WebsiteSetting.Name+"邮箱确认邮件", content, []string{email})
+}
+
 // SendResetpwdMail 发重置密码邮件
 func (self EmailLogic) SendResetpwdMail(email, uuid string, isHttps ...bool) {
 	global.App.SetCopyright()
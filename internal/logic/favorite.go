@@ -7,28 +7,62 @@
 package logic
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/studygolang/studygolang/db"
 	"github.com/studygolang/studygolang/internal/model"
 
+	"github.com/polaris1119/logger"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	// 每个用户最多的收藏夹数（不含默认收藏夹）
+	favoriteFolderMaxNum   = 50
+	favoriteFolderNameLen  = 30
+	favoriteFolderIntroLen = 200
+	favoriteNoteMaxLen     = 500
+	// 升级旧收藏时每批处理的数量
+	favoriteUpgradeBatch = 200
+)
+
 type FavoriteLogic struct{}
 
 var DefaultFavorite = FavoriteLogic{}
 
-func (FavoriteLogic) Save(ctx context.Context, uid, objid, objtype int) error {
+// Save 收藏到收藏夹 folderId（0 为默认收藏夹），note 为备注
+func (self FavoriteLogic) Save(ctx context.Context, uid, objid, objtype, folderId int, note string) error {
 	objLog := GetLogger(ctx)
 
+	if folderId != model.FavoriteFolderDefault && self.findFolder(ctx, uid, folderId) == nil {
+		return errors.New("收藏夹不存在")
+	}
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > favoriteNoteMaxLen {
+		return fmt.Errorf("备注不能超过 %d 个字", favoriteNoteMaxLen)
+	}
+
 	favorite := &model.Favorite{}
 	favorite.Uid = uid
 	favorite.Objid = objid
 	favorite.Objtype = objtype
+	favorite.FolderId = folderId
+	favorite.Note = note
+	favorite.Ctime = model.OftenTime(time.Now())
+	if source, doc := findObject(ctx, objtype, objid); doc != nil {
+		favorite.Title, _, _ = source.fields(doc)
+	}
 
 	_, err := db.GetCollection("favorites").InsertOne(ctx, favorite)
 	if err != nil {
@@ -91,3 +125,419 @@ func (FavoriteLogic) FindUserFavorites(ctx context.Context, uid, objtype, start,
 
 	return favorites, total
 }
+
+// FindFavorites 用户在收藏夹 folderId 中的收藏，folderId 小于 0 表示所有收藏夹；
+// keyword 非空时在标题和备注中搜索。新收藏的在前
+func (FavoriteLogic) FindFavorites(ctx context.Context, uid, folderId int, keyword string, paginator *Paginator) []*model.Favorite {
+	objLog := GetLogger(ctx)
+
+	filter := bson.M{"uid": uid}
+	if folderId >= 0 {
+		filter["folder_id"] = folderId
+	}
+	if keyword = strings.TrimSpace(keyword); keyword != "" {
+		regex := bson.M{"$regex": regexp.QuoteMeta(keyword), "$options": "i"}
+		filter["$or"] = bson.A{bson.M{"title": regex}, bson.M{"note": regex}}
+	}
+
+	coll := db.GetCollection("favorites")
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		objLog.Errorln("FavoriteLogic FindFavorites count error:", err)
+		return nil
+	}
+	paginator.SetTotal(total)
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "ctime", Value: -1}}).
+		SetSkip(int64(paginator.Offset())).
+		SetLimit(int64(paginator.PerPage()))
+	return findFavorites(ctx, filter, opts)
+}
+
+// SetNote 修改收藏的备注
+func (FavoriteLogic) SetNote(ctx context.Context, uid, objtype, objid int, note string) error {
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > favoriteNoteMaxLen {
+		return fmt.Errorf("备注不能超过 %d 个字", favoriteNoteMaxLen)
+	}
+
+	result, err := db.GetCollection("favorites").UpdateOne(ctx,
+		bson.M{"uid": uid, "objtype": objtype, "objid": objid},
+		bson.M{"$set": bson.M{"note": note}})
+	if err != nil {
+		GetLogger(ctx).Errorln("FavoriteLogic SetNote error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("收藏不存在")
+	}
+	return nil
+}
+
+// Move 把收藏 items（用 Objtype、Objid 指定）移到收藏夹 folderId，返回移动的数量
+func (self FavoriteLogic) Move(ctx context.Context, uid, folderId int, items []*model.Favorite) (int64, error) {
+	if len(items) == 0 {
+		return 0, errors.New("请选择要移动的收藏")
+	}
+	if folderId != model.FavoriteFolderDefault && self.findFolder(ctx, uid, folderId) == nil {
+		return 0, errors.New("收藏夹不存在")
+	}
+
+	objs := make(bson.A, len(items))
+	for i, item := range items {
+		objs[i] = bson.M{"objtype": item.Objtype, "objid": item.Objid}
+	}
+	result, err := db.GetCollection("favorites").UpdateMany(ctx,
+		bson.M{"uid": uid, "$or": objs},
+		bson.M{"$set": bson.M{"folder_id": folderId}})
+	if err != nil {
+		GetLogger(ctx).Errorln("FavoriteLogic Move error:", err)
+		return 0, errors.New("内部服务错误")
+	}
+	return result.ModifiedCount, nil
+}
+
+// CreateFolder 新建收藏夹
+func (self FavoriteLogic) CreateFolder(ctx context.Context, uid int, name, intro string, public bool) (*model.FavoriteFolder, error) {
+	objLog := GetLogger(ctx)
+
+	name, intro, err := self.checkFolder(name, intro)
+	if err != nil {
+		return nil, err
+	}
+
+	num, err := db.GetCollection("favorite_folder").CountDocuments(ctx, bson.M{"uid": uid})
+	if err != nil {
+		objLog.Errorln("FavoriteLogic CreateFolder count error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	if num >= favoriteFolderMaxNum {
+		return nil, fmt.Errorf("最多只能创建 %d 个收藏夹", favoriteFolderMaxNum)
+	}
+
+	now := time.Now()
+	folder := &model.FavoriteFolder{
+		Uid:       uid,
+		Name:      name,
+		Intro:     intro,
+		Public:    public,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	folder.Id, err = db.NextID("favorite_folder")
+	if err != nil {
+		objLog.Errorln("FavoriteLogic CreateFolder NextID error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+
+	// (uid, name) 有唯一索引
+	if _, err = db.GetCollection("favorite_folder").InsertOne(ctx, folder); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("已有同名收藏夹")
+		}
+		objLog.Errorln("FavoriteLogic CreateFolder insert error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	return folder, nil
+}
+
+// ModifyFolder 修改收藏夹的名称、介绍和是否公开
+func (self FavoriteLogic) ModifyFolder(ctx context.Context, uid, id int, name, intro string, public bool) error {
+	name, intro, err := self.checkFolder(name, intro)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.GetCollection("favorite_folder").UpdateOne(ctx,
+		bson.M{"_id": id, "uid": uid},
+		bson.M{"$set": bson.M{"name": name, "intro": intro, "public": public, "updated_at": time.Now()}})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("已有同名收藏夹")
+		}
+		GetLogger(ctx).Errorln("FavoriteLogic ModifyFolder error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("收藏夹不存在")
+	}
+	return nil
+}
+
+// DeleteFolder 删除收藏夹，其中的收藏移到默认收藏夹
+func (self FavoriteLogic) DeleteFolder(ctx context.Context, uid, id int) error {
+	objLog := GetLogger(ctx)
+
+	if self.findFolder(ctx, uid, id) == nil {
+		return errors.New("收藏夹不存在")
+	}
+
+	_, err := db.GetCollection("favorites").UpdateMany(ctx,
+		bson.M{"uid": uid, "folder_id": id},
+		bson.M{"$set": bson.M{"folder_id": model.FavoriteFolderDefault}})
+	if err != nil {
+		objLog.Errorln("FavoriteLogic DeleteFolder move error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	if _, err = db.GetCollection("favorite_folder").DeleteOne(ctx, bson.M{"_id": id, "uid": uid}); err != nil {
+		objLog.Errorln("FavoriteLogic DeleteFolder error:", err)
+		return errors.New("内部服务错误")
+	}
+	return nil
+}
+
+// FindFolders 用户的收藏夹及收藏数。onlyPublic 为 false 时包含私有的和默认收藏夹（排在最前）
+func (FavoriteLogic) FindFolders(ctx context.Context, uid int, onlyPublic bool) []*model.FavoriteFolder {
+	objLog := GetLogger(ctx)
+
+	filter := bson.M{"uid": uid}
+	if onlyPublic {
+		filter["public"] = true
+	}
+	cursor, err := db.GetCollection("favorite_folder").Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		objLog.Errorln("FavoriteLogic FindFolders error:", err)
+		return nil
+	}
+	list := make([]*model.FavoriteFolder, 0)
+	err = cursor.All(ctx, &list)
+	cursor.Close(ctx)
+	if err != nil {
+		objLog.Errorln("FavoriteLogic FindFolders decode error:", err)
+		return nil
+	}
+
+	folders := make([]*model.FavoriteFolder, 0, len(list)+1)
+	if !onlyPublic {
+		folders = append(folders, &model.FavoriteFolder{Uid: uid, Name: model.FavoriteFolderDefaultName})
+	}
+	folders = append(folders, list...)
+
+	cursor, err = db.GetCollection("favorites").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"uid": uid}}},
+		{{Key: "$group", Value: bson.M{"_id": "$folder_id", "num": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		objLog.Errorln("FavoriteLogic FindFolders aggregate error:", err)
+		return folders
+	}
+	var counts []struct {
+		FolderId int `bson:"_id"`
+		Num      int `bson:"num"`
+	}
+	err = cursor.All(ctx, &counts)
+	cursor.Close(ctx)
+	if err != nil {
+		objLog.Errorln("FavoriteLogic FindFolders decode count error:", err)
+		return folders
+	}
+
+	numMap := make(map[int]int, len(counts))
+	for _, count := range counts {
+		numMap[count.FolderId] = count.Num
+	}
+	for _, folder := range folders {
+		folder.Num = numMap[folder.Id]
+	}
+	return folders
+}
+
+// FindFolder 收藏夹 id，私有的只有自己（uid）可以看
+func (FavoriteLogic) FindFolder(ctx context.Context, uid, id int) (*model.FavoriteFolder, error) {
+	folder := &model.FavoriteFolder{}
+	err := db.GetCollection("favorite_folder").FindOne(ctx, bson.M{"_id": id}).Decode(folder)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			GetLogger(ctx).Errorln("FavoriteLogic FindFolder error:", err)
+		}
+		return nil, errors.New("收藏夹不存在")
+	}
+	if !folder.Public && folder.Uid != uid {
+		return nil, errors.New("收藏夹不存在")
+	}
+	return folder, nil
+}
+
+func (FavoriteLogic) findFolder(ctx context.Context, uid, id int) *model.FavoriteFolder {
+	folder := &model.FavoriteFolder{}
+	err := db.GetCollection("favorite_folder").FindOne(ctx, bson.M{"_id": id, "uid": uid}).Decode(folder)
+	if err != nil {
+		return nil
+	}
+	return folder
+}
+
+func (FavoriteLogic) checkFolder(name, intro string) (string, string, error) {
+	name, intro = strings.TrimSpace(name), strings.TrimSpace(intro)
+	if name == "" {
+		return "", "", errors.New("收藏夹名称不能为空")
+	}
+	if utf8.RuneCountInString(name) > favoriteFolderNameLen {
+		return "", "", fmt.Errorf("收藏夹名称不能超过 %d 个字", favoriteFolderNameLen)
+	}
+	if name == model.FavoriteFolderDefaultName {
+		return "", "", errors.New("已有同名收藏夹")
+	}
+	if utf8.RuneCountInString(intro) > favoriteFolderIntroLen {
+		return "", "", fmt.Errorf("收藏夹介绍不能超过 %d 个字", favoriteFolderIntroLen)
+	}
+	return name, intro, nil
+}
+
+// FavoriteExportFolder 导出的收藏夹及其中的收藏
+type FavoriteExportFolder struct {
+	*model.FavoriteFolder
+	Items []*model.Favorite `json:"items"`
+}
+
+// Export 导出用户的收藏，folderId 小于 0 表示所有收藏夹。收藏中的 Uri 为完整的网址
+func (self FavoriteLogic) Export(ctx context.Context, uid, folderId int) ([]*FavoriteExportFolder, error) {
+	folders := self.FindFolders(ctx, uid, false)
+	if folders == nil {
+		return nil, errors.New("内部服务错误")
+	}
+
+	filter := bson.M{"uid": uid}
+	if folderId >= 0 {
+		filter["folder_id"] = folderId
+	}
+	favorites := findFavorites(ctx, filter, options.Find().SetSort(bson.D{{Key: "ctime", Value: -1}}))
+
+	exports := make([]*FavoriteExportFolder, 0, len(folders))
+	exportMap := make(map[int]*FavoriteExportFolder, len(folders))
+	for _, folder := range folders {
+		if folderId >= 0 && folder.Id != folderId {
+			continue
+		}
+		export := &FavoriteExportFolder{FavoriteFolder: folder, Items: make([]*model.Favorite, 0, folder.Num)}
+		exports = append(exports, export)
+		exportMap[folder.Id] = export
+	}
+	if len(exports) == 0 {
+		return nil, errors.New("收藏夹不存在")
+	}
+
+	for _, favorite := range favorites {
+		favorite.Uri = website() + favorite.Uri
+		// 所在收藏夹已删除的，放到默认收藏夹中
+		export, ok := exportMap[favorite.FolderId]
+		if !ok {
+			if export, ok = exportMap[model.FavoriteFolderDefault]; !ok {
+				continue
+			}
+		}
+		export.Items = append(export.Items, favorite)
+	}
+	return exports, nil
+}
+
+// ExportHTML 生成 Netscape 书签格式的 HTML，浏览器都可以导入
+func (FavoriteLogic) ExportHTML(folders []*FavoriteExportFolder) []byte {
+	buf := bytes.NewBufferString(`<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+`)
+	for _, folder := range folders {
+		addDate := folder.CreatedAt.Unix()
+		if folder.CreatedAt.IsZero() {
+			addDate = 0
+		}
+		fmt.Fprintf(buf, "    <DT><H3 ADD_DATE=\"%d\">%s</H3>\n", addDate, html.EscapeString(folder.Name))
+		if folder.Intro != "" {
+			fmt.Fprintf(buf, "    <DD>%s\n", html.EscapeString(folder.Intro))
+		}
+		buf.WriteString("    <DL><p>\n")
+		for _, item := range folder.Items {
+			title := item.Title
+			if title == "" {
+				title = item.Uri
+			}
+			fmt.Fprintf(buf, "        <DT><A HREF=\"%s\" ADD_DATE=\"%d\">%s</A>\n",
+				html.EscapeString(item.Uri), time.Time(item.Ctime).Unix(), html.EscapeString(title))
+			if item.Note != "" {
+				fmt.Fprintf(buf, "        <DD>%s\n", html.EscapeString(item.Note))
+			}
+		}
+		buf.WriteString("    </DL><p>\n")
+	}
+	buf.WriteString("</DL><p>\n")
+	return buf.Bytes()
+}
+
+// Upgrade 升级收藏夹功能之前的收藏：归入默认收藏夹，补上标题，字符串的 ctime 改为收藏时间（取自 _id）。
+// 可以重复执行，已升级的不会再处理
+func (FavoriteLogic) Upgrade() {
+	ctx := context.Background()
+	coll := db.GetCollection("favorites")
+
+	_, err := coll.UpdateMany(ctx, bson.M{"ctime": bson.M{"$type": "string"}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"ctime": bson.M{"$toDate": "$_id"}}}}})
+	if err != nil {
+		logger.Errorln("FavoriteLogic Upgrade ctime error:", err)
+		return
+	}
+
+	_, err = coll.UpdateMany(ctx, bson.M{"folder_id": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"folder_id": model.FavoriteFolderDefault}})
+	if err != nil {
+		logger.Errorln("FavoriteLogic Upgrade folder error:", err)
+		return
+	}
+
+	for {
+		var favorites []struct {
+			Id      interface{} `bson:"_id"`
+			Objtype int         `bson:"objtype"`
+			Objid   int         `bson:"objid"`
+		}
+		cursor, err := coll.Find(ctx, bson.M{"title": bson.M{"$exists": false}}, options.Find().SetLimit(favoriteUpgradeBatch))
+		if err != nil {
+			logger.Errorln("FavoriteLogic Upgrade find error:", err)
+			return
+		}
+		err = cursor.All(ctx, &favorites)
+		cursor.Close(ctx)
+		if err != nil {
+			logger.Errorln("FavoriteLogic Upgrade decode error:", err)
+			return
+		}
+
+		for _, favorite := range favorites {
+			title := ""
+			if source, doc := findObject(ctx, favorite.Objtype, favorite.Objid); doc != nil {
+				title, _, _ = source.fields(doc)
+			}
+			if _, err = coll.UpdateOne(ctx, bson.M{"_id": favorite.Id}, bson.M{"$set": bson.M{"title": title}}); err != nil {
+				logger.Errorln("FavoriteLogic Upgrade update error:", err)
+				return
+			}
+		}
+		if len(favorites) < favoriteUpgradeBatch {
+			return
+		}
+	}
+}
+
+func findFavorites(ctx context.Context, filter bson.M, opts *options.FindOptions) []*model.Favorite {
+	favorites := make([]*model.Favorite, 0)
+	cursor, err := db.GetCollection("favorites").Find(ctx, filter, opts)
+	if err != nil {
+		GetLogger(ctx).Errorln("findFavorites error:", err)
+		return favorites
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &favorites); err != nil {
+		GetLogger(ctx).Errorln("findFavorites decode error:", err)
+	}
+	for _, favorite := range favorites {
+		favorite.Uri = model.PathUrlMap[favorite.Objtype] + strconv.Itoa(favorite.Objid)
+	}
+	return favorites
+}
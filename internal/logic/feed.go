@@ -342,6 +342,49 @@ func (FeedLogic) setTop(ctx context.Context, objid, objtype int, top int) error
 	return err
 }
 
+// setOffline 下线动态（如内容被删除）
+func (FeedLogic) setOffline(ctx context.Context, objid, objtype int) error {
+	_, err := db.GetCollection("feed").UpdateOne(ctx, bson.M{"objid": objid, "objtype": objtype}, bson.M{"$set": bson.M{
+		"state": model.FeedOffline,
+	}})
+
+	return err
+}
+
+// setOnline 重新上线动态（如被隐藏的内容审核通过）
+func (FeedLogic) setOnline(ctx context.Context, objid, objtype int) error {
+	_, err := db.GetCollection("feed").UpdateOne(ctx, bson.M{"objid": objid, "objtype": objtype}, bson.M{"$set": bson.M{
+		"state": 0,
+	}})
+
+	return err
+}
+
+// modifyTopicPermission 主题可见范围修改或审核通过后，同步动态：非公开的下线，改为公开的重新上线
+func (self FeedLogic) modifyTopicPermission(topic *model.Topic) {
+	go func() {
+		ctx := context.Background()
+		if topic.Restricted() {
+			self.setOffline(ctx, topic.Tid, model.TypeTopic)
+			return
+		}
+
+		feed := &model.Feed{}
+		err := db.GetCollection("feed").FindOne(ctx, bson.M{"objid": topic.Tid, "objtype": model.TypeTopic}).Decode(feed)
+		if err != nil {
+			// 发布时非公开，没有生成动态
+			model.PublishFeed(topic, nil, nil)
+			return
+		}
+
+		node := &model.TopicNode{}
+		err = db.GetCollection("topics_node").FindOne(ctx, bson.M{"_id": topic.Nid}).Decode(node)
+		if err == nil && node.ShowIndex {
+			db.GetCollection("feed").UpdateOne(ctx, bson.M{"_id": feed.Id}, bson.M{"$set": bson.M{"state": 0}})
+		}
+	}()
+}
+
 // updateComment 更新动态评论数据
 func (self FeedLogic) updateComment(objid, objtype, uid int, cmttime time.Time) {
 	go func() {
@@ -30,6 +30,14 @@ func (self GoBookLogic) Publish(ctx context.Context, user *model.Me, form url.Va
 	id := form.Get("id")
 	isModify := id != ""
 
+	kind := PublishKindCreate
+	if isModify {
+		kind = PublishKindModify
+	}
+	if err = CheckPublish(ctx, NewPublishAction(ctx, user, kind, model.TypeBook, form)); err != nil {
+		return
+	}
+
 	book := &model.Book{}
 
 	if isModify {
@@ -39,7 +47,7 @@ func (self GoBookLogic) Publish(ctx context.Context, user *model.Me, form url.Va
 			return
 		}
 
-		if !CanEdit(user, book) {
+		if !CanEdit(ctx, user, book) {
 			err = NotModifyAuthorityErr
 			return
 		}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"io/ioutil"
+	"time"
 
 	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/global"
 	"github.com/studygolang/studygolang/internal/model"
 
 	"github.com/polaris1119/config"
+	"github.com/polaris1119/logger"
 	xcontext "golang.org/x/net/context"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -20,7 +23,7 @@ type InstallLogic struct{}
 
 var DefaultInstall = InstallLogic{}
 
-func (InstallLogic) CreateTable(ctx xcontext.Context) error {
+func (self InstallLogic) CreateTable(ctx xcontext.Context) error {
 	objLog := GetLogger(ctx)
 
 	bgCtx := context.Background()
@@ -30,7 +33,7 @@ func (InstallLogic) CreateTable(ctx xcontext.Context) error {
 		"topics", "topics_ex", "topics_node", "topic_append", "recommend_node",
 		"articles", "article_gctt", "crawl_rule", "auto_crawl_rule",
 		"comments", "resource", "resource_ex", "resource_category",
-		"feed", "message", "system_message", "favorite", "like",
+		"feed", "message", "system_message", "favorites", "favorite_folder", "like",
 		"view_record", "view_source", "dynamic", "download",
 		"gift", "gift_redeem", "user_exchange_record",
 		"open_project", "subject", "subject_admin", "subject_article", "subject_follower",
@@ -39,10 +42,16 @@ func (InstallLogic) CreateTable(ctx xcontext.Context) error {
 		"role", "role_authority", "authority",
 		"website_setting", "user_setting", "default_avatar",
 		"image", "search_stat", "mission", "user_login_mission",
-		"user_balance_detail", "user_recharge",
+		"user_balance_detail", "user_recharge", "bounty", "poll", "poll_vote",
+		"tags", "tag_object", "tag_follow",
 		"wechat_user", "wechat_auto_reply",
 		"gctt_user", "gctt_git", "gctt_issue", "gctt_timeline",
 		"github_user", "counters",
+		"access_token", "user_session", "user_totp",
+		"node_moderator", "moderator_log", "user_follow",
+		"node_follow", "timeline_event", "sensitive_word", "moderation_item",
+		"report", "report_stat", "reporter", "risk_ip",
+		"revision", "draft",
 	}
 
 	for _, name := range collections {
@@ -55,79 +64,30 @@ func (InstallLogic) CreateTable(ctx xcontext.Context) error {
 		}
 	}
 
-	indexes := map[string][]mongo.IndexModel{
-		"user_info": {
-			{Keys: bson.D{{"username", 1}}, Options: options.Index().SetUnique(true)},
-			{Keys: bson.D{{"email", 1}}, Options: options.Index().SetUnique(true)},
-			{Keys: bson.D{{"status", 1}}},
-		},
-		"user_login": {
-			{Keys: bson.D{{"username", 1}}, Options: options.Index().SetUnique(true)},
-			{Keys: bson.D{{"email", 1}}},
-		},
-		"topics": {
-			{Keys: bson.D{{"uid", 1}}},
-			{Keys: bson.D{{"nid", 1}}},
-			{Keys: bson.D{{"ctime", -1}}},
-			{Keys: bson.D{{"flag", 1}}},
-			{Keys: bson.D{{"top", 1}}},
-		},
-		"articles": {
-			{Keys: bson.D{{"domain", 1}}},
-			{Keys: bson.D{{"status", 1}}},
-			{Keys: bson.D{{"ctime", -1}}},
-			{Keys: bson.D{{"url", 1}}},
-		},
-		"comments": {
-			{Keys: bson.D{{"objid", 1}, {"objtype", 1}}},
-			{Keys: bson.D{{"uid", 1}}},
-		},
-		"resource": {
-			{Keys: bson.D{{"uid", 1}}},
-			{Keys: bson.D{{"ctime", -1}}},
-			{Keys: bson.D{{"catid", 1}}},
-		},
-		"feed": {
-			{Keys: bson.D{{"uid", 1}}},
-			{Keys: bson.D{{"created_at", -1}}},
-			{Keys: bson.D{{"objtype", 1}, {"objid", 1}}},
-		},
-		"like": {
-			{Keys: bson.D{{"uid", 1}, {"objid", 1}, {"objtype", 1}}, Options: options.Index().SetUnique(true)},
-		},
-		"favorite": {
-			{Keys: bson.D{{"uid", 1}, {"objid", 1}, {"objtype", 1}}, Options: options.Index().SetUnique(true)},
-		},
-		"message": {
-			{Keys: bson.D{{"to", 1}, {"hasread", 1}}},
-		},
-		"view_record": {
-			{Keys: bson.D{{"uid", 1}, {"objid", 1}, {"objtype", 1}}},
-		},
-		"open_project": {
-			{Keys: bson.D{{"uri", 1}}},
-			{Keys: bson.D{{"ctime", -1}}},
-		},
-		"wiki": {
-			{Keys: bson.D{{"uid", 1}}},
-		},
-		"book": {
-			{Keys: bson.D{{"uid", 1}}},
-		},
-		"bind_user": {
-			{Keys: bson.D{{"uid", 1}}},
-			{Keys: bson.D{{"type", 1}, {"tuid", 1}}},
-		},
+	self.ensureIndexes(bgCtx)
+
+	return nil
+}
+
+// EnsureIndexes 启动时创建缺少的索引，已有的不会重复创建。
+// 升级的站点没有执行安装，依赖唯一索引去重、依赖 TTL 索引清理过期数据的功能需要这些索引
+func (self InstallLogic) EnsureIndexes() {
+	ctx := context.Background()
+	if !self.IsTableExist(ctx) {
+		return
 	}
+	self.ensureIndexes(ctx)
+}
 
-	for coll, idxModels := range indexes {
-		_, err := db.GetCollection(coll).Indexes().CreateMany(bgCtx, idxModels)
-		if err != nil {
-			objLog.Errorln("create indexes error:", coll, err)
+// ensureIndexes 逐个创建索引，某个索引失败（如已有重复数据）不影响其他的
+func (InstallLogic) ensureIndexes(ctx context.Context) {
+	for coll, idxModels := range collectionIndexes() {
+		for _, idxModel := range idxModels {
+			if _, err := db.GetCollection(coll).Indexes().CreateOne(ctx, idxModel); err != nil {
+				logger.Errorln("create index error:", coll, idxModel.Keys, err)
+			}
 		}
 	}
-
-	return nil
 }
 
 func (InstallLogic) InitTable(ctx xcontext.Context) error {
@@ -168,6 +128,113 @@ func (InstallLogic) InitTable(ctx xcontext.Context) error {
 	return nil
 }
 
+// UpgradeAuthority 补齐 init.json 中新增的权限，用于升级的站点（安装时才会导入 init.json）。
+// 按 route（一级菜单按名称）判断是否已有，已有的不会重复添加；新增的权限需要站长在后台分配给角色
+func (self InstallLogic) UpgradeAuthority() {
+	ctx := context.Background()
+	coll := db.GetCollection("authority")
+
+	buf, err := ioutil.ReadFile(config.ROOT + "/config/init.json")
+	if err != nil {
+		logger.Errorln("InstallLogic UpgradeAuthority read init file error:", err)
+		return
+	}
+	// init.json 中 id 的字段名是 _id
+	var initData struct {
+		Authority []struct {
+			Aid   int    `json:"_id"`
+			Name  string `json:"name"`
+			Menu1 int    `json:"menu1"`
+			Menu2 int    `json:"menu2"`
+			Route string `json:"route"`
+		} `json:"authority"`
+	}
+	if err = json.Unmarshal(buf, &initData); err != nil {
+		logger.Errorln("InstallLogic UpgradeAuthority parse json error:", err)
+		return
+	}
+
+	authorities := make([]*model.Authority, 0)
+	cursor, err := coll.Find(ctx, bson.M{})
+	if err != nil {
+		logger.Errorln("InstallLogic UpgradeAuthority find error:", err)
+		return
+	}
+	err = cursor.All(ctx, &authorities)
+	cursor.Close(ctx)
+	if err != nil {
+		logger.Errorln("InstallLogic UpgradeAuthority decode error:", err)
+		return
+	}
+	// 还没有安装
+	if len(authorities) == 0 {
+		return
+	}
+
+	maxAid := 0
+	exists := make(map[string]int, len(authorities))
+	usedAids := make(map[int]bool, len(authorities))
+	for _, authority := range authorities {
+		exists[self.authorityKey(authority)] = authority.Aid
+		usedAids[authority.Aid] = true
+		if authority.Aid > maxAid {
+			maxAid = authority.Aid
+		}
+	}
+
+	// init.json 中的 id 到实际 id 的映射，用于新增权限的 menu1、menu2
+	aidMap := make(map[int]int, len(initData.Authority))
+	added := 0
+	for _, seed := range initData.Authority {
+		authority := &model.Authority{Aid: seed.Aid, Name: seed.Name, Menu1: seed.Menu1, Menu2: seed.Menu2, Route: seed.Route}
+		if aid, ok := exists[self.authorityKey(authority)]; ok {
+			aidMap[authority.Aid] = aid
+			continue
+		}
+
+		if usedAids[authority.Aid] {
+			maxAid++
+			authority.Aid = maxAid
+		} else if authority.Aid > maxAid {
+			maxAid = authority.Aid
+		}
+		if aid, ok := aidMap[authority.Menu1]; ok {
+			authority.Menu1 = aid
+		}
+		if aid, ok := aidMap[authority.Menu2]; ok {
+			authority.Menu2 = aid
+		}
+		authority.OpUser = "system"
+		authority.Ctime = model.OftenTime(time.Now())
+		authority.Mtime = authority.Ctime
+
+		if _, err = coll.InsertOne(ctx, authority); err != nil {
+			logger.Errorln("InstallLogic UpgradeAuthority insert error:", authority.Route, err)
+			continue
+		}
+		aidMap[seed.Aid] = authority.Aid
+		usedAids[authority.Aid] = true
+		added++
+		logger.Infoln("InstallLogic UpgradeAuthority add authority:", authority.Aid, authority.Name, authority.Route)
+	}
+
+	if added > 0 {
+		// 让后台 NextID 分配的 id 不和补齐的冲突
+		if err = db.SetNextID("authority", maxAid); err != nil {
+			logger.Errorln("InstallLogic UpgradeAuthority set next id error:", err)
+		}
+		global.AuthorityChan <- struct{}{}
+	}
+}
+
+// authorityKey 判断权限是否已有的依据：route，一级菜单没有 route，用名称
+func (InstallLogic) authorityKey(authority *model.Authority) string {
+	if authority.Route == "" {
+		return "name:" + authority.Name
+	}
+	return authority.Route
+}
+
 func (InstallLogic) IsTableExist(ctx xcontext.Context) bool {
 	bgCtx := context.Background()
 	names, err := db.MasterDB.ListCollectionNames(bgCtx, bson.M{})
@@ -192,3 +259,172 @@ func (InstallLogic) HadRootUser(ctx xcontext.Context) bool {
 	}
 	return user.Uid != 0
 }
+
+// collectionIndexes 各集合的索引
+func collectionIndexes() map[string][]mongo.IndexModel {
+	return map[string][]mongo.IndexModel{
+		"user_info": {
+			{Keys: bson.D{{"username", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"email", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"status", 1}}},
+		},
+		"user_login": {
+			{Keys: bson.D{{"username", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"email", 1}}},
+			{Keys: bson.D{{"login_ip", 1}}},
+		},
+		"topics": {
+			{Keys: bson.D{{"uid", 1}}},
+			{Keys: bson.D{{"nid", 1}}},
+			{Keys: bson.D{{"ctime", -1}}},
+			{Keys: bson.D{{"flag", 1}}},
+			{Keys: bson.D{{"top", 1}}},
+			{Keys: bson.D{{"publish_at", 1}}, Options: options.Index().SetSparse(true)},
+			{Keys: bson.D{{"question", 1}, {"answer_cid", 1}}},
+		},
+		"articles": {
+			{Keys: bson.D{{"domain", 1}}},
+			{Keys: bson.D{{"status", 1}}},
+			{Keys: bson.D{{"ctime", -1}}},
+			{Keys: bson.D{{"url", 1}}},
+			{Keys: bson.D{{"publish_at", 1}}, Options: options.Index().SetSparse(true)},
+		},
+		"comments": {
+			{Keys: bson.D{{"objid", 1}, {"objtype", 1}}},
+			{Keys: bson.D{{"objid", 1}, {"objtype", 1}, {"parent_cid", 1}}},
+			{Keys: bson.D{{"objid", 1}, {"objtype", 1}, {"path", 1}}},
+			{Keys: bson.D{{"uid", 1}}},
+		},
+		"resource": {
+			{Keys: bson.D{{"uid", 1}}},
+			{Keys: bson.D{{"ctime", -1}}},
+			{Keys: bson.D{{"catid", 1}}},
+		},
+		"feed": {
+			{Keys: bson.D{{"uid", 1}}},
+			{Keys: bson.D{{"created_at", -1}}},
+			{Keys: bson.D{{"objtype", 1}, {"objid", 1}}},
+		},
+		"like": {
+			{Keys: bson.D{{"uid", 1}, {"objid", 1}, {"objtype", 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"favorite": {
+			{Keys: bson.D{{"uid", 1}, {"objid", 1}, {"objtype", 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"message": {
+			{Keys: bson.D{{"to", 1}, {"hasread", 1}}},
+		},
+		"view_record": {
+			{Keys: bson.D{{"uid", 1}, {"objid", 1}, {"objtype", 1}}},
+		},
+		"open_project": {
+			{Keys: bson.D{{"uri", 1}}},
+			{Keys: bson.D{{"ctime", -1}}},
+		},
+		"wiki": {
+			{Keys: bson.D{{"uid", 1}}},
+		},
+		"book": {
+			{Keys: bson.D{{"uid", 1}}},
+		},
+		"bind_user": {
+			{Keys: bson.D{{"uid", 1}}},
+			{Keys: bson.D{{"type", 1}, {"tuid", 1}}},
+		},
+		"access_token": {
+			{Keys: bson.D{{"token_hash", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"uid", 1}}},
+		},
+		"user_session": {
+			{Keys: bson.D{{"sid_hash", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"uid", 1}}},
+			// 过期会话由 mongo 自动清理
+			{Keys: bson.D{{"expire_at", 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+			{Keys: bson.D{{"ip", 1}}},
+		},
+		"revision": {
+			{Keys: bson.D{{"objtype", 1}, {"objid", 1}, {"version", 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"draft": {
+			{Keys: bson.D{{"uid", 1}, {"updated_at", -1}}},
+			// 修改已有内容的草稿每人只有一份
+			{Keys: bson.D{{"uid", 1}, {"objtype", 1}, {"objid", 1}}, Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"objid": bson.M{"$gt": 0}})},
+			{Keys: bson.D{{"expire_at", 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"risk_ip": {
+			{Keys: bson.D{{"list", 1}, {"cidr", 1}}, Options: options.Index().SetUnique(true)},
+			// 过期的条目由 mongo 自动清理，永久的没有 expire_at 字段
+			{Keys: bson.D{{"expire_at", 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"node_moderator": {
+			{Keys: bson.D{{"nid", 1}, {"uid", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"uid", 1}}},
+		},
+		"moderator_log": {
+			{Keys: bson.D{{"nid", 1}, {"_id", -1}}},
+		},
+		"user_follow": {
+			{Keys: bson.D{{"uid", 1}, {"follow_uid", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"follow_uid", 1}}},
+		},
+		"subject_follower": {
+			{Keys: bson.D{{"uid", 1}}},
+		},
+		"sensitive_word": {
+			{Keys: bson.D{{"word", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"category", 1}}},
+		},
+		"moderation_item": {
+			{Keys: bson.D{{"objtype", 1}, {"objid", 1}}, Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"state": model.ModerationStatePending})},
+			{Keys: bson.D{{"state", 1}, {"_id", 1}}},
+			{Keys: bson.D{{"sources", 1}}},
+		},
+		"bounty": {
+			{Keys: bson.D{{"tid", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"status", 1}, {"deadline", 1}}},
+		},
+		"poll": {
+			{Keys: bson.D{{"tid", 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"poll_vote": {
+			{Keys: bson.D{{"poll_id", 1}, {"uid", 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"favorites": {
+			{Keys: bson.D{{"uid", 1}, {"objtype", 1}, {"objid", 1}}},
+			{Keys: bson.D{{"uid", 1}, {"folder_id", 1}, {"ctime", -1}}},
+		},
+		"favorite_folder": {
+			{Keys: bson.D{{"uid", 1}, {"name", 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"tags": {
+			{Keys: bson.D{{"keys", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"total", -1}}},
+		},
+		"tag_object": {
+			{Keys: bson.D{{"tag_id", 1}, {"objtype", 1}, {"objid", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"objtype", 1}, {"objid", 1}}},
+			{Keys: bson.D{{"tag_id", 1}, {"ctime", -1}}},
+		},
+		"tag_follow": {
+			{Keys: bson.D{{"uid", 1}, {"tag_id", 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"report": {
+			{Keys: bson.D{{"objtype", 1}, {"objid", 1}, {"uid", 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{"objtype", 1}, {"objid", 1}, {"state", 1}}},
+		},
+		"report_stat": {
+			{Keys: bson.D{{"objtype", 1}, {"objid", 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"node_follow": {
+			{Keys: bson.D{{"uid", 1}, {"nid", 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"timeline_event": {
+			{Keys: bson.D{{"uid", 1}, {"_id", -1}}},
+			{Keys: bson.D{{"nid", 1}, {"_id", -1}}},
+			{Keys: bson.D{{"sids", 1}, {"_id", -1}}},
+			{Keys: bson.D{{"objtype", 1}, {"objid", 1}}},
+		},
+	}
+}
@@ -7,7 +7,6 @@
 package logic
 
 import (
-	"bytes"
 	"context"
 	"net/url"
 	"strconv"
@@ -19,10 +18,6 @@ import (
 	"github.com/polaris1119/goutils"
 	"github.com/polaris1119/logger"
 	"github.com/polaris1119/nosql"
-	"github.com/yuin/goldmark"
-	"github.com/yuin/goldmark/extension"
-	"github.com/yuin/goldmark/parser"
-	"github.com/yuin/goldmark/renderer/html"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -105,10 +100,7 @@ func (iq InterviewLogic) TodayQuestion(ctx context.Context) *model.InterviewQues
 		return nil
 	}
 
-	err = iq.parseMarkdown(ctx, question)
-	if err != nil {
-		return nil
-	}
+	iq.parseMarkdown(ctx, question)
 	return question
 }
 
@@ -122,8 +114,8 @@ func (iq InterviewLogic) FindOne(ctx context.Context, sn int64) (*model.Intervie
 		return nil, err
 	}
 
-	err = iq.parseMarkdown(ctx, question)
-	return question, err
+	iq.parseMarkdown(ctx, question)
+	return question, nil
 }
 
 func (InterviewLogic) UpdateTodayQuestionID() {
@@ -171,35 +163,9 @@ func (InterviewLogic) findByIds(ids []int) map[int]*model.InterviewQuestion {
 	return questions
 }
 
-func (InterviewLogic) parseMarkdown(ctx context.Context, question *model.InterviewQuestion) error {
-	objLog := GetLogger(ctx)
-
-	md := goldmark.New(
-		goldmark.WithExtensions(extension.GFM),
-		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(),
-		),
-		goldmark.WithRendererOptions(
-			html.WithHardWraps(),
-			html.WithXHTML(),
-		),
-	)
-
-	var buf bytes.Buffer
-	if err := md.Convert([]byte(question.Question), &buf); err != nil {
-		objLog.Errorln("InterviewLogic TodayQuestion markdown convert error:", err)
-		return err
-	}
-	question.Question = buf.String()
-
-	buf.Reset()
-	if err := md.Convert([]byte(question.Answer), &buf); err != nil {
-		objLog.Errorln("InterviewLogic TodayQuestion markdown convert error:", err)
-		return err
-	}
-	question.Answer = buf.String()
-
-	return nil
+func (InterviewLogic) parseMarkdown(ctx context.Context, question *model.InterviewQuestion) {
+	question.Question = DefaultRender.MarkdownHardWraps(ctx, question.Question).HTML
+	question.Answer = DefaultRender.MarkdownHardWraps(ctx, question.Answer).HTML
 }
 
 // 面试题回复（评论）
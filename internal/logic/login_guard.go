@@ -0,0 +1,267 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/global"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/goutils"
+	"github.com/polaris1119/logger"
+	"github.com/polaris1119/nosql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// 登录防暴力破解的默认配置，user_setting 中没有配置时使用
+var defaultLoginGuardSetting = map[string]int{
+	model.KeyLoginFailWindow:   900,
+	model.KeyLoginCaptchaFails: 3,
+	model.KeyLoginLockFails:    10,
+	model.KeyLoginIPLockFails:  30,
+	model.KeyLoginLockTime:     1800,
+	model.KeyLoginMaxDelay:     30,
+	model.KeyLoginTotpFails:    5,
+}
+
+type LoginGuardLogic struct{}
+
+var DefaultLoginGuard = LoginGuardLogic{}
+
+// Check 登录前检查：账号或 IP 是否被临时锁定、是否需要等待，以及是否需要验证码
+func (self LoginGuardLogic) Check(ctx context.Context, username, ip string) (bool, error) {
+	typ, account, _ := self.account(ctx, username)
+
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
+
+	now := time.Now().Unix()
+	for _, lockKey := range []string{self.lockKey(typ, account), self.lockKey("ip", ip)} {
+		unlockAt := goutils.MustInt64(redis.GET(lockKey))
+		if unlockAt > now {
+			minutes := (unlockAt - now + 59) / 60
+			return false, fmt.Errorf("登录失败次数太多，已被临时锁定，请 %d 分钟后再试", minutes)
+		}
+	}
+
+	userFails := self.failNum(redis, typ, account)
+	ipFails := self.failNum(redis, "ip", ip)
+
+	// 渐进式延迟：连续失败越多，两次尝试之间需要等待越久
+	if delay := self.delay(userFails); delay > 0 {
+		lastFailAt := goutils.MustInt64(redis.GET(self.lastFailKey(typ, account)))
+		if wait := lastFailAt + delay - now; wait > 0 {
+			return false, fmt.Errorf("尝试太频繁，请 %d 秒后再试", wait)
+		}
+	}
+
+	captchaFails := self.setting(model.KeyLoginCaptchaFails)
+	needCaptcha := captchaFails > 0 && (userFails >= captchaFails || ipFails >= captchaFails)
+	return needCaptcha, nil
+}
+
+// RecordFail 记录一次登录失败，达到阈值时临时锁定账号或 IP，锁定账号时邮件通知账号主人
+func (self LoginGuardLogic) RecordFail(ctx context.Context, username, ip string) {
+	typ, account, userLogin := self.account(ctx, username)
+
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
+
+	userFails := self.incrFail(redis, typ, account)
+	ipFails := self.incrFail(redis, "ip", ip)
+
+	now := time.Now().Unix()
+	window := self.setting(model.KeyLoginFailWindow)
+	redis.SET(self.lastFailKey(typ, account), now, window)
+
+	lockTime := self.setting(model.KeyLoginLockTime)
+	if lockTime <= 0 {
+		return
+	}
+
+	lockFails := self.setting(model.KeyLoginLockFails)
+	if lockFails > 0 && userFails >= lockFails {
+		lockKey := self.lockKey(typ, account)
+		if goutils.MustInt64(redis.GET(lockKey)) <= now {
+			redis.SET(lockKey, now+int64(lockTime), lockTime)
+			logger.Infof("用户 %q 登录失败 %d 次，临时锁定 %d 秒，ip: %s", username, userFails, lockTime, ip)
+			if userLogin != nil {
+				go self.sendLockedMail(userLogin.Username, ip, lockTime)
+			}
+		}
+	}
+
+	ipLockFails := self.setting(model.KeyLoginIPLockFails)
+	if ipLockFails > 0 && ipFails >= ipLockFails {
+		lockKey := self.lockKey("ip", ip)
+		if goutils.MustInt64(redis.GET(lockKey)) <= now {
+			redis.SET(lockKey, now+int64(lockTime), lockTime)
+			logger.Infof("IP %s 登录失败 %d 次，临时禁止登录 %d 秒", ip, ipFails, lockTime)
+		}
+	}
+}
+
+// RecordSuccess 登录成功，清除该账号的失败记录（IP 的记录保留）
+func (self LoginGuardLogic) RecordSuccess(ctx context.Context, username string) {
+	typ, account, _ := self.account(ctx, username)
+
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
+
+	bucket := time.Now().Unix() / int64(self.setting(model.KeyLoginFailWindow))
+	prefix := self.failKeyPrefix(typ, account)
+	redis.DEL(prefix + strconv.FormatInt(bucket, 10))
+	redis.DEL(prefix + strconv.FormatInt(bucket-1, 10))
+	redis.DEL(self.lastFailKey(typ, account))
+}
+
+// CheckTotp 两步验证前检查：账号的两步验证是否因失败次数太多被临时锁定
+func (self LoginGuardLogic) CheckTotp(ctx context.Context, uid int) error {
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
+
+	now := time.Now().Unix()
+	unlockAt := goutils.MustInt64(redis.GET(self.lockKey("totp", strconv.Itoa(uid))))
+	if unlockAt > now {
+		minutes := (unlockAt - now + 59) / 60
+		return fmt.Errorf("两步验证失败次数太多，已被临时锁定，请 %d 分钟后再试", minutes)
+	}
+	return nil
+}
+
+// RecordTotpFail 记录一次两步验证失败。按账号累计，重新登录拿到新的凭证也不会清零，
+// 达到阈值时临时锁定该账号的两步验证，并邮件通知账号主人（能走到这一步说明密码已经泄露）
+func (self LoginGuardLogic) RecordTotpFail(ctx context.Context, uid int, username, ip string) {
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
+
+	fails := self.incrFail(redis, "totp", strconv.Itoa(uid))
+
+	lockFails := self.setting(model.KeyLoginTotpFails)
+	lockTime := self.setting(model.KeyLoginLockTime)
+	if lockFails <= 0 || lockTime <= 0 || fails < lockFails {
+		return
+	}
+
+	now := time.Now().Unix()
+	lockKey := self.lockKey("totp", strconv.Itoa(uid))
+	if goutils.MustInt64(redis.GET(lockKey)) <= now {
+		redis.SET(lockKey, now+int64(lockTime), lockTime)
+		logger.Infof("用户 %q 两步验证失败 %d 次，临时锁定 %d 秒，ip: %s", username, fails, lockTime, ip)
+		go self.sendLockedMail(username, ip, lockTime)
+	}
+}
+
+// IsFailError 是否是需要计入失败次数的登录错误（用户名或密码错误）
+func (LoginGuardLogic) IsFailError(err error) bool {
+	return errors.Is(err, ErrUsername) || errors.Is(err, ErrPasswd)
+}
+
+// account 登录输入（用户名或邮箱）对应的账号。账号存在时失败次数按 uid 计，
+// 换用户名或邮箱登录不会重新计数；不存在时按输入计
+func (LoginGuardLogic) account(ctx context.Context, username string) (typ, account string, userLogin *model.UserLogin) {
+	userLogin = &model.UserLogin{}
+	filter := bson.M{"$or": []bson.M{{"username": username}, {"email": username}}}
+	err := db.GetCollection("user_login").FindOne(ctx, filter).Decode(userLogin)
+	if err != nil || userLogin.Uid == 0 {
+		if err != nil && err != mongo.ErrNoDocuments {
+			GetLogger(ctx).Errorln("LoginGuardLogic find account error:", err)
+		}
+		return "user", strings.ToLower(username), nil
+	}
+	return "uid", strconv.Itoa(userLogin.Uid), userLogin
+}
+
+// failNum 滑动窗口内的失败次数：当前窗口的计数加上前一个窗口按剩余比例折算的计数
+func (self LoginGuardLogic) failNum(redis *nosql.RedisClient, typ, val string) int {
+	window := int64(self.setting(model.KeyLoginFailWindow))
+	now := time.Now().Unix()
+	bucket := now / window
+
+	prefix := self.failKeyPrefix(typ, val)
+	cur := goutils.MustInt64(redis.GET(prefix + strconv.FormatInt(bucket, 10)))
+	prev := goutils.MustInt64(redis.GET(prefix + strconv.FormatInt(bucket-1, 10)))
+
+	return int(cur + prev*(window-now%window)/window)
+}
+
+func (self LoginGuardLogic) incrFail(redis *nosql.RedisClient, typ, val string) int {
+	window := self.setting(model.KeyLoginFailWindow)
+	bucket := time.Now().Unix() / int64(window)
+
+	key := self.failKeyPrefix(typ, val) + strconv.FormatInt(bucket, 10)
+	redis.INCR(key)
+	redis.EXPIRE(key, 2*window)
+
+	return self.failNum(redis, typ, val)
+}
+
+// delay 第 n 次失败后需要等待的秒数：超过验证码阈值后按 1、2、4…秒递增，不超过最大值
+func (self LoginGuardLogic) delay(fails int) int64 {
+	maxDelay := int64(self.setting(model.KeyLoginMaxDelay))
+	start := self.setting(model.KeyLoginCaptchaFails)
+	if maxDelay <= 0 || fails < start || fails == 0 {
+		return 0
+	}
+
+	delay := int64(1)
+	for i := start; i < fails && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+func (LoginGuardLogic) setting(key string) int {
+	if val, ok := UserSetting[key]; ok {
+		if key == model.KeyLoginFailWindow && val <= 0 {
+			return defaultLoginGuardSetting[key]
+		}
+		return val
+	}
+	return defaultLoginGuardSetting[key]
+}
+
+func (LoginGuardLogic) sendLockedMail(username, ip string, lockTime int) {
+	userLogin := &model.UserLogin{}
+	filter := bson.M{"$or": []bson.M{{"username": username}, {"email": username}}}
+	err := db.GetCollection("user_login").FindOne(context.Background(), filter).Decode(userLogin)
+	if err != nil || userLogin.Email == "" {
+		return
+	}
+
+	global.App.SetCopyright()
+
+	content := `您好，` + userLogin.Username + `：<br/><br/>
+&nbsp;&nbsp;&nbsp;&nbsp;您在 ` + WebsiteSetting.Name + ` 的帐号短时间内多次登录失败（最近一次来自 IP：` + ip + `），为保护帐号安全，已被临时锁定 ` + strconv.Itoa(lockTime/60) + ` 分钟。<br/><br/>
+&nbsp;&nbsp;&nbsp;&nbsp;如果不是您本人操作，说明有人在尝试登录您的帐号，建议尽快修改密码并开启两步验证。<br/><br/>
+<div style="text-align:right;">&copy;` + global.App.Copyright + ` ` + WebsiteSetting.Name + `</div>`
+	if err = DefaultEmail.SendAuthMail("【"+WebsiteSetting.Name+"】帐号登录异常提醒", content, []string{userLogin.Email}); err != nil {
+		logger.Errorln("LoginGuardLogic send locked mail error:", err)
+	}
+}
+
+func (LoginGuardLogic) failKeyPrefix(typ, val string) string {
+	return "login:fail:" + typ + ":" + val + ":"
+}
+
+func (LoginGuardLogic) lastFailKey(typ, val string) string {
+	return "login:fail:last:" + typ + ":" + val
+}
+
+func (LoginGuardLogic) lockKey(typ, val string) string {
+	return "login:lock:" + typ + ":" + val
+}
@@ -295,6 +295,12 @@ func (self MessageLogic) FindSysMsgsByUid(ctx context.Context, uid int, paginato
 	userMap := DefaultUser.FindUserInfos(ctx, set.IntSlice(uidSet))
 	commentMap := DefaultComment.findByIds(set.IntSlice(cidSet))
 	topicMap := DefaultTopic.findByTids(set.IntSlice(tidSet))
+	// 被提到时，关注可见、自己可见的主题可能无权查看，不显示标题
+	for _, topic := range topicMap {
+		if !CanViewTopic(ctx, topic) {
+			topic.Title = "（无权查看的主题）"
+		}
+	}
 	articleMap := DefaultArticle.findByIds(set.IntSlice(articleIdSet))
 	resourceMap := DefaultResource.findByIds(set.IntSlice(resIdSet))
 	wikiMap := DefaultWiki.findByIds(set.IntSlice(wikiIdSet))
@@ -433,6 +439,22 @@ func (self MessageLogic) FindSysMsgsByUid(ctx context.Context, uid int, paginato
 				tmpMap["sprefix"] = "的专栏"
 				tmpMap["surl"] = "/subject/" + strconv.Itoa(subject.Id)
 				tmpMap["stitle"] = subject.Name
+			case model.MsgtypeModeration:
+				objTitle, _ = ext["title"].(string)
+				objUrl, _ = ext["url"].(string)
+				if int(ext["state"].(float64)) == model.ModerationStateApproved {
+					title = "你发布的内容已通过审核："
+				} else {
+					title = "你发布的内容未通过审核："
+				}
+			case model.MsgtypeReportResult:
+				objTitle, _ = ext["title"].(string)
+				objUrl, _ = ext["url"].(string)
+				if int(ext["state"].(float64)) == model.ReportStateValid {
+					title = "你举报的内容已处理（举报属实）："
+				} else {
+					title = "你举报的内容经核实未违规："
+				}
 			}
 			tmpMap["objtitle"] = objTitle
 			tmpMap["objurl"] = objUrl
@@ -0,0 +1,434 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+	"github.com/studygolang/studygolang/util"
+
+	"github.com/polaris1119/logger"
+	"github.com/polaris1119/set"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 批量审核一次最多处理的条数
+const maxModerationBatch = 100
+
+type ModerationLogic struct{}
+
+var DefaultModeration = ModerationLogic{}
+
+// Enqueue 把内容加入审核队列。同一内容已有待审核记录时，合并来源和原因
+func (ModerationLogic) Enqueue(ctx context.Context, item *model.ModerationItem, source string) error {
+	objLog := GetLogger(ctx)
+
+	id, err := db.NextID("moderation_item")
+	if err != nil {
+		objLog.Errorln("ModerationLogic Enqueue NextID error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	now := time.Now()
+	filter := bson.M{"objtype": item.Objtype, "objid": item.Objid, "state": model.ModerationStatePending}
+	change := bson.M{
+		"reason":     item.Reason,
+		"title":      item.Title,
+		"content":    item.Content,
+		"updated_at": now,
+	}
+	// 已隐藏的不因为后来的来源（如举报）而取消隐藏
+	if item.Held {
+		change["held"] = true
+	}
+	if !item.Created && item.Held {
+		change["prev_title"] = item.PrevTitle
+		change["prev_content"] = item.PrevContent
+	}
+	setOnInsert := bson.M{
+		"_id":        id,
+		"uid":        item.Uid,
+		"created":    item.Created,
+		"op_uid":     0,
+		"op_reason":  "",
+		"created_at": now,
+	}
+	if !item.Held {
+		setOnInsert["held"] = false
+	}
+
+	update := bson.M{
+		"$set":         change,
+		"$setOnInsert": setOnInsert,
+		"$addToSet":    bson.M{"sources": source},
+	}
+	_, err = db.GetCollection("moderation_item").UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		objLog.Errorln("ModerationLogic Enqueue error:", err)
+		return errors.New("内部服务错误")
+	}
+	return nil
+}
+
+// hold 发布策略要求审核的内容（已经以待审核状态入库），加入审核队列。
+// 修改送审时 before 是修改前的内容，拒绝时恢复；新发布的内容 before 为 nil
+func (self ModerationLogic) hold(ctx context.Context, action *PublishAction, objtype, objid, uid int, before *revisionSnapshot) {
+	source := model.ModerationSourceSensitive
+	if action.ReviewSource != "" {
+		source = action.ReviewSource
+	}
+
+	item := &model.ModerationItem{
+		Objtype: objtype,
+		Objid:   objid,
+		Uid:     uid,
+		Reason:  action.ReviewReason,
+		Held:    true,
+		Created: before == nil,
+		Title:   action.Title,
+		Content: action.Content,
+	}
+	if before != nil {
+		item.PrevTitle, item.PrevContent = before.title, before.content
+
+		// 送审期间再次修改的，保留第一次送审前的内容
+		pending := &model.ModerationItem{}
+		filter := bson.M{"objtype": objtype, "objid": objid, "state": model.ModerationStatePending, "held": true}
+		if err := db.GetCollection("moderation_item").FindOne(ctx, filter).Decode(pending); err == nil {
+			item.PrevTitle, item.PrevContent = pending.PrevTitle, pending.PrevContent
+		}
+	}
+	if err := self.Enqueue(ctx, item, source); err != nil {
+		GetLogger(ctx).Errorln("hold content error, objtype:", objtype, "objid:", objid, "err:", err)
+	}
+}
+
+// holdTopic 主题修改后需要审核：隐藏主题，下线动态
+func (ModerationLogic) holdTopic(ctx context.Context, tid int) {
+	_, err := db.GetCollection("topics").UpdateOne(ctx, bson.M{"_id": tid}, bson.M{"$set": bson.M{"flag": model.FlagPending}})
+	if err != nil {
+		GetLogger(ctx).Errorln("ModerationLogic holdTopic error:", err)
+		return
+	}
+	DefaultFeed.setOffline(ctx, tid, model.TypeTopic)
+}
+
+// FindAll 审核队列，state 小于 0 时不按状态过滤，source、objtype 小于 0 时同样
+func (ModerationLogic) FindAll(ctx context.Context, paginator *Paginator, state int, source string, objtype int) []*model.ModerationItem {
+	objLog := GetLogger(ctx)
+
+	filter := bson.M{}
+	if state >= 0 {
+		filter["state"] = state
+	}
+	if source != "" {
+		filter["sources"] = source
+	}
+	if objtype >= 0 {
+		filter["objtype"] = objtype
+	}
+
+	coll := db.GetCollection("moderation_item")
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		objLog.Errorln("ModerationLogic FindAll count error:", err)
+		return nil
+	}
+	paginator.SetTotal(total)
+
+	items := make([]*model.ModerationItem, 0)
+	// 待审核的先进先审，已处理的最近的在前
+	sort := bson.D{{Key: "_id", Value: -1}}
+	if state == model.ModerationStatePending {
+		sort = bson.D{{Key: "_id", Value: 1}}
+	}
+	opts := options.Find().SetSort(sort).SetSkip(int64(paginator.Offset())).SetLimit(int64(paginator.PerPage()))
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		objLog.Errorln("ModerationLogic FindAll error:", err)
+		return nil
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &items); err != nil {
+		objLog.Errorln("ModerationLogic FindAll decode error:", err)
+		return nil
+	}
+
+	uidSet := set.New(set.NonThreadSafe)
+	for _, item := range items {
+		uidSet.Add(item.Uid)
+	}
+	usersMap := DefaultUser.FindUserInfos(ctx, set.IntSlice(uidSet))
+	for _, item := range items {
+		item.User = usersMap[item.Uid]
+	}
+
+	return items
+}
+
+// Audit 审核（支持批量）：approve 为 true 时通过，否则拒绝并删除（下线）内容，修改送审的恢复修改前的内容。
+// reason 会以系统消息的形式发给作者。返回成功处理的条数
+func (self ModerationLogic) Audit(ctx context.Context, me *model.Me, ids []int, approve bool, reason string) (int, error) {
+	if len(ids) == 0 {
+		return 0, errors.New("请选择要审核的内容")
+	}
+	if len(ids) > maxModerationBatch {
+		return 0, errors.New("一次最多审核 " + strconv.Itoa(maxModerationBatch) + " 条")
+	}
+	if !approve && reason == "" {
+		return 0, errors.New("请填写拒绝原因")
+	}
+
+	num := 0
+	for _, id := range ids {
+		if err := self.audit(ctx, me, id, approve, reason); err != nil {
+			GetLogger(ctx).Errorln("ModerationLogic Audit id:", id, "error:", err)
+			if len(ids) == 1 {
+				return 0, err
+			}
+			continue
+		}
+		num++
+	}
+	return num, nil
+}
+
+func (self ModerationLogic) audit(ctx context.Context, me *model.Me, id int, approve bool, reason string) error {
+	objLog := GetLogger(ctx)
+
+	state := model.ModerationStateRejected
+	if approve {
+		state = model.ModerationStateApproved
+	}
+
+	// 先抢占状态，避免多人同时审核同一条
+	item := &model.ModerationItem{}
+	err := db.GetCollection("moderation_item").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "state": model.ModerationStatePending},
+		bson.M{"$set": bson.M{
+			"state":      state,
+			"op_uid":     me.Uid,
+			"op_reason":  reason,
+			"updated_at": time.Now(),
+		}}).Decode(item)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.New("该内容不存在或已审核")
+		}
+		objLog.Errorln("ModerationLogic audit error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	if approve {
+		if item.Held {
+			self.release(ctx, item)
+		}
+	} else if item.Held && !item.Created && !item.Reported() {
+		// 修改送审被拒绝，恢复修改前的内容；被举报的内容和新发布的一样下线
+		if err = self.restore(ctx, me, item); err != nil {
+			objLog.Errorln("ModerationLogic audit restore error:", err)
+		}
+	} else if err = self.takeDown(ctx, item.Objtype, item.Objid); err != nil {
+		objLog.Errorln("ModerationLogic audit take down error:", err)
+	}
+
+	// 举报进来、没有隐藏的内容审核通过，不需要打扰作者
+	if !approve || item.Held {
+		self.notifyAuthor(ctx, item, approve, reason)
+	}
+
+	if item.Reported() {
+		DefaultReport.resolve(ctx, item, !approve)
+	}
+	return nil
+}
+
+// release 审核通过，恢复被隐藏的内容；新发布的内容补发动态和通知
+func (ModerationLogic) release(ctx context.Context, item *model.ModerationItem) {
+	objLog := GetLogger(ctx)
+
+	switch item.Objtype {
+	case model.TypeTopic:
+		topic := DefaultTopic.findByTid(item.Objid)
+		if topic.Tid == 0 {
+			return
+		}
+
+		// 定时发布的主题：没到时间的继续等待，到时间的直接发布
+		if !topic.PublishAt.IsZero() {
+			if topic.PublishAt.After(time.Now()) {
+				_, err := db.GetCollection("topics").UpdateOne(ctx,
+					bson.M{"_id": item.Objid, "flag": model.FlagPending},
+					bson.M{"$set": bson.M{"flag": model.FlagScheduled}})
+				if err != nil {
+					objLog.Errorln("release scheduled topic error:", err)
+				}
+				return
+			}
+			DefaultSchedule.publishTopic(ctx, topic, model.FlagPending)
+			return
+		}
+
+		_, err := db.GetCollection("topics").UpdateOne(ctx,
+			bson.M{"_id": item.Objid, "flag": model.FlagPending},
+			bson.M{"$set": bson.M{"flag": model.FlagNormal}, "$unset": bson.M{"at_usernames": ""}})
+		if err != nil {
+			objLog.Errorln("release topic error:", err)
+			return
+		}
+
+		if item.Created {
+			DefaultTopic.published(ctx, nil, topic, topic.AtUsernames)
+		} else {
+			DefaultFeed.modifyTopicPermission(topic)
+			// 送审期间主题不可见，修改时同步标签已经解除了关联，这里重新关联
+			go DefaultTag.Sync(context.Background(), model.TypeTopic, item.Objid)
+		}
+	case model.TypeComment:
+		_, err := db.GetCollection("comments").UpdateOne(ctx,
+			bson.M{"_id": item.Objid, "flag": model.FlagPending},
+			bson.M{"$set": bson.M{"flag": model.FlagNormal}})
+		if err != nil {
+			objLog.Errorln("release comment error:", err)
+			return
+		}
+
+		if item.Created {
+			comment, err := DefaultComment.FindById(item.Objid)
+			if err == nil {
+				DefaultComment.published(ctx, comment, nil)
+			}
+		}
+	case model.TypeArticle:
+		// 只有被举报自动隐藏的博文会走到这里
+		_, err := db.GetCollection("articles").UpdateOne(ctx,
+			bson.M{"_id": item.Objid, "status": model.ArticleStatusOffline},
+			bson.M{"$set": bson.M{"status": model.ArticleStatusOnline}})
+		if err != nil {
+			objLog.Errorln("release article error:", err)
+			return
+		}
+		DefaultFeed.setOnline(ctx, item.Objid, model.TypeArticle)
+	}
+}
+
+// restore 修改送审被拒绝：恢复修改前的标题和内容，取消隐藏。主题恢复的内容作为新版本保存
+func (ModerationLogic) restore(ctx context.Context, me *model.Me, item *model.ModerationItem) error {
+	switch item.Objtype {
+	case model.TypeTopic:
+		topic := DefaultTopic.findByTid(item.Objid)
+		if topic.Tid == 0 {
+			return nil
+		}
+		before := DefaultRevision.snapshot(topic)
+
+		change := bson.M{
+			"title":      item.PrevTitle,
+			"content":    item.PrevContent,
+			"flag":       model.FlagNormal,
+			"editor_uid": me.Uid,
+			"mtime":      time.Now(),
+		}
+		_, err := db.GetCollection("topics").UpdateOne(ctx,
+			bson.M{"_id": item.Objid, "flag": model.FlagPending},
+			bson.M{"$set": change, "$unset": bson.M{"at_usernames": ""}})
+		if err != nil {
+			return err
+		}
+
+		DefaultRevision.record(ctx, model.TypeTopic, item.Objid, before, me.Uid, item.PrevTitle, item.PrevContent, "审核未通过，恢复修改前的内容", 0)
+		DefaultFeed.modifyTopicPermission(topic)
+		go DefaultTag.Sync(context.Background(), model.TypeTopic, item.Objid)
+	case model.TypeComment:
+		_, err := db.GetCollection("comments").UpdateOne(ctx,
+			bson.M{"_id": item.Objid, "flag": model.FlagPending},
+			bson.M{"$set": bson.M{"content": item.PrevContent, "flag": model.FlagNormal}})
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("不支持恢复该类型的内容：" + strconv.Itoa(item.Objtype))
+	}
+	return nil
+}
+
+// takeDown 审核拒绝，删除（下线）内容
+func (ModerationLogic) takeDown(ctx context.Context, objtype, objid int) error {
+	var err error
+	switch objtype {
+	case model.TypeTopic:
+		_, err = db.GetCollection("topics").UpdateOne(ctx, bson.M{"_id": objid}, bson.M{"$set": bson.M{"flag": model.FlagAuditDelete}})
+		if err == nil {
+			err = DefaultFeed.setOffline(ctx, objid, objtype)
+		}
+	case model.TypeComment:
+		_, err = db.GetCollection("comments").UpdateOne(ctx, bson.M{"_id": objid}, bson.M{"$set": bson.M{"flag": model.FlagAuditDelete}})
+	case model.TypeArticle:
+		_, err = db.GetCollection("articles").UpdateOne(ctx, bson.M{"_id": objid}, bson.M{"$set": bson.M{"status": model.ArticleStatusOffline}})
+		if err == nil {
+			err = DefaultFeed.setOffline(ctx, objid, objtype)
+		}
+	case model.TypeProject:
+		_, err = db.GetCollection("open_project").UpdateOne(ctx, bson.M{"_id": objid}, bson.M{"$set": bson.M{"status": model.ProjectStatusOffline}})
+		if err == nil {
+			err = DefaultFeed.setOffline(ctx, objid, objtype)
+		}
+	case model.TypeMessage:
+		_, err = db.GetCollection("message").UpdateOne(ctx, bson.M{"_id": objid}, bson.M{"$set": bson.M{
+			"fdel": model.FdelHasDel,
+			"tdel": model.TdelHasDel,
+		}})
+	default:
+		err = errors.New("不支持下线该类型的内容：" + strconv.Itoa(objtype))
+	}
+	return err
+}
+
+// notifyAuthor 把审核结果以系统消息发给作者
+func (ModerationLogic) notifyAuthor(ctx context.Context, item *model.ModerationItem, approve bool, reason string) {
+	title := item.Title
+	if title == "" {
+		title = util.Substring(item.Content, 30, "...")
+	}
+
+	state := model.ModerationStateRejected
+	if approve {
+		state = model.ModerationStateApproved
+	}
+	ext := map[string]interface{}{
+		"objid":   item.Objid,
+		"objtype": item.Objtype,
+		"title":   title,
+		"url":     moderationObjUrl(item),
+		"state":   state,
+		"content": reason,
+	}
+	if !DefaultMessage.SendSystemMsgTo(ctx, item.Uid, model.MsgtypeModeration, ext) {
+		logger.Errorln("send moderation result message error, item:", item.Id)
+	}
+}
+
+// moderationObjUrl 审核内容的地址，评论为所在主题（博文等）的地址，私信没有地址
+func moderationObjUrl(item *model.ModerationItem) string {
+	switch item.Objtype {
+	case model.TypeComment:
+		if comment, err := DefaultComment.FindById(item.Objid); err == nil {
+			return model.PathUrlMap[comment.Objtype] + strconv.Itoa(comment.Objid)
+		}
+		return ""
+	case model.TypeMessage:
+		return ""
+	}
+	return model.PathUrlMap[item.Objtype] + strconv.Itoa(item.Objid)
+}
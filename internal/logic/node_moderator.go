@@ -0,0 +1,384 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 节点最大层级，防止 parent 数据有环时死循环
+const maxNodeLevel = 10
+
+type NodeModeratorLogic struct{}
+
+var DefaultNodeModerator = NodeModeratorLogic{}
+
+// Assign 任命 username 为节点 nid 的版主，版主同时管理该节点的子节点
+func (self NodeModeratorLogic) Assign(ctx context.Context, me *model.Me, nid int, username string) error {
+	objLog := GetLogger(ctx)
+
+	node := DefaultNode.FindOne(nid)
+	if node.Nid == 0 {
+		return errors.New("节点不存在")
+	}
+
+	user := DefaultUser.FindOne(ctx, "username", username)
+	if user == nil || user.Uid == 0 {
+		return errors.New("用户不存在")
+	}
+
+	total, err := db.GetCollection("node_moderator").CountDocuments(ctx, bson.M{"nid": nid, "uid": user.Uid})
+	if err != nil {
+		objLog.Errorln("NodeModeratorLogic Assign count error:", err)
+		return errors.New("内部服务错误")
+	}
+	if total > 0 {
+		return errors.New("该用户已经是该节点的版主")
+	}
+
+	moderator := &model.NodeModerator{
+		Nid:       nid,
+		Uid:       user.Uid,
+		Username:  user.Username,
+		OpUid:     me.Uid,
+		CreatedAt: time.Now(),
+	}
+	moderator.Id, err = db.NextID("node_moderator")
+	if err != nil {
+		objLog.Errorln("NodeModeratorLogic Assign NextID error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	if _, err = db.GetCollection("node_moderator").InsertOne(ctx, moderator); err != nil {
+		objLog.Errorln("NodeModeratorLogic Assign insert error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	self.record(ctx, me, nid, model.ModActionAssign, objtypeNone, 0, user.Uid, "", "任命 "+user.Username+" 为 "+node.Name+" 版主")
+	return nil
+}
+
+// Revoke 撤销 uid 在节点 nid 的版主身份
+func (self NodeModeratorLogic) Revoke(ctx context.Context, me *model.Me, nid, uid int) error {
+	objLog := GetLogger(ctx)
+
+	moderator := &model.NodeModerator{}
+	err := db.GetCollection("node_moderator").FindOneAndDelete(ctx, bson.M{"nid": nid, "uid": uid}).Decode(moderator)
+	if err != nil {
+		objLog.Errorln("NodeModeratorLogic Revoke error:", err)
+		return NotFoundErr
+	}
+
+	node := DefaultNode.FindOne(nid)
+	self.record(ctx, me, nid, model.ModActionRevoke, objtypeNone, 0, uid, "", "撤销 "+moderator.Username+" 的 "+node.Name+" 版主")
+	return nil
+}
+
+// FindByNid 节点的版主（不包括上级节点的版主）
+func (NodeModeratorLogic) FindByNid(ctx context.Context, nid int) []*model.NodeModerator {
+	return findNodeModerators(ctx, bson.M{"nid": nid})
+}
+
+// FindByUid 用户担任版主的节点
+func (NodeModeratorLogic) FindByUid(ctx context.Context, uid int) []*model.NodeModerator {
+	return findNodeModerators(ctx, bson.M{"uid": uid})
+}
+
+// IsModerator 用户是否是节点 nid（或其上级节点）的版主
+func (NodeModeratorLogic) IsModerator(ctx context.Context, uid, nid int) bool {
+	if uid == 0 || nid == 0 {
+		return false
+	}
+
+	total, err := db.GetCollection("node_moderator").CountDocuments(ctx, bson.M{
+		"uid": uid,
+		"nid": bson.M{"$in": nodeAncestors(nid)},
+	})
+	if err != nil {
+		logger.Errorln("NodeModeratorLogic IsModerator error:", err)
+		return false
+	}
+	return total > 0
+}
+
+// CanModerate 用户能否管理节点 nid 下的内容：站长、社区管理员以及该节点的版主
+func (self NodeModeratorLogic) CanModerate(ctx context.Context, me *model.Me, nid int) bool {
+	if me == nil || me.Uid == 0 {
+		return false
+	}
+	if me.IsRoot || (me.IsAdmin && roleCanEdit(model.TopicAdmin, me)) {
+		return true
+	}
+	return self.IsModerator(ctx, me.Uid, nid)
+}
+
+// MoveTopic 移动主题到节点 nid，版主只能在自己管理的节点之间移动
+func (self NodeModeratorLogic) MoveTopic(ctx context.Context, me *model.Me, tid, nid int, reason string) error {
+	topic, err := self.findTopic(ctx, me, tid)
+	if err != nil {
+		return err
+	}
+	if topic.Nid == nid {
+		return nil
+	}
+
+	node := DefaultNode.FindOne(nid)
+	if node.Nid == 0 {
+		return errors.New("节点不存在")
+	}
+	if !self.CanModerate(ctx, me, nid) {
+		return errors.New("无权将主题移动到该节点")
+	}
+
+	if err = self.updateTopic(ctx, tid, bson.M{"nid": nid}); err != nil {
+		return err
+	}
+	DefaultFeed.modifyTopicNode(tid, nid)
+	go DefaultTimeline.modifyTopic(tid, nid, topic.Permission)
+
+	oldNode := DefaultNode.FindOne(topic.Nid)
+	self.record(ctx, me, nid, model.ModActionMove, model.TypeTopic, tid, topic.Uid, reason, oldNode.Name+" => "+node.Name)
+	return nil
+}
+
+// LockTopic 锁定（禁止回复）或解除锁定主题
+func (self NodeModeratorLogic) LockTopic(ctx context.Context, me *model.Me, tid int, lock bool, reason string) error {
+	topic, err := self.findTopic(ctx, me, tid)
+	if err != nil {
+		return err
+	}
+
+	if err = self.updateTopic(ctx, tid, bson.M{"close_reply": lock}); err != nil {
+		return err
+	}
+
+	action := model.ModActionLock
+	if !lock {
+		action = model.ModActionUnlock
+	}
+	self.record(ctx, me, topic.Nid, action, model.TypeTopic, tid, topic.Uid, reason, topic.Title)
+	return nil
+}
+
+// SetNodeTop 节点内置顶或取消置顶，只影响节点下的主题列表
+func (self NodeModeratorLogic) SetNodeTop(ctx context.Context, me *model.Me, tid int, top bool, reason string) error {
+	topic, err := self.findTopic(ctx, me, tid)
+	if err != nil {
+		return err
+	}
+
+	nodeTop, action := 0, model.ModActionNodeUntop
+	if top {
+		nodeTop, action = 1, model.ModActionNodeTop
+	}
+	if err = self.updateTopic(ctx, tid, bson.M{"node_top": nodeTop}); err != nil {
+		return err
+	}
+
+	self.record(ctx, me, topic.Nid, action, model.TypeTopic, tid, topic.Uid, reason, topic.Title)
+	return nil
+}
+
+// DeleteTopic 删除（软删除）主题
+func (self NodeModeratorLogic) DeleteTopic(ctx context.Context, me *model.Me, tid int, reason string) error {
+	topic, err := self.findTopic(ctx, me, tid)
+	if err != nil {
+		return err
+	}
+
+	if err = self.updateTopic(ctx, tid, bson.M{"flag": model.FlagAuditDelete}); err != nil {
+		return err
+	}
+	DefaultFeed.setOffline(ctx, tid, model.TypeTopic)
+
+	self.record(ctx, me, topic.Nid, model.ModActionDelete, model.TypeTopic, tid, topic.Uid, reason, topic.Title)
+	return nil
+}
+
+// DeleteComment 删除（软删除）主题下的评论
+func (self NodeModeratorLogic) DeleteComment(ctx context.Context, me *model.Me, cid int, reason string) error {
+	objLog := GetLogger(ctx)
+
+	comment, err := DefaultComment.FindById(cid)
+	if err != nil {
+		return errors.New("评论不存在")
+	}
+	if comment.Objtype != model.TypeTopic {
+		return NotModifyAuthorityErr
+	}
+
+	topic, err := self.findTopic(ctx, me, comment.Objid)
+	if err != nil {
+		return err
+	}
+
+	// 只删除正常的评论，已删除的再删除不能重复减少评论数，待审核的还没有计入评论数
+	filter := bson.M{
+		"_id":  cid,
+		"flag": bson.M{"$nin": []int{model.FlagPending, model.FlagAuditDelete, model.FlagUserDelete}},
+	}
+	result, err := db.GetCollection("comments").UpdateOne(ctx, filter, bson.M{"$set": bson.M{"flag": model.FlagAuditDelete}})
+	if err != nil {
+		objLog.Errorln("NodeModeratorLogic DeleteComment error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.ModifiedCount == 0 {
+		return errors.New("评论不存在")
+	}
+	go decrementCommentCount(comment.Objid, comment.Objtype)
+
+	self.record(ctx, me, topic.Nid, model.ModActionDeleteComment, model.TypeComment, cid, comment.Uid, reason, fmt.Sprintf("%s #%d楼", topic.Title, comment.Floor))
+	return nil
+}
+
+// FindLogs 版主操作记录，nid 为 0 时查询所有节点
+func (NodeModeratorLogic) FindLogs(ctx context.Context, nid int, paginator *Paginator) []*model.ModeratorLog {
+	objLog := GetLogger(ctx)
+
+	filter := bson.M{}
+	if nid != 0 {
+		filter["nid"] = bson.M{"$in": nodeDescendants(ctx, nid)}
+	}
+
+	total, err := db.GetCollection("moderator_log").CountDocuments(ctx, filter)
+	if err != nil {
+		objLog.Errorln("NodeModeratorLogic FindLogs count error:", err)
+		return nil
+	}
+	paginator.SetTotal(total)
+
+	logs := make([]*model.ModeratorLog, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetSkip(int64(paginator.Offset())).SetLimit(int64(paginator.PerPage()))
+	cursor, err := db.GetCollection("moderator_log").Find(ctx, filter, opts)
+	if err != nil {
+		objLog.Errorln("NodeModeratorLogic FindLogs error:", err)
+		return nil
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &logs); err != nil {
+		objLog.Errorln("NodeModeratorLogic FindLogs decode error:", err)
+	}
+	return logs
+}
+
+// recordEdit 非作者编辑主题时记录，由 TopicLogic.Modify 调用
+func (self NodeModeratorLogic) recordEdit(ctx context.Context, me *model.Me, topic *model.Topic, nid int) {
+	if me.Uid == topic.Uid {
+		return
+	}
+
+	detail := topic.Title
+	if nid != 0 && nid != topic.Nid {
+		detail += fmt.Sprintf("（节点 %d => %d）", topic.Nid, nid)
+	}
+	self.record(ctx, me, topic.Nid, model.ModActionEdit, model.TypeTopic, topic.Tid, topic.Uid, "", detail)
+}
+
+func (NodeModeratorLogic) findTopic(ctx context.Context, me *model.Me, tid int) (*model.Topic, error) {
+	topic := &model.Topic{}
+	err := db.GetCollection("topics").FindOne(ctx, bson.M{"_id": tid}).Decode(topic)
+	if err != nil {
+		return nil, errors.New("主题不存在")
+	}
+
+	if !DefaultNodeModerator.CanModerate(ctx, me, topic.Nid) {
+		return nil, NotModifyAuthorityErr
+	}
+	return topic, nil
+}
+
+func (NodeModeratorLogic) updateTopic(ctx context.Context, tid int, change bson.M) error {
+	// 更新 mtime，以便增量索引时同步到搜索引擎
+	change["mtime"] = time.Now()
+	_, err := db.GetCollection("topics").UpdateOne(ctx, bson.M{"_id": tid}, bson.M{"$set": change})
+	if err != nil {
+		GetLogger(ctx).Errorf("版主更新主题 【%d】 失败：%s", tid, err)
+		return errors.New("内部服务错误")
+	}
+	return nil
+}
+
+func (NodeModeratorLogic) record(ctx context.Context, me *model.Me, nid int, action string, objtype, objid, targetUid int, reason, detail string) {
+	modLog := &model.ModeratorLog{
+		Nid:       nid,
+		Uid:       me.Uid,
+		Username:  me.Username,
+		Action:    action,
+		Objtype:   objtype,
+		Objid:     objid,
+		TargetUid: targetUid,
+		Reason:    reason,
+		Detail:    detail,
+		CreatedAt: time.Now(),
+	}
+	if ip, ok := ctx.Value("ip").(string); ok {
+		modLog.Ip = ip
+	}
+
+	var err error
+	modLog.Id, err = db.NextID("moderator_log")
+	if err == nil {
+		_, err = db.GetCollection("moderator_log").InsertOne(ctx, modLog)
+	}
+	if err != nil {
+		GetLogger(ctx).Errorln("NodeModeratorLogic record error:", err, "log:", *modLog)
+	}
+}
+
+func findNodeModerators(ctx context.Context, filter bson.M) []*model.NodeModerator {
+	moderators := make([]*model.NodeModerator, 0)
+	cursor, err := db.GetCollection("node_moderator").Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		logger.Errorln("find node moderators error:", err)
+		return moderators
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &moderators); err != nil {
+		logger.Errorln("find node moderators decode error:", err)
+	}
+	return moderators
+}
+
+// nodeAncestors 节点自身及其所有上级节点
+func nodeAncestors(nid int) []int {
+	nids := []int{nid}
+	for i := 0; i < maxNodeLevel; i++ {
+		node := DefaultNode.FindOne(nid)
+		if node.Parent == 0 || node.Parent == nid {
+			break
+		}
+		nid = node.Parent
+		nids = append(nids, nid)
+	}
+	return nids
+}
+
+// nodeDescendants 节点自身及其所有子节点
+func nodeDescendants(ctx context.Context, nid int) []int {
+	children := make(map[int][]int)
+	for _, node := range DefaultNode.FindAll(ctx) {
+		children[node.Parent] = append(children[node.Parent], node.Nid)
+	}
+
+	nids := []int{nid}
+	for i := 0; i < len(nids) && len(nids) < 1000; i++ {
+		nids = append(nids, children[nids[i]]...)
+	}
+	return nids
+}
@@ -0,0 +1,358 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/config"
+	"github.com/polaris1119/logger"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// OAuthUserInfo 从第三方获取的用户信息，已按 claim 映射转换
+type OAuthUserInfo struct {
+	Id       string
+	Username string
+	Name     string
+	Email    string
+	Avatar   string
+	Website  string
+	Location string
+	Company  string
+}
+
+// OAuthProvider 一个 OAuth2/OIDC 登录方式，由配置文件中与 name 同名的 section 配置：
+//
+//	[oauth]
+//	providers = github,gitea,mycorp
+//
+//	[mycorp]
+//	display_name = 公司账号
+//	discovery_url = https://sso.example.com/.well-known/openid-configuration
+//	client_id = xxx
+//	client_secret = xxx
+//	scopes = openid,profile,email
+//	; claim 映射，默认值见 defaultClaims
+//	claim_username = preferred_username
+//
+// 不使用 discovery 时，可以直接配置 auth_url、token_url 和 userinfo_url
+type OAuthProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+
+	bindType     int
+	discoveryURL string
+	userInfoURL  string
+	claims       map[string]string
+	conf         *oauth2.Config
+
+	discoverLocker sync.Mutex
+	discovered     bool
+}
+
+// 用户信息字段 => 默认的 claim（OIDC 标准 claim）
+var defaultClaims = map[string]string{
+	"id":       "sub",
+	"username": "preferred_username",
+	"name":     "name",
+	"email":    "email",
+	"avatar":   "picture",
+	"website":  "website",
+	"location": "locale",
+	"company":  "",
+}
+
+// 内置的 GitHub 和 Gitea 配置，配置文件中的同名项会覆盖它们
+var builtinProviders = map[string]map[string]string{
+	"github": {
+		"display_name":   "GitHub",
+		"auth_url":       "https://github.com/login/oauth/authorize",
+		"token_url":      "https://github.com/login/oauth/access_token",
+		"userinfo_url":   GithubAPIBaseUrl + "/user",
+		"scopes":         "user:email",
+		"claim_id":       "id",
+		"claim_username": "login",
+		"claim_avatar":   "avatar_url",
+		"claim_website":  "blog",
+		"claim_location": "location",
+		"claim_company":  "company",
+	},
+	"gitea": {
+		"display_name":   "Gitea",
+		"auth_url":       "https://gitea.com/login/oauth/authorize",
+		"token_url":      "https://gitea.com/login/oauth/access_token",
+		"userinfo_url":   GiteaAPIBaseUrl + "/user",
+		"claim_id":       "id",
+		"claim_username": "login",
+		"claim_name":     "full_name",
+		"claim_avatar":   "avatar_url",
+		"claim_website":  "website",
+		"claim_location": "location",
+	},
+}
+
+var (
+	oauthProviders     = make(map[string]*OAuthProvider)
+	oauthProviderNames []string
+)
+
+func init() {
+	names := config.ConfigFile.MustValueArray("oauth", "providers", ",")
+	if len(names) == 0 {
+		names = []string{"github", "gitea"}
+	}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		provider := newOAuthProvider(name)
+		if provider.conf.ClientID == "" {
+			continue
+		}
+		oauthProviders[name] = provider
+		oauthProviderNames = append(oauthProviderNames, name)
+	}
+}
+
+func newOAuthProvider(name string) *OAuthProvider {
+	builtin := builtinProviders[name]
+	value := func(key string) string {
+		val := config.ConfigFile.MustValue(name, key)
+		if val == "" {
+			val = builtin[key]
+		}
+		return val
+	}
+
+	provider := &OAuthProvider{
+		Name:         name,
+		DisplayName:  value("display_name"),
+		bindType:     model.BindTypeOAuth,
+		discoveryURL: value("discovery_url"),
+		userInfoURL:  value("userinfo_url"),
+		claims:       make(map[string]string, len(defaultClaims)),
+		conf: &oauth2.Config{
+			ClientID:     value("client_id"),
+			ClientSecret: value("client_secret"),
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  value("auth_url"),
+				TokenURL: value("token_url"),
+			},
+		},
+	}
+	if provider.DisplayName == "" {
+		provider.DisplayName = name
+	}
+
+	switch name {
+	case "github":
+		provider.bindType = model.BindTypeGithub
+	case "gitea":
+		provider.bindType = model.BindTypeGitea
+	}
+
+	if scopes := value("scopes"); scopes != "" {
+		for _, scope := range strings.Split(scopes, ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				provider.conf.Scopes = append(provider.conf.Scopes, scope)
+			}
+		}
+	} else if provider.discoveryURL != "" {
+		provider.conf.Scopes = []string{"openid", "profile", "email"}
+	}
+
+	for field, claim := range defaultClaims {
+		if val := value("claim_" + field); val != "" {
+			claim = val
+		}
+		provider.claims[field] = claim
+	}
+
+	return provider
+}
+
+// OAuthProviders 已启用的第三方登录方式，按配置顺序
+func OAuthProviders() []*OAuthProvider {
+	providers := make([]*OAuthProvider, 0, len(oauthProviderNames))
+	for _, name := range oauthProviderNames {
+		providers = append(providers, oauthProviders[name])
+	}
+	return providers
+}
+
+// FindOAuthProvider 根据名称获取登录方式
+func FindOAuthProvider(name string) (*OAuthProvider, error) {
+	provider, ok := oauthProviders[name]
+	if !ok {
+		return nil, errors.New("不支持的登录方式：" + name)
+	}
+	return provider, nil
+}
+
+// AuthCodeURL 跳转到第三方授权页面的地址
+func (p *OAuthProvider) AuthCodeURL(ctx context.Context, redirectURL, state string) (string, error) {
+	conf, err := p.config(ctx, redirectURL)
+	if err != nil {
+		return "", err
+	}
+	return conf.AuthCodeURL(state, oauth2.AccessTypeOffline), nil
+}
+
+// Exchange 用授权码换取 token，并获取用户信息
+func (p *OAuthProvider) Exchange(ctx context.Context, redirectURL, code string) (*OAuthUserInfo, *oauth2.Token, error) {
+	conf, err := p.config(ctx, redirectURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token, err := conf.Exchange(ctx, code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := conf.Client(ctx, token).Get(p.userInfoURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("get %s user info error, status: %d", p.Name, resp.StatusCode)
+	}
+
+	userInfo, err := p.parseUserInfo(respBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return userInfo, token, nil
+}
+
+// parseUserInfo 按 claim 映射解析 userinfo 接口返回的 JSON
+func (p *OAuthProvider) parseUserInfo(data []byte) (*OAuthUserInfo, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	claims := make(map[string]interface{})
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, err
+	}
+
+	claim := func(field string) string {
+		name := p.claims[field]
+		if name == "" {
+			return ""
+		}
+		val, ok := claims[name]
+		if !ok || val == nil {
+			return ""
+		}
+		return strings.TrimSpace(fmt.Sprint(val))
+	}
+
+	userInfo := &OAuthUserInfo{
+		Id:       claim("id"),
+		Username: claim("username"),
+		Name:     claim("name"),
+		Email:    claim("email"),
+		Avatar:   claim("avatar"),
+		Website:  claim("website"),
+		Location: claim("location"),
+		Company:  claim("company"),
+	}
+	if userInfo.Id == "" || userInfo.Id == "0" {
+		return nil, fmt.Errorf("get %s user info error: no %s claim", p.Name, p.claims["id"])
+	}
+
+	// 没有用户名时，取邮箱的前缀
+	if userInfo.Username == "" {
+		if pos := strings.Index(userInfo.Email, "@"); pos > 0 {
+			userInfo.Username = userInfo.Email[:pos]
+		}
+	}
+	if userInfo.Username == "" {
+		return nil, fmt.Errorf("get %s user info error: no username", p.Name)
+	}
+	if userInfo.Name == "" {
+		userInfo.Name = userInfo.Username
+	}
+
+	return userInfo, nil
+}
+
+// config 返回设置了回调地址的配置副本；配置了 discovery_url 的，首次使用时获取各个端点
+func (p *OAuthProvider) config(ctx context.Context, redirectURL string) (*oauth2.Config, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	conf := *p.conf
+	conf.RedirectURL = redirectURL
+	return &conf, nil
+}
+
+func (p *OAuthProvider) discover(ctx context.Context) error {
+	if p.discoveryURL == "" {
+		return nil
+	}
+
+	p.discoverLocker.Lock()
+	defer p.discoverLocker.Unlock()
+
+	if p.discovered {
+		return nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(p.discoveryURL)
+	if err != nil {
+		logger.Errorln("OAuthProvider discover", p.Name, "error:", err)
+		return errors.New("获取登录配置失败，请稍后再试")
+	}
+	defer resp.Body.Close()
+
+	metadata := struct {
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		logger.Errorln("OAuthProvider discover", p.Name, "decode error:", err)
+		return errors.New("获取登录配置失败，请稍后再试")
+	}
+
+	// 配置文件中明确配置的端点优先
+	if p.conf.Endpoint.AuthURL == "" {
+		p.conf.Endpoint.AuthURL = metadata.AuthorizationEndpoint
+	}
+	if p.conf.Endpoint.TokenURL == "" {
+		p.conf.Endpoint.TokenURL = metadata.TokenEndpoint
+	}
+	if p.userInfoURL == "" {
+		p.userInfoURL = metadata.UserinfoEndpoint
+	}
+	p.discovered = true
+
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// objectDoc 各类内容（主题、文章等）的公共字段。各类内容的字段名不同，由 objectSource.fields 选取
+type objectDoc struct {
+	Id        int             `bson:"_id"`
+	Tags      string          `bson:"tags"`
+	Title     string          `bson:"title"`
+	Name      string          `bson:"name"`
+	Category  string          `bson:"category"`
+	Content   string          `bson:"content"`
+	Txt       string          `bson:"txt"`
+	Desc      string          `bson:"desc"`
+	Ctime     model.OftenTime `bson:"ctime"`
+	CreatedAt model.OftenTime `bson:"created_at"`
+}
+
+// objectSource 一类内容：所在集合、可见条件，以及标题、正文和发布时间
+type objectSource struct {
+	coll    string
+	visible bson.M
+	fields  func(doc *objectDoc) (title, content string, ctime model.OftenTime)
+}
+
+var objectSources = map[int]*objectSource{
+	model.TypeTopic: {
+		coll: "topics",
+		visible: bson.M{
+			"flag":       bson.M{"$lt": model.FlagAuditDelete},
+			"permission": bson.M{"$nin": []int{model.PermissionFollow, model.PermissionOnlyMe}},
+		},
+		fields: func(doc *objectDoc) (string, string, model.OftenTime) { return doc.Title, doc.Content, doc.Ctime },
+	},
+	model.TypeArticle: {
+		coll:    "articles",
+		visible: bson.M{"status": bson.M{"$lt": model.ArticleStatusOffline}},
+		fields:  func(doc *objectDoc) (string, string, model.OftenTime) { return doc.Title, doc.Txt, doc.Ctime },
+	},
+	model.TypeResource: {
+		coll:    "resource",
+		visible: bson.M{},
+		fields:  func(doc *objectDoc) (string, string, model.OftenTime) { return doc.Title, doc.Content, doc.Ctime },
+	},
+	model.TypeWiki: {
+		coll:    "wiki",
+		visible: bson.M{},
+		fields:  func(doc *objectDoc) (string, string, model.OftenTime) { return doc.Title, doc.Content, doc.Ctime },
+	},
+	model.TypeProject: {
+		coll:    "open_project",
+		visible: bson.M{"status": bson.M{"$in": []int{model.ProjectStatusNew, model.ProjectStatusOnline}}},
+		fields: func(doc *objectDoc) (string, string, model.OftenTime) {
+			return doc.Category + " " + doc.Name, doc.Desc, doc.Ctime
+		},
+	},
+	model.TypeBook: {
+		coll:    "book",
+		visible: bson.M{},
+		fields:  func(doc *objectDoc) (string, string, model.OftenTime) { return doc.Name, doc.Desc, doc.CreatedAt },
+	},
+}
+
+// 遍历所有内容时按该顺序处理
+var objectTypes = []int{model.TypeTopic, model.TypeArticle, model.TypeResource, model.TypeWiki, model.TypeProject, model.TypeBook}
+
+// findObject 查找可见的内容，不存在、不可见或类型不支持时返回 nil
+func findObject(ctx context.Context, objtype, objid int) (*objectSource, *objectDoc) {
+	source, ok := objectSources[objtype]
+	if !ok {
+		return nil, nil
+	}
+
+	filter := bson.M{"_id": objid}
+	for k, v := range source.visible {
+		filter[k] = v
+	}
+	doc := &objectDoc{}
+	if err := db.GetCollection(source.coll).FindOne(ctx, filter).Decode(doc); err != nil {
+		return source, nil
+	}
+	return source, doc
+}
+
+// CanViewObject 当前用户能否查看内容，查看评论、投票等附属内容前检查。主题还要判断可见范围（CanViewTopic）；
+// 其他类型按 objectSources 中的可见条件，不在其中的类型不限制
+func CanViewObject(ctx context.Context, objtype, objid int) bool {
+	if objtype == model.TypeTopic {
+		topic := DefaultTopic.findByTid(objid)
+		return topic.Tid != 0 && topic.Flag <= model.FlagNormal && CanViewTopic(ctx, topic)
+	}
+
+	if _, ok := objectSources[objtype]; !ok {
+		return true
+	}
+	_, doc := findObject(ctx, objtype, objid)
+	return doc != nil
+}
@@ -28,16 +28,20 @@ func init() {
 	publishObservable.AddObserver(&UserWeightObserver{})
 	publishObservable.AddObserver(&TodayActiveObserver{})
 	publishObservable.AddObserver(&UserRichObserver{})
+	publishObservable.AddObserver(&TimelineObserver{})
+	publishObservable.AddObserver(&TagObserver{})
 
 	modifyObservable = NewConcreteObservable(actionModify)
 	modifyObservable.AddObserver(&UserWeightObserver{})
 	modifyObservable.AddObserver(&TodayActiveObserver{})
 	modifyObservable.AddObserver(&UserRichObserver{})
+	modifyObservable.AddObserver(&TagObserver{})
 
 	commentObservable = NewConcreteObservable(actionComment)
 	commentObservable.AddObserver(&UserWeightObserver{})
 	commentObservable.AddObserver(&TodayActiveObserver{})
 	commentObservable.AddObserver(&UserRichObserver{})
+	commentObservable.AddObserver(&TimelineObserver{})
 
 	ViewObservable = NewConcreteObservable(actionView)
 	ViewObservable.AddObserver(&UserWeightObserver{})
@@ -0,0 +1,337 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/goutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	pollMinOptions   = 2
+	pollMaxOptions   = 20
+	pollOptionMaxLen = 100
+)
+
+// 并发改票时，没抢到的请求返回该错误
+var errPollConflict = errors.New("投票太频繁，请稍后再试")
+
+type PollLogic struct{}
+
+var DefaultPoll = PollLogic{}
+
+// newPoll 从发布主题的表单中解析投票，没有 poll_options 时返回 nil。
+// poll_options 每行一个选项；poll_multiple 是否多选，poll_max_choices 多选时最多选几项；
+// poll_show_result 结果什么时候可见；poll_allow_change 是否允许改票；poll_close_at 截止时间
+func (PollLogic) newPoll(form url.Values) (*model.Poll, error) {
+	titles := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(form.Get("poll_options"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > pollOptionMaxLen {
+			return nil, fmt.Errorf("投票选项不能超过 %d 个字", pollOptionMaxLen)
+		}
+		if seen[line] {
+			return nil, errors.New("投票选项不能重复")
+		}
+		seen[line] = true
+		titles = append(titles, line)
+	}
+	if len(titles) == 0 {
+		return nil, nil
+	}
+	if len(titles) < pollMinOptions || len(titles) > pollMaxOptions {
+		return nil, fmt.Errorf("投票选项为 %d 到 %d 个", pollMinOptions, pollMaxOptions)
+	}
+
+	poll := &model.Poll{
+		MaxChoices: goutils.MustInt(form.Get("poll_max_choices")),
+		ShowResult: goutils.MustInt(form.Get("poll_show_result")),
+		Options:    make([]*model.PollOption, len(titles)),
+	}
+	poll.Multiple, _ = strconv.ParseBool(form.Get("poll_multiple"))
+	poll.AllowChange, _ = strconv.ParseBool(form.Get("poll_allow_change"))
+	for i, title := range titles {
+		poll.Options[i] = &model.PollOption{Id: i, Title: title}
+	}
+
+	if poll.ShowResult != model.PollResultAlways && poll.ShowResult != model.PollResultVoted {
+		return nil, errors.New("投票结果的可见方式不正确")
+	}
+	if !poll.Multiple {
+		poll.MaxChoices = 0
+	} else if poll.MaxChoices < 0 || poll.MaxChoices > len(titles) {
+		return nil, errors.New("最多可选项数不正确")
+	}
+
+	if closeAt := form.Get("poll_close_at"); closeAt != "" {
+		var err error
+		for _, layout := range publishAtLayouts {
+			if poll.CloseAt, err = time.ParseInLocation(layout, closeAt, time.Local); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, errors.New("投票截止时间格式不正确")
+		}
+		if poll.CloseAt.Before(time.Now()) {
+			return nil, errors.New("投票截止时间必须晚于现在")
+		}
+	}
+
+	return poll, nil
+}
+
+// fillForm newPoll 的逆过程，定时发布的主题取消时把投票一起存到草稿中
+func (PollLogic) fillForm(form url.Values, poll *model.Poll) {
+	titles := make([]string, len(poll.Options))
+	for i, option := range poll.Options {
+		titles[i] = option.Title
+	}
+	form.Set("poll_options", strings.Join(titles, "\n"))
+	form.Set("poll_multiple", strconv.FormatBool(poll.Multiple))
+	form.Set("poll_max_choices", strconv.Itoa(poll.MaxChoices))
+	form.Set("poll_show_result", strconv.Itoa(poll.ShowResult))
+	form.Set("poll_allow_change", strconv.FormatBool(poll.AllowChange))
+	if !poll.CloseAt.IsZero() {
+		form.Set("poll_close_at", poll.CloseAt.Format(publishAtLayouts[0]))
+	}
+}
+
+// FindByTid 主题的投票（按 me 是否可以看结果处理过），没有或者看不到主题时返回 nil
+func (self PollLogic) FindByTid(ctx context.Context, me *model.Me, tid int) *model.Poll {
+	topic := DefaultTopic.findByTid(tid)
+	if topic.Tid == 0 || topic.Flag > model.FlagNormal || !CanViewTopic(ctx, topic) {
+		return nil
+	}
+
+	return self.findByTid(ctx, me, tid)
+}
+
+// findByTid 和 FindByTid 一样，但不检查主题是否可见，调用方需要自己检查
+func (self PollLogic) findByTid(ctx context.Context, me *model.Me, tid int) *model.Poll {
+	poll := &model.Poll{}
+	err := db.GetCollection("poll").FindOne(ctx, bson.M{"tid": tid}).Decode(poll)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			GetLogger(ctx).Errorln("PollLogic FindByTid error:", err)
+		}
+		return nil
+	}
+
+	self.fillForUser(ctx, me, poll)
+	return poll
+}
+
+// Vote me 给投票 pollID 投票，choices 为选项 Id。允许改票的投票再次投票即为改票
+func (self PollLogic) Vote(ctx context.Context, me *model.Me, pollID int, choices []int) (*model.Poll, error) {
+	objLog := GetLogger(ctx)
+
+	poll := &model.Poll{}
+	err := db.GetCollection("poll").FindOne(ctx, bson.M{"_id": pollID}).Decode(poll)
+	if err != nil {
+		return nil, errors.New("投票不存在")
+	}
+	if poll.IsClosed() {
+		return nil, errors.New("投票已经截止")
+	}
+
+	topic := &model.Topic{}
+	err = db.GetCollection("topics").FindOne(ctx, bson.M{"_id": poll.Tid}).Decode(topic)
+	if err != nil || topic.Flag > model.FlagNormal || !CanViewTopic(ctx, topic) {
+		return nil, errors.New("投票不存在")
+	}
+
+	choices, err = self.checkChoices(poll, choices)
+	if err != nil {
+		return nil, err
+	}
+
+	oldVote := &model.PollVote{}
+	err = db.GetCollection("poll_vote").FindOne(ctx, bson.M{"poll_id": pollID, "uid": me.Uid}).Decode(oldVote)
+	if err != nil && err != mongo.ErrNoDocuments {
+		objLog.Errorln("PollLogic Vote find vote error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	if oldVote.Id != 0 && !poll.AllowChange {
+		return nil, errors.New("你已经投过票了")
+	}
+
+	// 各选项的票数变化
+	inc := bson.M{}
+	for _, choice := range oldVote.Choices {
+		inc["options."+strconv.Itoa(choice)+".votes"] = -1
+	}
+	for _, choice := range choices {
+		key := "options." + strconv.Itoa(choice) + ".votes"
+		if n, ok := inc[key].(int); ok {
+			inc[key] = n + 1
+		} else {
+			inc[key] = 1
+		}
+	}
+
+	now := time.Now()
+	vote := &model.PollVote{
+		PollId:    pollID,
+		Uid:       me.Uid,
+		Choices:   choices,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if oldVote.Id == 0 {
+		inc["voters"] = 1
+		vote.Id, err = db.NextID("poll_vote")
+		if err != nil {
+			objLog.Errorln("PollLogic Vote NextID error:", err)
+			return nil, errors.New("内部服务错误")
+		}
+	}
+
+	session, err := db.GetClient().StartSession()
+	if err != nil {
+		objLog.Errorln("PollLogic Vote StartSession error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if oldVote.Id == 0 {
+			// (poll_id, uid) 有唯一索引，重复提交时只有一个成功
+			if _, txErr := db.GetCollection("poll_vote").InsertOne(sessCtx, vote); txErr != nil {
+				return nil, txErr
+			}
+		} else {
+			// 带上原来的选择，并发改票时只有一个成功，保证票数一致
+			result, txErr := db.GetCollection("poll_vote").UpdateOne(sessCtx,
+				bson.M{"_id": oldVote.Id, "choices": oldVote.Choices},
+				bson.M{"$set": bson.M{"choices": choices, "updated_at": now}})
+			if txErr != nil {
+				return nil, txErr
+			}
+			if result.ModifiedCount == 0 {
+				return nil, errPollConflict
+			}
+		}
+
+		_, txErr := db.GetCollection("poll").UpdateOne(sessCtx, bson.M{"_id": pollID}, bson.M{"$inc": inc})
+		return nil, txErr
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("你已经投过票了")
+		}
+		if err == errPollConflict {
+			return nil, err
+		}
+		objLog.Errorln("PollLogic Vote transaction error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+
+	poll = self.findByTid(ctx, me, poll.Tid)
+	if poll != nil {
+		go self.broadcast(poll, topic)
+	}
+	return poll, nil
+}
+
+// checkChoices 检查选择是否合法，返回去重、升序后的选项
+func (PollLogic) checkChoices(poll *model.Poll, choices []int) ([]int, error) {
+	seen := make(map[int]bool)
+	result := make([]int, 0, len(choices))
+	for _, choice := range choices {
+		if choice < 0 || choice >= len(poll.Options) {
+			return nil, errors.New("投票选项不存在")
+		}
+		if !seen[choice] {
+			seen[choice] = true
+			result = append(result, choice)
+		}
+	}
+	sort.Ints(result)
+
+	if len(result) == 0 {
+		return nil, errors.New("请选择投票选项")
+	}
+	if !poll.Multiple && len(result) > 1 {
+		return nil, errors.New("该投票只能选一项")
+	}
+	if poll.MaxChoices > 0 && len(result) > poll.MaxChoices {
+		return nil, fmt.Errorf("最多只能选 %d 项", poll.MaxChoices)
+	}
+	return result, nil
+}
+
+// fillForUser 填充 me 的选择；结果投票后可见的，没投票时隐藏票数（发起人和投票截止后除外）
+func (PollLogic) fillForUser(ctx context.Context, me *model.Me, poll *model.Poll) {
+	poll.Closed = poll.IsClosed()
+
+	uid := 0
+	if me != nil {
+		uid = me.Uid
+	}
+	if uid != 0 {
+		vote := &model.PollVote{}
+		err := db.GetCollection("poll_vote").FindOne(ctx, bson.M{"poll_id": poll.Id, "uid": uid}).Decode(vote)
+		if err == nil {
+			poll.MyChoices = vote.Choices
+		}
+	}
+
+	if poll.ShowResult == model.PollResultVoted && !poll.Closed && poll.MyChoices == nil && uid != poll.Uid {
+		poll.Hidden = true
+		for _, option := range poll.Options {
+			option.Votes = 0
+		}
+	}
+}
+
+// broadcast 通过 WebSocket 通知在线用户投票结果有变化。结果投票后可见的只发投票人数，客户端自己再取结果。
+// 只对部分用户可见的主题，只通知在线的作者和能看到的关注者
+func (PollLogic) broadcast(poll *model.Poll, topic *model.Topic) {
+	body := map[string]interface{}{
+		"tid":     poll.Tid,
+		"poll_id": poll.Id,
+		"voters":  poll.Voters,
+	}
+	if poll.ShowResult == model.PollResultAlways {
+		body["options"] = poll.Options
+	}
+	message := NewMessage(WsMsgPoll, body)
+
+	if !topic.Restricted() {
+		Book.BroadcastAllUsersMessage(message)
+		return
+	}
+
+	Book.PostMessage(topic.Uid, message)
+	if topic.Permission != model.PermissionFollow {
+		return
+	}
+	ctx := context.Background()
+	for _, loginUser := range Book.LoginUserData() {
+		if loginUser.Uid != topic.Uid && DefaultUserFollow.IsFollowing(ctx, loginUser.Uid, topic.Uid) {
+			Book.PostMessage(loginUser.Uid, message)
+		}
+	}
+}
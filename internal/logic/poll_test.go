@@ -0,0 +1,138 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studygolang/studygolang/internal/model"
+)
+
+func TestNewPoll(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour).Format(publishAtLayouts[0])
+	past := time.Now().Add(-time.Hour).Format(publishAtLayouts[0])
+
+	tests := []struct {
+		name       string
+		form       url.Values
+		wantNil    bool
+		wantErr    bool
+		titles     []string
+		multiple   bool
+		maxChoices int
+	}{
+		{name: "没有投票", form: url.Values{}, wantNil: true},
+		{name: "只有空行", form: url.Values{"poll_options": {"\n  \n"}}, wantNil: true},
+		{
+			name:   "单选，去掉空行和首尾空白",
+			form:   url.Values{"poll_options": {" Go \n\nRust\n"}, "poll_max_choices": {"2"}},
+			titles: []string{"Go", "Rust"},
+		},
+		{
+			name:       "多选",
+			form:       url.Values{"poll_options": {"a\nb\nc"}, "poll_multiple": {"true"}, "poll_max_choices": {"2"}},
+			titles:     []string{"a", "b", "c"},
+			multiple:   true,
+			maxChoices: 2,
+		},
+		{name: "选项太少", form: url.Values{"poll_options": {"a"}}, wantErr: true},
+		{name: "选项太多", form: url.Values{"poll_options": {manyPollOptions(pollMaxOptions + 1)}}, wantErr: true},
+		{name: "选项重复", form: url.Values{"poll_options": {"a\nb\na"}}, wantErr: true},
+		{name: "选项太长", form: url.Values{"poll_options": {"a\n" + strings.Repeat("长", pollOptionMaxLen+1)}}, wantErr: true},
+		{name: "结果可见方式不正确", form: url.Values{"poll_options": {"a\nb"}, "poll_show_result": {"9"}}, wantErr: true},
+		{name: "最多可选项数超过选项数", form: url.Values{"poll_options": {"a\nb"}, "poll_multiple": {"true"}, "poll_max_choices": {"3"}}, wantErr: true},
+		{name: "截止时间格式不正确", form: url.Values{"poll_options": {"a\nb"}, "poll_close_at": {"明天"}}, wantErr: true},
+		{name: "截止时间已过", form: url.Values{"poll_options": {"a\nb"}, "poll_close_at": {past}}, wantErr: true},
+		{name: "截止时间", form: url.Values{"poll_options": {"a\nb"}, "poll_close_at": {future}}, titles: []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		poll, err := DefaultPoll.newPoll(tt.form)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: want error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if tt.wantNil {
+			if poll != nil {
+				t.Errorf("%s: want nil poll", tt.name)
+			}
+			continue
+		}
+
+		titles := make([]string, len(poll.Options))
+		for i, option := range poll.Options {
+			if option.Id != i {
+				t.Errorf("%s: option %d has id %d", tt.name, i, option.Id)
+			}
+			titles[i] = option.Title
+		}
+		if !reflect.DeepEqual(titles, tt.titles) {
+			t.Errorf("%s: options = %v, want %v", tt.name, titles, tt.titles)
+		}
+		if poll.Multiple != tt.multiple || poll.MaxChoices != tt.maxChoices {
+			t.Errorf("%s: multiple=%v max_choices=%d, want multiple=%v max_choices=%d", tt.name,
+				poll.Multiple, poll.MaxChoices, tt.multiple, tt.maxChoices)
+		}
+		if tt.form.Get("poll_close_at") != "" && poll.CloseAt.IsZero() {
+			t.Errorf("%s: close_at not set", tt.name)
+		}
+	}
+}
+
+func manyPollOptions(n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = strings.Repeat("x", i+1)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestCheckChoices(t *testing.T) {
+	options := []*model.PollOption{{Id: 0}, {Id: 1}, {Id: 2}, {Id: 3}}
+	single := &model.Poll{Options: options}
+	multiple := &model.Poll{Options: options, Multiple: true}
+	limited := &model.Poll{Options: options, Multiple: true, MaxChoices: 2}
+
+	tests := []struct {
+		name    string
+		poll    *model.Poll
+		choices []int
+		want    []int
+		wantErr bool
+	}{
+		{name: "单选", poll: single, choices: []int{2}, want: []int{2}},
+		{name: "单选重复的算一项", poll: single, choices: []int{1, 1}, want: []int{1}},
+		{name: "单选选了多项", poll: single, choices: []int{0, 1}, wantErr: true},
+		{name: "没有选择", poll: single, choices: nil, wantErr: true},
+		{name: "选项不存在", poll: single, choices: []int{4}, wantErr: true},
+		{name: "选项为负数", poll: multiple, choices: []int{-1}, wantErr: true},
+		{name: "多选去重并排序", poll: multiple, choices: []int{3, 0, 3, 1}, want: []int{0, 1, 3}},
+		{name: "多选不超过上限", poll: limited, choices: []int{2, 1}, want: []int{1, 2}},
+		{name: "多选超过上限", poll: limited, choices: []int{0, 1, 2}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := DefaultPoll.checkChoices(tt.poll, tt.choices)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: want error, got %v", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -38,6 +38,14 @@ func (self ProjectLogic) Publish(ctx context.Context, user *model.Me, form url.V
 	id := form.Get("id")
 	isModify := id != ""
 
+	kind := PublishKindCreate
+	if isModify {
+		kind = PublishKindModify
+	}
+	if err = CheckPublish(ctx, NewPublishAction(ctx, user, kind, model.TypeProject, form)); err != nil {
+		return
+	}
+
 	project := &model.OpenProject{}
 
 	if isModify {
@@ -48,7 +56,7 @@ func (self ProjectLogic) Publish(ctx context.Context, user *model.Me, form url.V
 			return
 		}
 
-		if !CanEdit(user, project) {
+		if !CanEdit(ctx, user, project) {
 			err = NotModifyAuthorityErr
 			return
 		}
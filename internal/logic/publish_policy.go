@@ -0,0 +1,368 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/studygolang/studygolang/internal/model"
+	"github.com/studygolang/studygolang/util"
+
+	"github.com/dchest/captcha"
+	"github.com/polaris1119/config"
+	"github.com/polaris1119/goutils"
+	"github.com/polaris1119/logger"
+)
+
+// 发布行为的类型
+const (
+	PublishKindCreate  = iota // 发布新内容
+	PublishKindModify         // 修改已有内容
+	PublishKindComment        // 评论
+	PublishKindAppend         // 主题附言
+)
+
+// objtypeNone 没有对应 model.TypeXXX 的内容（如专栏）
+const objtypeNone = -1
+
+// 发布策略拒绝的原因代码
+const (
+	RejectNeedLogin   = "need_login"
+	RejectNotActive   = "not_active"
+	RejectNewUserWait = "new_user_wait"
+	RejectSensitive   = "sensitive"
+	RejectCaptcha     = "captcha"
+	RejectBalance     = "balance"
+)
+
+// PolicyRejection 发布策略拒绝发布的原因，实现了 error 接口
+type PolicyRejection struct {
+	Code string                 `json:"code"`
+	Msg  string                 `json:"msg"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+func (this *PolicyRejection) Error() string {
+	return this.Msg
+}
+
+// PublishAction 一次发布（发布、修改、评论）行为。
+// 策略可能改写 Title、Content（同时改写 Form），调用方应使用策略执行后的值
+type PublishAction struct {
+	Me      *model.Me
+	Kind    int
+	Objtype int // model.TypeXXX
+	Title   string
+	Content string
+	Ip      string
+	Form    url.Values
+
+	// Review 允许发布，但需要审核通过后才展示（只有 Holdable 的行为会被设置）
+	Review       bool
+	ReviewSource string // model.ModerationSourceXXX
+	ReviewReason string
+}
+
+// NewPublishAction 从表单构造发布行为，title、content 从表单中获取
+func NewPublishAction(ctx context.Context, me *model.Me, kind, objtype int, form url.Values) *PublishAction {
+	action := &PublishAction{
+		Me:      me,
+		Kind:    kind,
+		Objtype: objtype,
+		Form:    form,
+	}
+	if form != nil {
+		action.Title = form.Get("title")
+		action.Content = form.Get("content")
+	}
+	if ip, ok := ctx.Value("ip").(string); ok {
+		action.Ip = ip
+	}
+	return action
+}
+
+// PublishPolicy 发布策略，返回非 nil 表示拒绝发布
+type PublishPolicy func(ctx context.Context, action *PublishAction) *PolicyRejection
+
+// publishPolicies 按顺序执行的发布策略
+var publishPolicies = []PublishPolicy{
+	loginPolicy,
+	newUserWaitPolicy,
+	sensitivePolicy,
+	newUserReviewPolicy,
+	midNightSpamPolicy,
+	captchaPolicy,
+	balancePolicy,
+}
+
+// RegisterPublishPolicy 在策略链末尾追加发布策略
+func RegisterPublishPolicy(policy PublishPolicy) {
+	publishPolicies = append(publishPolicies, policy)
+}
+
+// CheckPublish 依次执行所有发布策略，有一个拒绝即返回 *PolicyRejection
+func CheckPublish(ctx context.Context, action *PublishAction) error {
+	for _, policy := range publishPolicies {
+		if rejection := policy(ctx, action); rejection != nil {
+			GetLogger(ctx).Infoln("publish rejected, uid:", action.uid(), "code:", rejection.Code, "msg:", rejection.Msg)
+			return rejection
+		}
+	}
+
+	return nil
+}
+
+// Holdable 是否支持先入库、审核通过后再展示：发布、修改主题，以及发布、修改评论。
+// 博文、资源、项目等没有待审核状态，命中需要审核的敏感词时和拒绝发布一样，提示修改后再发布
+func (this *PublishAction) Holdable() bool {
+	switch this.Kind {
+	case PublishKindCreate, PublishKindModify:
+		return this.Objtype == model.TypeTopic || this.Objtype == model.TypeComment
+	case PublishKindComment:
+		return true
+	}
+	return false
+}
+
+// freezable 命中冻结级别的敏感词时能否冻结发布者：修改内容时不冻结，管理员不冻结
+func (this *PublishAction) freezable() bool {
+	if this.Kind == PublishKindModify || this.uid() == 0 {
+		return false
+	}
+	return !this.Me.IsRoot && !this.Me.IsAdmin
+}
+
+// review 标记本次发布需要审核
+func (this *PublishAction) review(source, reason string) {
+	if this.Review {
+		this.ReviewReason += "；" + reason
+		return
+	}
+	this.Review = true
+	this.ReviewSource = source
+	this.ReviewReason = reason
+}
+
+func (this *PublishAction) uid() int {
+	if this.Me == nil {
+		return 0
+	}
+	return this.Me.Uid
+}
+
+// publishUser 获取执行发布操作的用户：优先使用当前登录用户，否则从库中加载 uid 对应的用户
+func publishUser(ctx context.Context, uid int) *model.Me {
+	if me, ok := ctx.Value("user").(*model.Me); ok && me.Uid != 0 {
+		return me
+	}
+
+	user := DefaultUser.FindOne(ctx, "uid", uid)
+	if user.Uid == 0 {
+		return &model.Me{}
+	}
+	return &model.Me{
+		Uid:       user.Uid,
+		Username:  user.Username,
+		Status:    user.Status,
+		IsAdmin:   DefaultUser.IsAdmin(user),
+		IsRoot:    user.IsRoot,
+		CreatedAt: time.Time(user.Ctime),
+		Balance:   user.Balance,
+	}
+}
+
+func loginPolicy(ctx context.Context, action *PublishAction) *PolicyRejection {
+	if action.Me == nil || action.Me.Uid == 0 {
+		return &PolicyRejection{Code: RejectNeedLogin, Msg: "请先登录"}
+	}
+
+	if action.Me.Status != model.UserStatusAudit {
+		return &PolicyRejection{Code: RejectNotActive, Msg: "您的账号未激活或状态异常，不能发布内容"}
+	}
+
+	return nil
+}
+
+func newUserWaitPolicy(ctx context.Context, action *PublishAction) *PolicyRejection {
+	newUserWait := time.Duration(UserSetting[model.KeyNewUserWait]) * time.Second
+	if newUserWait <= 0 {
+		return nil
+	}
+
+	elapse := time.Now().Sub(action.Me.CreatedAt)
+	if elapse <= newUserWait {
+		wait := newUserWait - elapse
+		return &PolicyRejection{
+			Code: RejectNewUserWait,
+			Msg:  "您需要再等待" + wait.String() + "才能进行此操作",
+			Data: map[string]interface{}{"wait": int(wait.Seconds())},
+		}
+	}
+
+	return nil
+}
+
+// newUserReviewPolicy 新用户注册后一段时间内发布的主题和评论需要审核
+func newUserReviewPolicy(ctx context.Context, action *PublishAction) *PolicyRejection {
+	newUserReview := time.Duration(UserSetting[model.KeyNewUserReview]) * time.Second
+	if newUserReview <= 0 || !action.Holdable() || action.Kind == PublishKindModify {
+		return nil
+	}
+
+	if time.Now().Sub(action.Me.CreatedAt) <= newUserReview {
+		action.review(model.ModerationSourceNewUser, "新用户发布")
+	}
+	return nil
+}
+
+var (
+	midNightSpam []string
+	spamNum      int
+)
+
+func init() {
+	midNightSpam = strings.Split(config.ConfigFile.MustValue("spam", "mid_night"), ",")
+	spamNum = config.ConfigFile.MustInt("spam", "num")
+}
+
+// sensitivePolicy 敏感词检测，按命中词中最严重的处理方式处理：
+// 冻结账号并将 IP 加入黑名单、拒绝发布、送审，或者把词替换为 * 后发布
+func sensitivePolicy(ctx context.Context, action *PublishAction) *PolicyRejection {
+	titleResult := DefaultSensitive.Check(ctx, action.Title)
+	contentResult := DefaultSensitive.Check(ctx, action.Content)
+
+	words := append(titleResult.Words(), contentResult.Words()...)
+	level := model.SensitiveActionLevel[titleResult.Action]
+	if model.SensitiveActionLevel[contentResult.Action] > level {
+		level = model.SensitiveActionLevel[contentResult.Action]
+	}
+	// 修改内容的可能是管理员、版主而不是作者，管理员账号也不冻结，都只拒绝发布
+	if level == model.SensitiveActionLevel[model.SensitiveActionFreeze] && !action.freezable() {
+		level = model.SensitiveActionLevel[model.SensitiveActionReject]
+	}
+
+	switch level {
+	case model.SensitiveActionLevel[model.SensitiveActionFreeze]:
+		// 把账号冻结
+		DefaultUser.UpdateUserStatus(ctx, action.Me.Uid, model.UserStatusFreeze)
+		logger.Infoln("user=", action.Me.Uid, "publish sensitive words:", words, ", title=", action.Title, ";content=", action.Content, ". freeze")
+		// IP 加入黑名单
+		if action.Ip != "" {
+			DefaultRisk.AddBlackIP(ctx, action.Ip, "发布敏感词被冻结："+strings.Join(words, "、"))
+		}
+		return &PolicyRejection{Code: RejectSensitive, Msg: "对不起，您的账号已被冻结！"}
+	case model.SensitiveActionLevel[model.SensitiveActionReject]:
+		return &PolicyRejection{
+			Code: RejectSensitive,
+			Msg:  "内容包含不允许发布的词语：" + strings.Join(words, "、"),
+			Data: map[string]interface{}{"words": words},
+		}
+	case model.SensitiveActionLevel[model.SensitiveActionReview]:
+		if !action.Holdable() {
+			return &PolicyRejection{
+				Code: RejectSensitive,
+				Msg:  "内容包含需要审核的词语，请修改后再发布：" + strings.Join(words, "、"),
+				Data: map[string]interface{}{"words": words},
+			}
+		}
+		action.review(model.ModerationSourceSensitive, "命中敏感词："+strings.Join(words, "、"))
+	}
+
+	if titleResult.Masked != action.Title {
+		action.Title = titleResult.Masked
+		if action.Form != nil {
+			action.Form.Set("title", action.Title)
+		}
+	}
+	if contentResult.Masked != action.Content {
+		action.Content = contentResult.Masked
+		if action.Form != nil {
+			action.Form.Set("content", action.Content)
+		}
+	}
+
+	return nil
+}
+
+// midNightSpamPolicy 半夜发布新内容（评论不算）计入 spam
+func midNightSpamPolicy(ctx context.Context, action *PublishAction) *PolicyRejection {
+	if action.Title == "" || spamNum <= 0 || len(midNightSpam) != 2 {
+		return nil
+	}
+
+	curHour := time.Now().Hour()
+	startHour := goutils.MustInt(midNightSpam[0])
+	endHour := goutils.MustInt(midNightSpam[1])
+	// 比如 23 ~ 8（不包括 8 点）
+	if startHour > endHour {
+		if curHour >= startHour || curHour < endHour {
+			SpamRecord(ctx, action.Me, spamNum)
+		}
+	} else {
+		// 比如 0 ~ 8（不包括 8 点）
+		if curHour >= startHour && curHour < endHour {
+			SpamRecord(ctx, action.Me, spamNum)
+		}
+	}
+
+	return nil
+}
+
+// captchaPolicy 新用户或发布太频繁时，发布新内容和评论需要验证码
+func captchaPolicy(ctx context.Context, action *PublishAction) *PolicyRejection {
+	if action.Kind != PublishKindCreate && action.Kind != PublishKindComment {
+		return nil
+	}
+
+	if !NeedCaptcha(action.Me) {
+		return nil
+	}
+
+	captchaId := action.Form.Get("captchaid")
+	if captchaId != "" && captcha.VerifyString(captchaId, action.Form.Get("captchaSolution")) {
+		return nil
+	}
+
+	if captchaId != "" {
+		util.SetCaptcha(captchaId)
+	} else {
+		captchaId = captcha.NewLen(util.CaptchaLen)
+	}
+	return &PolicyRejection{
+		Code: RejectCaptcha,
+		Msg:  "验证码错误，记得刷新验证码！",
+		Data: map[string]interface{}{"captchaid": captchaId},
+	}
+}
+
+// 发布新内容和评论需要的最低余额（铜币）
+const (
+	minBalanceCreate  = 20
+	minBalanceComment = 5
+)
+
+// balancePolicy 发布新内容和评论时，校验余额是否足够
+func balancePolicy(ctx context.Context, action *PublishAction) *PolicyRejection {
+	minBalance := 0
+	switch action.Kind {
+	case PublishKindCreate:
+		minBalance = minBalanceCreate
+	case PublishKindComment:
+		minBalance = minBalanceComment
+	}
+
+	if action.Me.Balance < minBalance {
+		return &PolicyRejection{
+			Code: RejectBalance,
+			Msg:  "对不起，您的账号余额不足，可以领取初始资本！",
+			Data: map[string]interface{}{"need": minBalance, "balance": action.Me.Balance},
+		}
+	}
+	return nil
+}
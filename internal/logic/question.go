@@ -0,0 +1,132 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// 没有配置 answer_award 时，回答被采纳奖励的铜币数
+const defaultAnswerAward = 20
+
+type QuestionLogic struct{}
+
+var DefaultQuestion = QuestionLogic{}
+
+// AcceptAnswer 采纳主题 tid 下的回复 cid 为答案。主题作者或节点版主可以采纳，采纳后不能更改
+func (self QuestionLogic) AcceptAnswer(ctx context.Context, me *model.Me, tid, cid int) error {
+	objLog := GetLogger(ctx)
+
+	topic := &model.Topic{}
+	err := db.GetCollection("topics").FindOne(ctx, bson.M{"_id": tid}).Decode(topic)
+	if err != nil || topic.Flag > model.FlagNormal {
+		return errors.New("主题不存在")
+	}
+	if !topic.Question {
+		return errors.New("该主题不是问答模式")
+	}
+	if topic.AnswerCid != 0 {
+		return errors.New("已经采纳了答案")
+	}
+
+	isModerator := me.Uid != topic.Uid
+	if isModerator && !DefaultNodeModerator.CanModerate(ctx, me, topic.Nid) {
+		return NotModifyAuthorityErr
+	}
+
+	comment, err := DefaultComment.FindById(cid)
+	if err != nil || comment.Objtype != model.TypeTopic || comment.Objid != tid {
+		return errors.New("回复不存在")
+	}
+	if comment.Flag == model.FlagAuditDelete || comment.Flag == model.FlagUserDelete || comment.Flag == model.FlagPending {
+		return errors.New("回复不存在")
+	}
+	// 版主替提问者采纳时，不能采纳自己的回复，否则可以给自己发奖励、领走悬赏
+	if isModerator && comment.Uid == me.Uid {
+		return errors.New("不能采纳自己的回复")
+	}
+	// 采纳自己的回复会把悬赏退回，悬赏期间不允许，否则悬赏可以无成本撤回
+	if comment.Uid == topic.Uid && DefaultBounty.isOpen(ctx, tid) {
+		return errors.New("悬赏期间不能采纳自己的回复")
+	}
+
+	// 条件更新，并发采纳时只有一个成功；更新 mtime 以便增量索引时同步解决状态。
+	// 加上问答模式之前的主题没有 answer_cid 字段，null 可以匹配到
+	result, err := db.GetCollection("topics").UpdateOne(ctx,
+		bson.M{"_id": tid, "answer_cid": bson.M{"$in": []interface{}{0, nil}}},
+		bson.M{"$set": bson.M{"answer_cid": cid, "mtime": time.Now()}})
+	if err != nil {
+		objLog.Errorln("QuestionLogic AcceptAnswer update error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.ModifiedCount == 0 {
+		return errors.New("已经采纳了答案")
+	}
+	topic.AnswerCid = cid
+
+	if isModerator {
+		DefaultNodeModerator.record(ctx, me, topic.Nid, model.ModActionAccept, model.TypeTopic, tid, comment.Uid, "", topic.Title)
+	}
+
+	DefaultBounty.settleTopic(ctx, tid)
+	go self.awardAnswer(topic, comment)
+
+	return nil
+}
+
+// awardAnswer 给被采纳的回答者奖励铜币，采纳自己的回复不奖励
+func (QuestionLogic) awardAnswer(topic *model.Topic, comment *model.Comment) {
+	if comment.Uid == topic.Uid {
+		return
+	}
+
+	award, ok := UserSetting[model.KeyAnswerAward]
+	if !ok {
+		award = defaultAnswerAward
+	}
+	if award <= 0 {
+		return
+	}
+
+	user := DefaultUser.FindOne(context.Background(), "uid", comment.Uid)
+	if user.Uid == 0 {
+		return
+	}
+	desc := fmt.Sprintf(`在问题 › <a href="/topics/%d">%s</a> 中的回答被采纳`, topic.Tid, topic.Title)
+	DefaultUserRich.IncrUserRich(user, model.MissionTypeAnswer, award, desc)
+}
+
+// questionMode 发布、修改主题时的问答模式：节点是问答节点时强制开启，已采纳答案或有悬赏的不能关闭。
+// form 中没有 question 时保持 current
+func (QuestionLogic) questionMode(ctx context.Context, form url.Values, nid int, current *model.Topic) bool {
+	question := current != nil && current.Question
+	if _, ok := form["question"]; ok {
+		question, _ = strconv.ParseBool(form.Get("question"))
+	}
+	if current != nil && (current.AnswerCid != 0 || DefaultBounty.isOpen(ctx, current.Tid)) {
+		return true
+	}
+	if !question && nid != 0 {
+		question = DefaultNode.FindOne(nid).Question
+	}
+	return question
+}
+
+// UnansweredFilter 未解决的问题，用于 FindAll、Count 的 querystring 和 args。
+// 没有 answer_cid 字段的老主题也算未解决
+func (QuestionLogic) UnansweredFilter() (string, []interface{}) {
+	return "question=? AND answer_cid IN(?,?)", []interface{}{true, 0, nil}
+}
@@ -31,7 +31,7 @@ func (self RankLogic) GenDayRank(objtype, objid, num int) {
 		ctx := context.Background()
 		topic := &model.Topic{}
 		err := db.GetCollection("topics").FindOne(ctx, bson.M{"_id": objid}).Decode(topic)
-		if err != nil {
+		if err != nil || topic.Restricted() {
 			return
 		}
 
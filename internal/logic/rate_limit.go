@@ -0,0 +1,206 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/studygolang/studygolang/util"
+
+	"github.com/polaris1119/config"
+	"github.com/polaris1119/logger"
+	"github.com/polaris1119/nosql"
+)
+
+// 限流的操作，对应配置文件 [ratelimit] 中的 key
+const (
+	RateLimitLogin    = "login"
+	RateLimitRegister = "register"
+	RateLimitComment  = "comment"
+	RateLimitLike     = "like"
+	RateLimitMessage  = "message"
+	RateLimitUpload   = "upload"
+	RateLimitSearch   = "search"
+	RateLimitRevision = "revision"
+)
+
+// 默认规则，格式为 次数/时长。按用户的规则为 action，按 IP 的为 action.ip；
+// 登录、注册时还没有用户，只按 IP 限制
+var defaultRateLimitRules = map[string]string{
+	RateLimitLogin + ".ip":    "10/1m",
+	RateLimitRegister + ".ip": "5/1h",
+	RateLimitComment:          "10/1m",
+	RateLimitComment + ".ip":  "30/1m",
+	RateLimitLike:             "30/1m",
+	RateLimitLike + ".ip":     "60/1m",
+	RateLimitMessage:          "10/1m",
+	RateLimitMessage + ".ip":  "30/1m",
+	RateLimitUpload:           "20/10m",
+	RateLimitUpload + ".ip":   "40/10m",
+	RateLimitSearch:           "30/1m",
+	RateLimitSearch + ".ip":   "60/1m",
+	RateLimitRevision:         "30/1m",
+	RateLimitRevision + ".ip": "60/1m",
+}
+
+var (
+	rateLimitRulesOnce sync.Once
+	rateLimitRules     map[string]util.TokenBucket
+
+	// 桶的状态读改写不是原子的，同一实例内按 key 加锁；多实例之间的并发会有少量误差，限流可以接受
+	rateLimitLocks [64]sync.Mutex
+)
+
+// RateLimitResult 限流结果，用于设置响应头
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // 被限制时，多久之后可以重试
+	Reset      time.Time     // 桶补满的时间
+}
+
+type RateLimitLogic struct{}
+
+var DefaultRateLimit = RateLimitLogic{}
+
+// Allow 检查 uid（未登录为 0）和 ip 执行 action 是否超过频率限制，没超过时消耗一个令牌。
+// 多个桶中任意一个没有令牌即被限制，此时不消耗其他桶的令牌；没有配置规则时返回 nil
+func (self RateLimitLogic) Allow(ctx context.Context, action string, uid int, ip string) *RateLimitResult {
+	type bucketState struct {
+		key    string
+		bucket util.TokenBucket
+		tokens float64
+	}
+
+	states := make([]*bucketState, 0, 2)
+	if bucket, ok := self.rule(action); ok && uid > 0 {
+		states = append(states, &bucketState{key: self.key(action, "uid", strconv.Itoa(uid)), bucket: bucket})
+	}
+	if bucket, ok := self.rule(action + ".ip"); ok && ip != "" {
+		states = append(states, &bucketState{key: self.key(action, "ip", ip), bucket: bucket})
+	}
+	if len(states) == 0 {
+		return nil
+	}
+
+	// 按序加锁，避免两个 key 落在同一把锁上或加锁顺序不同导致死锁
+	lockIdxes := make([]int, 0, len(states))
+	for _, state := range states {
+		idx := self.lockIndex(state.key)
+		if len(lockIdxes) == 0 || lockIdxes[0] != idx {
+			lockIdxes = append(lockIdxes, idx)
+		}
+	}
+	sort.Ints(lockIdxes)
+	for _, idx := range lockIdxes {
+		rateLimitLocks[idx].Lock()
+		defer rateLimitLocks[idx].Unlock()
+	}
+
+	redisClient := nosql.NewRedisFromPool()
+	defer redisClient.Close()
+
+	now := time.Now()
+	result := &RateLimitResult{Allowed: true, Remaining: math.MaxInt32}
+	for _, state := range states {
+		tokens, last := self.load(redisClient, state.key)
+		left, ok := state.bucket.Take(tokens, last, now)
+		state.tokens = left
+
+		if !ok {
+			wait := state.bucket.Wait(left)
+			if result.Allowed || wait > result.RetryAfter {
+				result.Allowed = false
+				result.RetryAfter = wait
+				result.Limit = state.bucket.Burst
+				result.Remaining = 0
+				result.Reset = now.Add(state.bucket.FullAfter(left))
+			}
+			continue
+		}
+
+		if result.Allowed && int(left) < result.Remaining {
+			result.Limit = state.bucket.Burst
+			result.Remaining = int(left)
+			result.Reset = now.Add(state.bucket.FullAfter(left))
+		}
+	}
+
+	if !result.Allowed {
+		logger.Infoln("rate limited, action:", action, "uid:", uid, "ip:", ip, "retry after:", result.RetryAfter)
+		return result
+	}
+
+	for _, state := range states {
+		// 超过一个周期桶必然是满的，状态不用再保存
+		expire := int(math.Ceil(state.bucket.Period.Seconds()))
+		val := strconv.FormatFloat(state.tokens, 'f', 4, 64) + "|" + strconv.FormatInt(now.UnixNano(), 10)
+		if err := redisClient.SET(state.key, val, expire); err != nil {
+			GetLogger(ctx).Errorln("RateLimitLogic Allow save bucket error:", err)
+		}
+	}
+
+	return result
+}
+
+// load 读取桶的状态，没有时返回零值（新桶）
+func (RateLimitLogic) load(redisClient *nosql.RedisClient, key string) (float64, time.Time) {
+	parts := strings.SplitN(redisClient.GET(key), "|", 2)
+	if len(parts) != 2 {
+		return 0, time.Time{}
+	}
+
+	tokens, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, time.Time{}
+	}
+	nano, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}
+	}
+	return tokens, time.Unix(0, nano)
+}
+
+// rule 获取规则，配置为空或 off 表示不限制
+func (RateLimitLogic) rule(name string) (util.TokenBucket, bool) {
+	rateLimitRulesOnce.Do(func() {
+		rateLimitRules = make(map[string]util.TokenBucket, len(defaultRateLimitRules))
+		for key, defVal := range defaultRateLimitRules {
+			val := strings.TrimSpace(config.ConfigFile.MustValue("ratelimit", key, defVal))
+			if val == "" || val == "off" {
+				continue
+			}
+
+			bucket, err := util.ParseTokenBucket(val)
+			if err != nil {
+				logger.Errorln("ratelimit config", key, "error:", err, "use default:", defVal)
+				bucket, _ = util.ParseTokenBucket(defVal)
+			}
+			rateLimitRules[key] = bucket
+		}
+	})
+
+	bucket, ok := rateLimitRules[name]
+	return bucket, ok
+}
+
+func (RateLimitLogic) lockIndex(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(rateLimitLocks)))
+}
+
+func (RateLimitLogic) key(action, typ, val string) string {
+	return "ratelimit:" + action + ":" + typ + ":" + val
+}
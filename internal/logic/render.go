@@ -0,0 +1,189 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/studygolang/studygolang/internal/dao/cache"
+	"github.com/studygolang/studygolang/internal/model"
+	"github.com/studygolang/studygolang/util"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	// 渲染规则（markdown 扩展、过滤白名单）变化时修改，让旧的缓存失效
+	renderVersion = "1"
+	// 进入目录的最深标题级别
+	tocMaxLevel = 4
+)
+
+// CommonMark + GFM（表格、任务列表、删除线、自动链接），允许内嵌 HTML，由过滤器统一处理
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// markdownHardWraps 单个换行也换行，面试题一直是这样显示的
+var markdownHardWraps = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	goldmark.WithRendererOptions(html.WithUnsafe(), html.WithHardWraps()),
+)
+
+// @别人
+var atUserReg = regexp.MustCompile(`@([^\s@]{4,20})`)
+
+type RenderLogic struct{}
+
+var DefaultRender = RenderLogic{}
+
+// Markdown 渲染 markdown，并按白名单过滤 HTML，同时提取目录
+func (self RenderLogic) Markdown(ctx context.Context, content string) *model.Rendered {
+	return self.render(ctx, "md", content)
+}
+
+// MarkdownHardWraps 和 Markdown 一样，但单个换行也渲染为换行
+func (self RenderLogic) MarkdownHardWraps(ctx context.Context, content string) *model.Rendered {
+	return self.render(ctx, "mdbr", content)
+}
+
+// HTML 过滤本身就是 HTML 的内容，如抓取的文章
+func (self RenderLogic) HTML(ctx context.Context, content string) *model.Rendered {
+	return self.render(ctx, "html", content)
+}
+
+// Article 文章按是否 markdown 渲染
+func (self RenderLogic) Article(ctx context.Context, article *model.Article) *model.Rendered {
+	if article.Markdown {
+		return self.Markdown(ctx, article.Content)
+	}
+	return self.HTML(ctx, article.Content)
+}
+
+// render 先查缓存（按内容哈希），没有再渲染
+func (RenderLogic) render(ctx context.Context, kind, content string) *model.Rendered {
+	if strings.TrimSpace(content) == "" {
+		return &model.Rendered{Toc: []*model.TocItem{}}
+	}
+
+	sum := sha1.Sum([]byte(renderVersion + kind + "\x00" + content))
+	hash := hex.EncodeToString(sum[:])
+	if rendered := cache.Render.Get(ctx, hash); rendered != nil {
+		return rendered
+	}
+
+	var rendered *model.Rendered
+	switch kind {
+	case "md":
+		rendered = renderMarkdown(ctx, markdown, content)
+	case "mdbr":
+		rendered = renderMarkdown(ctx, markdownHardWraps, content)
+	default:
+		rendered = &model.Rendered{HTML: util.SanitizeHTML(content), Toc: []*model.TocItem{}}
+	}
+	cache.Render.Set(ctx, hash, rendered)
+
+	return rendered
+}
+
+func renderMarkdown(ctx context.Context, md goldmark.Markdown, content string) *model.Rendered {
+	source := []byte(content)
+	pctx := parser.NewContext(parser.WithIDs(&headingIDs{values: make(map[string]bool)}))
+	doc := md.Parser().Parse(text.NewReader(source), parser.WithContext(pctx))
+
+	toc := make([]*model.TocItem, 0)
+	ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := node.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		if heading.Level <= tocMaxLevel {
+			id, _ := heading.AttributeString("id")
+			idBytes, _ := id.([]byte)
+			toc = append(toc, &model.TocItem{
+				Level: heading.Level,
+				Id:    string(idBytes),
+				Title: string(heading.Text(source)),
+			})
+		}
+		return ast.WalkSkipChildren, nil
+	})
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, source, doc); err != nil {
+		GetLogger(ctx).Errorln("RenderLogic markdown render error:", err)
+		// 渲染失败时按纯文本显示
+		return &model.Rendered{HTML: "<p>" + template.HTMLEscapeString(content) + "</p>", Toc: toc}
+	}
+
+	return &model.Rendered{HTML: util.SanitizeHTML(buf.String()), Toc: toc}
+}
+
+// linkAtUser 把渲染后 HTML 文本中 @ 的用户替换为链接，代码和已有的链接中的不处理
+func linkAtUser(ctx context.Context, content string) string {
+	return util.LinkifyText(content, atUserReg, func(match []string) string {
+		username := match[1]
+
+		// 校验 username 是否存在
+		user := DefaultUser.FindOne(ctx, "username", username)
+		if user.Username != username {
+			return ""
+		}
+		escaped := template.HTMLEscapeString(username)
+		return `<a href="/user/` + escaped + `" title="@` + escaped + `">@` + escaped + `</a>`
+	})
+}
+
+// headingIDs 生成标题的锚点。goldmark 默认只保留 ASCII 字符，中文标题都会变成 heading-N，
+// 这里保留所有字母和数字，其他字符替换为 -，重复的加序号
+type headingIDs struct {
+	values map[string]bool
+}
+
+func (s *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(string(value))) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			b.WriteRune(r)
+			dash = false
+		} else if b.Len() > 0 && !dash {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	id := strings.TrimSuffix(b.String(), "-")
+	if id == "" {
+		id = "heading"
+	}
+	result := id
+	for i := 1; s.values[result]; i++ {
+		result = id + "-" + strconv.Itoa(i)
+	}
+	s.values[result] = true
+	return []byte(result)
+}
+
+func (s *headingIDs) Put(value []byte) {
+	s.values[string(value)] = true
+}
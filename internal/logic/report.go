@@ -0,0 +1,358 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+	"github.com/studygolang/studygolang/util"
+
+	"github.com/polaris1119/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 误报较多的举报人，其举报计入阈值的权重降低
+const (
+	// 不属实次数达到该值才开始降权
+	reporterMinInvalid = 3
+	// 备注最长字数
+	maxReportRemarkLen = 200
+)
+
+type ReportLogic struct{}
+
+var DefaultReport = ReportLogic{}
+
+// reportTarget 被举报的内容
+type reportTarget struct {
+	uid     int
+	title   string
+	content string
+}
+
+// Report me 举报内容。同一内容只能举报一次；汇总后达到阈值时送审或自动隐藏
+func (self ReportLogic) Report(ctx context.Context, me *model.Me, objtype, objid int, reason, remark string) error {
+	objLog := GetLogger(ctx)
+
+	if _, ok := model.ReportReasonMap[reason]; !ok {
+		return errors.New("请选择举报原因")
+	}
+	remark = strings.TrimSpace(remark)
+	if reason == model.ReportReasonOther && remark == "" {
+		return errors.New("请说明举报原因")
+	}
+	if len([]rune(remark)) > maxReportRemarkLen {
+		return errors.New("说明不能超过 " + strconv.Itoa(maxReportRemarkLen) + " 个字")
+	}
+
+	target, err := self.findTarget(ctx, me, objtype, objid)
+	if err != nil {
+		return err
+	}
+	if target.uid == me.Uid {
+		return errors.New("不能举报自己的内容")
+	}
+
+	id, err := db.NextID("report")
+	if err != nil {
+		objLog.Errorln("ReportLogic Report NextID error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	report := &model.Report{
+		Id:        id,
+		Objtype:   objtype,
+		Objid:     objid,
+		Uid:       me.Uid,
+		TargetUid: target.uid,
+		Reason:    reason,
+		Remark:    remark,
+		Weight:    self.reporterWeight(ctx, me.Uid),
+		State:     model.ReportStatePending,
+		CreatedAt: time.Now(),
+	}
+	// 按 (objtype, objid, uid) upsert，不依赖唯一索引也能保证同一用户只举报一次
+	result, err := db.GetCollection("report").UpdateOne(ctx,
+		bson.M{"objtype": objtype, "objid": objid, "uid": me.Uid},
+		bson.M{"$setOnInsert": report},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("你已经举报过该内容，请等待处理")
+		}
+		objLog.Errorln("ReportLogic Report insert error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.UpsertedCount == 0 {
+		return errors.New("你已经举报过该内容，请等待处理")
+	}
+
+	_, err = db.GetCollection("reporter").UpdateOne(ctx, bson.M{"_id": me.Uid}, bson.M{
+		"$inc": bson.M{"total": 1},
+		"$set": bson.M{"updated_at": report.CreatedAt},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		objLog.Errorln("ReportLogic Report update reporter error:", err)
+	}
+
+	stat, err := self.aggregate(ctx, report)
+	if err != nil {
+		objLog.Errorln("ReportLogic Report aggregate error:", err)
+		return nil
+	}
+	self.checkThreshold(ctx, stat, target)
+
+	return nil
+}
+
+// FindByObject 某个内容的所有未处理举报，给审核人员参考
+func (ReportLogic) FindByObject(ctx context.Context, objtype, objid int) []*model.Report {
+	objLog := GetLogger(ctx)
+
+	reports := make([]*model.Report, 0)
+	filter := bson.M{"objtype": objtype, "objid": objid, "state": model.ReportStatePending}
+	cursor, err := db.GetCollection("report").Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		objLog.Errorln("ReportLogic FindByObject error:", err)
+		return reports
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &reports); err != nil {
+		objLog.Errorln("ReportLogic FindByObject decode error:", err)
+	}
+	return reports
+}
+
+// FindReporter 举报人的信誉
+func (ReportLogic) FindReporter(ctx context.Context, uid int) *model.Reporter {
+	reporter := &model.Reporter{Uid: uid}
+	err := db.GetCollection("reporter").FindOne(ctx, bson.M{"_id": uid}).Decode(reporter)
+	if err != nil && err != mongo.ErrNoDocuments {
+		GetLogger(ctx).Errorln("ReportLogic FindReporter error:", err)
+	}
+	return reporter
+}
+
+// reporterWeight 举报人的权重：不属实的次数达到 reporterMinInvalid 且多于属实的，权重减半；
+// 不属实的次数是属实的两倍以上，举报不再计入阈值（但仍然会记录，供审核人员参考）
+func (self ReportLogic) reporterWeight(ctx context.Context, uid int) float64 {
+	reporter := self.FindReporter(ctx, uid)
+	if reporter.Invalid < reporterMinInvalid || reporter.Invalid <= reporter.Valid {
+		return 1
+	}
+	if reporter.Invalid >= 2*reporter.Valid {
+		return 0
+	}
+	return 0.5
+}
+
+// aggregate 汇总同一内容的举报
+func (ReportLogic) aggregate(ctx context.Context, report *model.Report) (*model.ReportStat, error) {
+	id, err := db.NextID("report_stat")
+	if err != nil {
+		return nil, err
+	}
+
+	stat := &model.ReportStat{}
+	err = db.GetCollection("report_stat").FindOneAndUpdate(ctx,
+		bson.M{"objtype": report.Objtype, "objid": report.Objid},
+		bson.M{
+			"$inc": bson.M{"num": 1, "score": report.Weight, "reasons." + report.Reason: 1},
+			"$set": bson.M{"updated_at": report.CreatedAt},
+			"$setOnInsert": bson.M{
+				"_id":        id,
+				"target_uid": report.TargetUid,
+				"queued":     false,
+				"hidden":     false,
+				"created_at": report.CreatedAt,
+			},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(stat)
+	return stat, err
+}
+
+// checkThreshold 举报数达到阈值：自动隐藏（并送审），或者只送审
+func (self ReportLogic) checkThreshold(ctx context.Context, stat *model.ReportStat, target *reportTarget) {
+	hideNum := float64(UserSetting[model.KeyReportHideNum])
+	reviewNum := float64(UserSetting[model.KeyReportReviewNum])
+
+	hide := hideNum > 0 && stat.Score >= hideNum && !stat.Hidden && self.hideable(stat.Objtype)
+	if !hide && (reviewNum <= 0 || stat.Score < reviewNum || stat.Queued) {
+		return
+	}
+
+	// 抢占，避免并发举报时重复处理
+	change := bson.M{"queued": true}
+	filter := bson.M{"_id": stat.Id, "queued": false}
+	if hide {
+		change["hidden"] = true
+		filter = bson.M{"_id": stat.Id, "hidden": false}
+	}
+	result, err := db.GetCollection("report_stat").UpdateOne(ctx, filter, bson.M{"$set": change})
+	if err != nil || result.ModifiedCount == 0 {
+		return
+	}
+
+	if hide {
+		if err = self.hide(ctx, stat.Objtype, stat.Objid); err != nil {
+			GetLogger(ctx).Errorln("ReportLogic hide error:", err)
+			hide = false
+		}
+	}
+
+	item := &model.ModerationItem{
+		Objtype: stat.Objtype,
+		Objid:   stat.Objid,
+		Uid:     target.uid,
+		Reason:  self.reasonSummary(stat),
+		Held:    hide,
+		Title:   target.title,
+		Content: target.content,
+	}
+	DefaultModeration.Enqueue(ctx, item, model.ModerationSourceReport)
+}
+
+// reasonSummary 如：被 5 人举报：广告、垃圾信息 x3，辱骂、人身攻击 x2
+func (ReportLogic) reasonSummary(stat *model.ReportStat) string {
+	reasons := make([]string, 0, len(stat.Reasons))
+	for reason := range stat.Reasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		return stat.Reasons[reasons[i]] > stat.Reasons[reasons[j]]
+	})
+
+	for i, reason := range reasons {
+		reasons[i] = fmt.Sprintf("%s x%d", model.ReportReasonMap[reason], stat.Reasons[reason])
+	}
+	return fmt.Sprintf("被 %d 人举报：%s", stat.Num, strings.Join(reasons, "，"))
+}
+
+// hideable 能自动隐藏的内容类型
+func (ReportLogic) hideable(objtype int) bool {
+	return objtype == model.TypeTopic || objtype == model.TypeComment || objtype == model.TypeArticle
+}
+
+// hide 自动隐藏内容，审核通过后恢复
+func (ReportLogic) hide(ctx context.Context, objtype, objid int) error {
+	var err error
+	switch objtype {
+	case model.TypeTopic:
+		DefaultModeration.holdTopic(ctx, objid)
+	case model.TypeComment:
+		_, err = db.GetCollection("comments").UpdateOne(ctx, bson.M{"_id": objid}, bson.M{"$set": bson.M{"flag": model.FlagPending}})
+	case model.TypeArticle:
+		_, err = db.GetCollection("articles").UpdateOne(ctx, bson.M{"_id": objid}, bson.M{"$set": bson.M{"status": model.ArticleStatusOffline}})
+		if err == nil {
+			err = DefaultFeed.setOffline(ctx, objid, objtype)
+		}
+	}
+	return err
+}
+
+// resolve 审核完成后处理该内容的举报：valid 表示举报属实（内容被删除）。
+// 更新举报人的信誉，并通知举报人处理结果
+func (ReportLogic) resolve(ctx context.Context, item *model.ModerationItem, valid bool) {
+	objLog := GetLogger(ctx)
+
+	state := model.ReportStateInvalid
+	if valid {
+		state = model.ReportStateValid
+	}
+
+	reports := DefaultReport.FindByObject(ctx, item.Objtype, item.Objid)
+	if len(reports) == 0 {
+		return
+	}
+
+	now := time.Now()
+	_, err := db.GetCollection("report").UpdateMany(ctx,
+		bson.M{"objtype": item.Objtype, "objid": item.Objid, "state": model.ReportStatePending},
+		bson.M{"$set": bson.M{"state": state, "resolved_at": now}})
+	if err != nil {
+		objLog.Errorln("ReportLogic resolve update reports error:", err)
+		return
+	}
+
+	// 之后的举报重新汇总
+	if _, err = db.GetCollection("report_stat").DeleteOne(ctx, bson.M{"objtype": item.Objtype, "objid": item.Objid}); err != nil {
+		objLog.Errorln("ReportLogic resolve delete stat error:", err)
+	}
+
+	field := "invalid"
+	if valid {
+		field = "valid"
+	}
+
+	title := item.Title
+	if title == "" {
+		title = util.Substring(item.Content, 30, "...")
+	}
+	url := moderationObjUrl(item)
+	for _, report := range reports {
+		_, err = db.GetCollection("reporter").UpdateOne(ctx, bson.M{"_id": report.Uid}, bson.M{
+			"$inc": bson.M{field: 1},
+			"$set": bson.M{"updated_at": now},
+		}, options.Update().SetUpsert(true))
+		if err != nil {
+			logger.Errorln("ReportLogic resolve update reporter error:", err)
+		}
+
+		DefaultMessage.SendSystemMsgTo(ctx, report.Uid, model.MsgtypeReportResult, map[string]interface{}{
+			"objid":   item.Objid,
+			"objtype": item.Objtype,
+			"title":   title,
+			"url":     url,
+			"state":   state,
+		})
+	}
+}
+
+// findTarget 查找被举报的内容，私信只有收信人可以举报
+func (ReportLogic) findTarget(ctx context.Context, me *model.Me, objtype, objid int) (*reportTarget, error) {
+	switch objtype {
+	case model.TypeTopic:
+		topic := DefaultTopic.findByTid(objid)
+		// 无权查看的主题当作不存在，避免通过举报探测
+		if topic.Tid == 0 || topic.Flag > model.FlagNormal || !CanViewTopic(ctx, topic) {
+			return nil, NotFoundErr
+		}
+		return &reportTarget{uid: topic.Uid, title: topic.Title, content: topic.Content}, nil
+	case model.TypeComment:
+		comment, err := DefaultComment.FindById(objid)
+		if err != nil || comment.Flag > model.FlagNormal || !CanViewObject(ctx, comment.Objtype, comment.Objid) {
+			return nil, NotFoundErr
+		}
+		return &reportTarget{uid: comment.Uid, content: comment.Content}, nil
+	case model.TypeArticle:
+		article, err := DefaultArticle.FindById(ctx, objid)
+		if err != nil || article.Id == 0 || article.Status >= model.ArticleStatusOffline {
+			return nil, NotFoundErr
+		}
+		return &reportTarget{uid: DefaultArticle.getOwner(objid), title: article.Title, content: article.Txt}, nil
+	case model.TypeMessage:
+		message := &model.Message{}
+		err := db.GetCollection("message").FindOne(ctx, bson.M{"_id": objid}).Decode(message)
+		if err != nil || message.To != me.Uid {
+			return nil, NotFoundErr
+		}
+		return &reportTarget{uid: message.From, content: message.Content}, nil
+	}
+
+	return nil, errors.New("不支持举报该类型的内容")
+}
@@ -32,6 +32,14 @@ var DefaultResource = ResourceLogic{}
 func (ResourceLogic) Publish(ctx context.Context, me *model.Me, form url.Values) (err error) {
 	objLog := GetLogger(ctx)
 
+	kind := PublishKindCreate
+	if form.Get("id") != "" {
+		kind = PublishKindModify
+	}
+	if err = CheckPublish(ctx, NewPublishAction(ctx, me, kind, model.TypeResource, form)); err != nil {
+		return
+	}
+
 	uid := me.Uid
 	resource := &model.Resource{}
 
@@ -43,7 +51,7 @@ func (ResourceLogic) Publish(ctx context.Context, me *model.Me, form url.Values)
 			return
 		}
 
-		if !CanEdit(me, resource) {
+		if !CanEdit(ctx, me, resource) {
 			err = NotModifyAuthorityErr
 			return
 		}
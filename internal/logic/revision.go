@@ -0,0 +1,369 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+	"github.com/studygolang/studygolang/util"
+
+	"github.com/jaytaylor/html2text"
+	"github.com/polaris1119/set"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 编辑说明最长字数
+const maxRevisionSummaryLen = 100
+
+// revisionSnapshot 对象当前（修改前）的标题和内容
+type revisionSnapshot struct {
+	uid     int
+	title   string
+	content string
+	at      time.Time
+}
+
+// RevisionDiff 两个版本的差异
+type RevisionDiff struct {
+	From    *model.Revision `json:"from"`
+	To      *model.Revision `json:"to"`
+	Title   []util.DiffOp   `json:"title"`
+	Content []util.DiffOp   `json:"content"`
+}
+
+type RevisionLogic struct{}
+
+var DefaultRevision = RevisionLogic{}
+
+// FindAll 对象的版本列表（不含内容），最新的在前
+func (self RevisionLogic) FindAll(ctx context.Context, me *model.Me, objtype, objid int, paginator *Paginator) ([]*model.Revision, error) {
+	objLog := GetLogger(ctx)
+
+	if _, err := self.findObject(ctx, me, objtype, objid); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"objtype": objtype, "objid": objid}
+	coll := db.GetCollection("revision")
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		objLog.Errorln("RevisionLogic FindAll count error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	paginator.SetTotal(total)
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetProjection(bson.M{"content": 0}).
+		SetSkip(int64(paginator.Offset())).SetLimit(int64(paginator.PerPage()))
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		objLog.Errorln("RevisionLogic FindAll error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	defer cursor.Close(ctx)
+
+	revisions := make([]*model.Revision, 0)
+	if err = cursor.All(ctx, &revisions); err != nil {
+		objLog.Errorln("RevisionLogic FindAll decode error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	self.fillUser(ctx, revisions...)
+
+	return revisions, nil
+}
+
+// FindOne 某个版本的完整内容
+func (self RevisionLogic) FindOne(ctx context.Context, me *model.Me, objtype, objid, version int) (*model.Revision, error) {
+	if _, err := self.findObject(ctx, me, objtype, objid); err != nil {
+		return nil, err
+	}
+
+	revision, err := self.findVersion(ctx, objtype, objid, version)
+	if err != nil {
+		return nil, err
+	}
+	self.fillUser(ctx, revision)
+	return revision, nil
+}
+
+// Diff 比较任意两个版本，mode 为 word 时按词比较，否则按行比较
+func (self RevisionLogic) Diff(ctx context.Context, me *model.Me, objtype, objid, from, to int, mode string) (*RevisionDiff, error) {
+	if _, err := self.findObject(ctx, me, objtype, objid); err != nil {
+		return nil, err
+	}
+
+	fromRevision, err := self.findVersion(ctx, objtype, objid, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := self.findVersion(ctx, objtype, objid, to)
+	if err != nil {
+		return nil, err
+	}
+	self.fillUser(ctx, fromRevision, toRevision)
+
+	diff := &RevisionDiff{
+		From:  fromRevision,
+		To:    toRevision,
+		Title: util.DiffWords(fromRevision.Title, toRevision.Title),
+	}
+	if mode == "word" {
+		diff.Content = util.DiffWords(fromRevision.Content, toRevision.Content)
+	} else {
+		diff.Content = util.DiffLines(fromRevision.Content, toRevision.Content)
+	}
+	return diff, nil
+}
+
+// Rollback 回滚到某个版本：用该版本的标题和内容覆盖当前内容，并生成一个新版本。
+// 和修改一样要经过发布策略检查，form 中可以带编辑说明 summary 和验证码
+func (self RevisionLogic) Rollback(ctx context.Context, me *model.Me, objtype, objid, version int, form url.Values) error {
+	objLog := GetLogger(ctx)
+
+	summary, err := self.checkSummary(form.Get("summary"))
+	if err != nil {
+		return err
+	}
+
+	object, err := self.findObject(ctx, me, objtype, objid)
+	if err != nil {
+		return err
+	}
+	if !CanEdit(ctx, me, object) {
+		return NotModifyAuthorityErr
+	}
+
+	revision, err := self.findVersion(ctx, objtype, objid, version)
+	if err != nil {
+		return err
+	}
+
+	before := self.snapshot(object)
+	if before.title == revision.Title && before.content == revision.Content {
+		return errors.New("该版本和当前内容相同，不需要回滚")
+	}
+
+	// 回滚的内容可能包含之后被列为敏感词的内容，需要和修改一样检查
+	form.Set("title", revision.Title)
+	form.Set("content", revision.Content)
+	action := NewPublishAction(ctx, me, PublishKindModify, objtype, form)
+	if err = CheckPublish(ctx, action); err != nil {
+		return err
+	}
+	title, content := action.Title, action.Content
+
+	change := bson.M{"title": title, "content": content}
+	coll := ""
+	switch objtype {
+	case model.TypeTopic:
+		coll = "topics"
+		change["editor_uid"] = me.Uid
+		change["mtime"] = time.Now()
+	case model.TypeArticle:
+		coll = "articles"
+		change["op_user"] = me.Username
+		// 列表摘要和搜索用的纯文本，和发布时一样从 HTML 内容转换
+		change["txt"], _ = html2text.FromString(content)
+	case model.TypeWiki:
+		coll = "wiki"
+		change["mtime"] = time.Now()
+	}
+
+	_, err = db.GetCollection(coll).UpdateOne(ctx, bson.M{"_id": objid}, bson.M{"$set": change})
+	if err != nil {
+		objLog.Errorln("RevisionLogic Rollback update error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	if summary == "" {
+		summary = "回滚到版本 " + strconv.Itoa(version)
+	}
+	self.record(ctx, objtype, objid, before, me.Uid, title, content, summary, version)
+
+	if action.Review {
+		DefaultModeration.holdTopic(ctx, objid)
+		DefaultModeration.hold(ctx, action, model.TypeTopic, objid, object.(*model.Topic).Uid, before)
+	}
+
+	go modifyObservable.NotifyObservers(me.Uid, objtype, objid)
+
+	return nil
+}
+
+// record 修改后保存新版本。对象还没有版本时，先把修改前的内容保存为版本 1。
+// 标题和内容都没有变化时不保存
+func (self RevisionLogic) record(ctx context.Context, objtype, objid int, before *revisionSnapshot, uid int, title, content, summary string, rollback int) {
+	objLog := GetLogger(ctx)
+
+	if before.title == title && before.content == content {
+		return
+	}
+
+	coll := db.GetCollection("revision")
+	latest := &model.Revision{}
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.M{"version": 1})
+	err := coll.FindOne(ctx, bson.M{"objtype": objtype, "objid": objid}, opts).Decode(latest)
+	if err != nil && err != mongo.ErrNoDocuments {
+		objLog.Errorln("RevisionLogic record find latest error:", err)
+		return
+	}
+
+	revisions := make([]*model.Revision, 0, 2)
+	if latest.Version == 0 {
+		revisions = append(revisions, &model.Revision{
+			Uid:       before.uid,
+			Title:     before.title,
+			Content:   before.content,
+			Summary:   "原始版本",
+			CreatedAt: before.at,
+		})
+	}
+	revisions = append(revisions, &model.Revision{
+		Uid:       uid,
+		Title:     title,
+		Content:   content,
+		Summary:   summary,
+		Rollback:  rollback,
+		CreatedAt: time.Now(),
+	})
+
+	version := latest.Version
+	for _, revision := range revisions {
+		id, err := db.NextID("revision")
+		if err != nil {
+			objLog.Errorln("RevisionLogic record NextID error:", err)
+			return
+		}
+
+		revision.Id = id
+		revision.Objtype = objtype
+		revision.Objid = objid
+
+		// 同时修改时版本号可能冲突，顺延重试
+		for i := 0; i < 3; i++ {
+			version++
+			revision.Version = version
+			_, err = coll.InsertOne(ctx, revision)
+			if err == nil || !mongo.IsDuplicateKeyError(err) {
+				break
+			}
+		}
+		if err != nil {
+			objLog.Errorln("RevisionLogic record insert error:", err)
+			return
+		}
+	}
+}
+
+// checkSummary 检查编辑说明
+func (RevisionLogic) checkSummary(summary string) (string, error) {
+	summary = strings.TrimSpace(summary)
+	if len([]rune(summary)) > maxRevisionSummaryLen {
+		return "", errors.New("编辑说明不能超过 " + strconv.Itoa(maxRevisionSummaryLen) + " 个字")
+	}
+	return summary, nil
+}
+
+// findObject 查找对象并检查 me 是否能查看
+func (RevisionLogic) findObject(ctx context.Context, me *model.Me, objtype, objid int) (interface{}, error) {
+	switch objtype {
+	case model.TypeTopic:
+		topic := DefaultTopic.findByTid(objid)
+		if topic.Tid == 0 || topic.Flag > model.FlagNormal || !CanViewTopic(ctx, topic) {
+			return nil, NotFoundErr
+		}
+		return topic, nil
+	case model.TypeArticle:
+		article, err := DefaultArticle.FindById(ctx, objid)
+		if err != nil || article.Id == 0 {
+			return nil, NotFoundErr
+		}
+		if article.Status == model.ArticleStatusOffline && !me.IsAdmin {
+			return nil, NotFoundErr
+		}
+		if article.Status == model.ArticleStatusScheduled && !CanEdit(ctx, me, article) {
+			return nil, NotFoundErr
+		}
+		return article, nil
+	case model.TypeWiki:
+		wiki := DefaultWiki.FindById(ctx, objid)
+		if wiki == nil || wiki.Id == 0 {
+			return nil, NotFoundErr
+		}
+		return wiki, nil
+	}
+
+	return nil, errors.New("该类型的内容没有历史版本")
+}
+
+// snapshot 对象当前的标题和内容
+func (RevisionLogic) snapshot(object interface{}) *revisionSnapshot {
+	switch entity := object.(type) {
+	case *model.Topic:
+		uid := entity.Uid
+		if entity.EditorUid != 0 {
+			uid = entity.EditorUid
+		}
+		at := time.Time(entity.Mtime)
+		if at.IsZero() {
+			at = time.Time(entity.Ctime)
+		}
+		return &revisionSnapshot{uid: uid, title: entity.Title, content: entity.Content, at: at}
+	case *model.Article:
+		at := time.Time(entity.Mtime)
+		if at.IsZero() {
+			at = time.Time(entity.Ctime)
+		}
+		return &revisionSnapshot{uid: DefaultArticle.getOwner(entity.Id), title: entity.Title, content: entity.Content, at: at}
+	case *model.Wiki:
+		at := entity.Mtime
+		if at.IsZero() {
+			at = time.Time(entity.Ctime)
+		}
+		return &revisionSnapshot{uid: entity.Uid, title: entity.Title, content: entity.Content, at: at}
+	}
+	return &revisionSnapshot{}
+}
+
+func (RevisionLogic) findVersion(ctx context.Context, objtype, objid, version int) (*model.Revision, error) {
+	revision := &model.Revision{}
+	filter := bson.M{"objtype": objtype, "objid": objid, "version": version}
+	err := db.GetCollection("revision").FindOne(ctx, filter).Decode(revision)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("版本 " + strconv.Itoa(version) + " 不存在")
+		}
+		GetLogger(ctx).Errorln("RevisionLogic findVersion error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	return revision, nil
+}
+
+func (RevisionLogic) fillUser(ctx context.Context, revisions ...*model.Revision) {
+	uidSet := set.New(set.NonThreadSafe)
+	for _, revision := range revisions {
+		if revision.Uid > 0 {
+			uidSet.Add(revision.Uid)
+		}
+	}
+	if uidSet.Size() == 0 {
+		return
+	}
+
+	usersMap := DefaultUser.FindUserInfos(ctx, set.IntSlice(uidSet))
+	for _, revision := range revisions {
+		revision.User = usersMap[revision.Uid]
+	}
+}
@@ -8,30 +8,73 @@ package logic
 
 import (
 	"context"
+	"errors"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/studygolang/studygolang/db"
 	"github.com/studygolang/studygolang/internal/model"
+	"github.com/studygolang/studygolang/util"
 
+	"github.com/garyburd/redigo/redis"
+	"github.com/polaris1119/config"
+	"github.com/polaris1119/logger"
 	"github.com/polaris1119/nosql"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// IP 名单版本号，后台修改后递增，其他实例据此重新加载
+	riskIPVersionKey = "risk:ip:version"
+	// 检查名单版本的间隔
+	riskIPCheckInterval = 30 * time.Second
+	// 查询某网段的访问用户时，最多返回的用户数
+	maxIPSeenUsers = 100
+	// 之前版本的 IP 黑名单，Redis hash，field 是 IP
+	legacyBlackIPKey = "black:ip"
+)
+
+// riskIPDict 内存中的 IP 黑白名单，AutoLogin 每个请求都要查询，不能每次访问 Redis 或 DB
+type riskIPDict struct {
+	block *util.IPSet
+	allow *util.IPSet
+	// 最早的过期时间，到期后重新加载
+	nextExpire time.Time
+	version    string
+	checkedAt  time.Time
+}
+
+var (
+	riskIPLocker  sync.RWMutex
+	riskIPCurDict *riskIPDict
 )
 
 type RiskLogic struct{}
 
 var DefaultRisk = RiskLogic{}
 
-// AddBlackIP 加入 IP 黑名单
-func (RiskLogic) AddBlackIP(ip string) error {
-	redisClient := nosql.NewRedisClient()
-	defer redisClient.Close()
-
-	key := "black:ip"
-	return redisClient.HSET(key, ip, "1")
+// AddBlackIP 自动加入 IP 黑名单（如发布敏感词被冻结）。和之前一样默认永久有效，
+// 可以通过配置 [risk] auto_block_ttl 设置有效期
+func (self RiskLogic) AddBlackIP(ctx context.Context, ip, reason string) error {
+	var ttl time.Duration
+	if val := config.ConfigFile.MustValue("risk", "auto_block_ttl"); val != "" {
+		var err error
+		if ttl, err = time.ParseDuration(val); err != nil || ttl < 0 {
+			GetLogger(ctx).Errorln("RiskLogic AddBlackIP invalid auto_block_ttl:", val)
+			ttl = 0
+		}
+	}
+	return self.Add(ctx, &model.Me{}, model.RiskListBlock, ip, reason, ttl)
 }
 
 // AddBlackIPByUID 通过用户 UID 将最后一次登录 IP 加入黑名单
-func (self RiskLogic) AddBlackIPByUID(uid int) error {
-	ctx := context.Background()
+func (self RiskLogic) AddBlackIPByUID(ctx context.Context, uid int, reason string) error {
 	userLogin := &model.UserLogin{}
 	err := db.GetCollection("user_login").FindOne(ctx, bson.M{"_id": uid}).Decode(userLogin)
 	if err != nil {
@@ -39,22 +82,436 @@ func (self RiskLogic) AddBlackIPByUID(uid int) error {
 	}
 
 	if userLogin.LoginIp != "" {
-		return self.AddBlackIP(userLogin.LoginIp)
+		return self.AddBlackIP(ctx, userLogin.LoginIp, reason)
 	}
 
 	return nil
 }
 
-// IsBlackIP 是否是 IP 黑名单
-func (RiskLogic) IsBlackIP(ip string) bool {
-	redisClient := nosql.NewRedisClient()
+// IsBlackIP 是否是 IP 黑名单（在白名单中的不算）
+func (self RiskLogic) IsBlackIP(ip string) bool {
+	// 未安装时
+	if db.MasterDB == nil {
+		return false
+	}
+
+	dict := self.dict(context.Background())
+
+	if _, ok := dict.allow.Lookup(ip); ok {
+		return false
+	}
+	_, ok := dict.block.Lookup(ip)
+	return ok
+}
+
+// Add 加入黑名单或白名单，cidr 可以是单个 IP 或网段；ttl 为 0 表示永久。
+// 同一名单中已有该网段时，更新原因和有效期
+func (self RiskLogic) Add(ctx context.Context, me *model.Me, list, cidr, reason string, ttl time.Duration) error {
+	objLog := GetLogger(ctx)
+
+	if _, ok := model.RiskListMap[list]; !ok {
+		return errors.New("名单类型不正确")
+	}
+	prefix, err := util.ParsePrefix(cidr)
+	if err != nil {
+		return errors.New("IP 或网段格式不正确")
+	}
+	if ttl < 0 {
+		return errors.New("有效期不正确")
+	}
+
+	if err = self.upsert(ctx, me, list, prefix, reason, ttl); err != nil {
+		objLog.Errorln("RiskLogic Add error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	logger.Infoln("risk ip add, list:", list, "cidr:", prefix, "reason:", reason, "op:", me.Uid)
+	self.reload(ctx)
+	return nil
+}
+
+// upsert 写入名单条目，不重新加载名单
+func (RiskLogic) upsert(ctx context.Context, me *model.Me, list string, prefix netip.Prefix, reason string, ttl time.Duration) error {
+	id, err := db.NextID("risk_ip")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	change := bson.M{
+		"reason":     strings.TrimSpace(reason),
+		"op_uid":     me.Uid,
+		"updated_at": now,
+	}
+	update := bson.M{
+		"$set": change,
+		"$setOnInsert": bson.M{
+			"_id":        id,
+			"created_at": now,
+		},
+	}
+	if ttl > 0 {
+		change["expire_at"] = now.Add(ttl)
+	} else {
+		update["$unset"] = bson.M{"expire_at": ""}
+	}
+
+	filter := bson.M{"list": list, "cidr": prefix.String()}
+	_, err = db.GetCollection("risk_ip").UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// Remove 从名单中移除
+func (self RiskLogic) Remove(ctx context.Context, id int) error {
+	objLog := GetLogger(ctx)
+
+	result, err := db.GetCollection("risk_ip").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		objLog.Errorln("RiskLogic Remove error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.DeletedCount == 0 {
+		return NotFoundErr
+	}
+
+	self.reload(ctx)
+	return nil
+}
+
+// FindAll 后台名单列表，list 为空时不过滤。kw 是 IP 或网段时，查找和它有交集的网段；
+// 否则按网段或原因模糊搜索
+func (RiskLogic) FindAll(ctx context.Context, paginator *Paginator, list, kw string) []*model.RiskIP {
+	objLog := GetLogger(ctx)
+
+	filter := bson.M{}
+	if list != "" {
+		filter["list"] = list
+	}
+
+	kw = strings.TrimSpace(kw)
+	kwPrefix, err := util.ParsePrefix(kw)
+	isPrefix := kw != "" && err == nil
+	if kw != "" && !isPrefix {
+		regex := bson.M{"$regex": regexp.QuoteMeta(kw)}
+		filter["$or"] = []bson.M{{"cidr": regex}, {"reason": regex}}
+	}
+
+	coll := db.GetCollection("risk_ip")
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+	if !isPrefix {
+		total, err := coll.CountDocuments(ctx, filter)
+		if err != nil {
+			objLog.Errorln("RiskLogic FindAll count error:", err)
+			return nil
+		}
+		paginator.SetTotal(total)
+		opts.SetSkip(int64(paginator.Offset())).SetLimit(int64(paginator.PerPage()))
+	}
+
+	riskIPs := make([]*model.RiskIP, 0)
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		objLog.Errorln("RiskLogic FindAll error:", err)
+		return nil
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &riskIPs); err != nil {
+		objLog.Errorln("RiskLogic FindAll decode error:", err)
+		return nil
+	}
+
+	if !isPrefix {
+		return riskIPs
+	}
+
+	// 网段在库中是字符串，无法直接比较，在内存中过滤后分页
+	matched := make([]*model.RiskIP, 0)
+	for _, riskIP := range riskIPs {
+		prefix, err := util.ParsePrefix(riskIP.Cidr)
+		if err == nil && prefix.Overlaps(kwPrefix) {
+			matched = append(matched, riskIP)
+		}
+	}
+	paginator.SetTotal(int64(len(matched)))
+
+	start := paginator.Offset()
+	if start >= len(matched) {
+		return []*model.RiskIP{}
+	}
+	end := start + paginator.PerPage()
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end]
+}
+
+// FindSeenUsers 最近从 cidr（IP 或网段）访问过的用户：最后一次登录的 IP 和未过期的登录会话
+func (RiskLogic) FindSeenUsers(ctx context.Context, cidr string) ([]*model.IPSeenUser, error) {
+	objLog := GetLogger(ctx)
+
+	prefix, err := util.ParsePrefix(cidr)
+	if err != nil {
+		return nil, errors.New("IP 或网段格式不正确")
+	}
+
+	ipFilter, err := seenIPFilter(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*model.IPSeenUser, 0)
+
+	userLogins := make([]*model.UserLogin, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "login_time", Value: -1}}).SetLimit(5 * maxIPSeenUsers)
+	cursor, err := db.GetCollection("user_login").Find(ctx, bson.M{"login_ip": ipFilter}, opts)
+	if err != nil {
+		objLog.Errorln("RiskLogic FindSeenUsers find user_login error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	err = cursor.All(ctx, &userLogins)
+	cursor.Close(ctx)
+	if err != nil {
+		objLog.Errorln("RiskLogic FindSeenUsers decode user_login error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	for _, userLogin := range userLogins {
+		if ipInPrefix(prefix, userLogin.LoginIp) {
+			users = append(users, &model.IPSeenUser{
+				Uid:      userLogin.Uid,
+				Username: userLogin.Username,
+				Ip:       userLogin.LoginIp,
+				Source:   "login",
+				SeenAt:   userLogin.LoginTime,
+			})
+		}
+	}
+
+	sessions := make([]*model.UserSession, 0)
+	filter := bson.M{"ip": ipFilter, "expire_at": bson.M{"$gt": time.Now()}}
+	opts = options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}}).SetLimit(5 * maxIPSeenUsers)
+	cursor, err = db.GetCollection("user_session").Find(ctx, filter, opts)
+	if err != nil {
+		objLog.Errorln("RiskLogic FindSeenUsers find user_session error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	err = cursor.All(ctx, &sessions)
+	cursor.Close(ctx)
+	if err != nil {
+		objLog.Errorln("RiskLogic FindSeenUsers decode user_session error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	for _, session := range sessions {
+		if ipInPrefix(prefix, session.Ip) {
+			users = append(users, &model.IPSeenUser{
+				Uid:      session.Uid,
+				Username: session.Username,
+				Ip:       session.Ip,
+				Source:   "session",
+				SeenAt:   session.LastSeenAt,
+			})
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].SeenAt.After(users[j].SeenAt)
+	})
+	if len(users) > maxIPSeenUsers {
+		users = users[:maxIPSeenUsers]
+	}
+	return users, nil
+}
+
+// seenIPFilter 查询某网段访问记录的条件。库中 IP 是字符串，单个 IP 直接相等；
+// 网段用按文本前缀的正则缩小范围，再在内存中精确过滤，因此网段不能太大
+func seenIPFilter(prefix netip.Prefix) (interface{}, error) {
+	addr := prefix.Addr()
+	if prefix.IsSingleIP() {
+		return addr.String(), nil
+	}
+
+	if addr.Is4() {
+		if prefix.Bits() < 16 {
+			return nil, errors.New("IPv4 网段不能大于 /16")
+		}
+		octets := strings.Split(addr.String(), ".")
+		return bson.M{"$regex": "^" + regexp.QuoteMeta(strings.Join(octets[:prefix.Bits()/8], ".")+".")}, nil
+	}
+
+	if prefix.Bits() < 32 {
+		return nil, errors.New("IPv6 网段不能大于 /32")
+	}
+	// 库中是压缩格式，只有第一段可靠
+	first := addr.As16()
+	return bson.M{"$regex": "^" + strconv.FormatInt(int64(first[0])<<8|int64(first[1]), 16) + ":"}, nil
+}
+
+func ipInPrefix(prefix netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	return prefix.Contains(addr.Unmap().WithZone(""))
+}
+
+// reload 名单修改后递增版本号并立即重新加载；其他实例在下次检查版本时加载
+func (self RiskLogic) reload(ctx context.Context) {
+	redisClient := nosql.NewRedisFromPool()
 	defer redisClient.Close()
 
-	key := "black:ip"
-	val, err := redisClient.HGET(key, ip)
+	if err := redisClient.INCR(riskIPVersionKey); err != nil {
+		GetLogger(ctx).Errorln("RiskLogic reload incr version error:", err)
+	}
+
+	riskIPLocker.Lock()
+	defer riskIPLocker.Unlock()
+	riskIPCurDict = self.load(ctx, redisClient.GET(riskIPVersionKey))
+}
+
+// dict 获取当前名单，有条目过期或超过检查间隔且版本号有变化时重新加载
+func (self RiskLogic) dict(ctx context.Context) *riskIPDict {
+	riskIPLocker.RLock()
+	dict := riskIPCurDict
+	riskIPLocker.RUnlock()
+
+	now := time.Now()
+	expired := dict != nil && !dict.nextExpire.IsZero() && now.After(dict.nextExpire)
+	if dict != nil && !expired && now.Sub(dict.checkedAt) < riskIPCheckInterval {
+		return dict
+	}
+
+	riskIPLocker.Lock()
+	defer riskIPLocker.Unlock()
+
+	// 等锁期间可能已经被其他请求加载
+	if riskIPCurDict != dict {
+		return riskIPCurDict
+	}
+
+	redisClient := nosql.NewRedisFromPool()
+	version := redisClient.GET(riskIPVersionKey)
+	redisClient.Close()
+
+	if dict != nil && !expired && dict.version == version {
+		checked := *dict
+		checked.checkedAt = now
+		riskIPCurDict = &checked
+		return riskIPCurDict
+	}
+
+	riskIPCurDict = self.load(ctx, version)
+	return riskIPCurDict
+}
+
+// load 从库中加载未过期的名单
+func (self RiskLogic) load(ctx context.Context, version string) *riskIPDict {
+	objLog := GetLogger(ctx)
+
+	now := time.Now()
+	dict := &riskIPDict{
+		block:     util.NewIPSet(),
+		allow:     util.NewIPSet(),
+		version:   version,
+		checkedAt: now,
+	}
+
+	filter := bson.M{"$or": []bson.M{
+		{"expire_at": bson.M{"$exists": false}},
+		{"expire_at": bson.M{"$gt": now}},
+	}}
+	cursor, err := db.GetCollection("risk_ip").Find(ctx, filter)
+	if err != nil {
+		objLog.Errorln("RiskLogic load error:", err)
+		return dict
+	}
+	defer cursor.Close(ctx)
+
+	riskIPs := make([]*model.RiskIP, 0)
+	if err = cursor.All(ctx, &riskIPs); err != nil {
+		objLog.Errorln("RiskLogic load decode error:", err)
+		return dict
+	}
+
+	for _, riskIP := range riskIPs {
+		prefix, err := util.ParsePrefix(riskIP.Cidr)
+		if err != nil {
+			objLog.Errorln("RiskLogic load invalid cidr:", riskIP.Cidr)
+			continue
+		}
+
+		if riskIP.List == model.RiskListAllow {
+			dict.allow.Add(prefix, riskIP.Id)
+		} else {
+			dict.block.Add(prefix, riskIP.Id)
+		}
+
+		if !riskIP.ExpireAt.IsZero() && (dict.nextExpire.IsZero() || riskIP.ExpireAt.Before(dict.nextExpire)) {
+			dict.nextExpire = riskIP.ExpireAt
+		}
+	}
+
+	logger.Infoln("risk ip loaded, version:", version, "block:", dict.block.Len(), "allow:", dict.allow.Len())
+	return dict
+}
+
+// UpgradeBlackIP 把 Redis 中旧的 IP 黑名单导入库中，和之前一样永久有效，已导入的会从旧黑名单删除
+func (self RiskLogic) UpgradeBlackIP() {
+	ctx := context.Background()
+
+	redisClient := nosql.NewRedisFromPool()
+	defer redisClient.Close()
+
+	var (
+		cursor      uint64
+		err         error
+		resultSlice []interface{}
+		imported    int
+	)
+
+	for {
+		cursor, resultSlice, err = redisClient.HSCAN(legacyBlackIPKey, cursor, "COUNT", 100)
+		if err != nil {
+			logger.Errorln("RiskLogic UpgradeBlackIP HSCAN error:", err)
+			break
+		}
+
+		for len(resultSlice) > 0 {
+			var ip, val string
+			resultSlice, err = redis.Scan(resultSlice, &ip, &val)
+			if err != nil {
+				logger.Errorln("RiskLogic UpgradeBlackIP redis Scan error:", err)
+				break
+			}
+
+			if self.moveLegacyBlackIP(ctx, redisClient, ip) {
+				imported++
+			}
+		}
+
+		if cursor == 0 {
+			break
+		}
+	}
+
+	if imported > 0 {
+		logger.Infoln("RiskLogic UpgradeBlackIP import", imported, "black ips from redis")
+		self.reload(ctx)
+	}
+}
+
+// moveLegacyBlackIP 把旧黑名单中的 ip 写入库中并从旧黑名单删除
+func (self RiskLogic) moveLegacyBlackIP(ctx context.Context, redisClient *nosql.RedisClient, ip string) bool {
+	prefix, err := util.ParsePrefix(ip)
 	if err != nil {
+		logger.Errorln("RiskLogic UpgradeBlackIP invalid ip:", ip)
+		redisClient.HDEL(legacyBlackIPKey, ip)
 		return false
 	}
 
-	return val == "1"
+	if err = self.upsert(ctx, &model.Me{}, model.RiskListBlock, prefix, "从旧的 IP 黑名单导入", 0); err != nil {
+		logger.Errorln("RiskLogic UpgradeBlackIP upsert:", ip, "error:", err)
+		return false
+	}
+	redisClient.HDEL(legacyBlackIPKey, ip)
+	return true
 }
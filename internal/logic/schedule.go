@@ -0,0 +1,352 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/logger"
+	"github.com/polaris1119/times"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// 定时发布时间的范围：至少 5 分钟后，最多 90 天内
+	minScheduleDelay = 5 * time.Minute
+	maxScheduleDelay = 90 * 24 * time.Hour
+	// 每次最多发布的条数，剩下的下一分钟再发
+	schedulePublishBatch = 100
+)
+
+var publishAtLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// ScheduledItem 待定时发布的内容
+type ScheduledItem struct {
+	Objtype   int       `json:"objtype"`
+	Objid     int       `json:"objid"`
+	Title     string    `json:"title"`
+	PublishAt time.Time `json:"publish_at"`
+}
+
+type ScheduleLogic struct{}
+
+var DefaultSchedule = ScheduleLogic{}
+
+// FindMine 我的待发布主题和文章，发布时间早的在前
+func (ScheduleLogic) FindMine(ctx context.Context, me *model.Me) []*ScheduledItem {
+	objLog := GetLogger(ctx)
+
+	items := make([]*ScheduledItem, 0)
+	opts := options.Find().SetProjection(bson.M{"title": 1, "publish_at": 1}).SetLimit(maxDraftNum)
+
+	cursor, err := db.GetCollection("topics").Find(ctx, bson.M{"uid": me.Uid, "flag": model.FlagScheduled}, opts)
+	if err != nil {
+		objLog.Errorln("ScheduleLogic FindMine topics error:", err)
+		return nil
+	}
+	topics := make([]*model.Topic, 0)
+	err = cursor.All(ctx, &topics)
+	cursor.Close(ctx)
+	if err != nil {
+		objLog.Errorln("ScheduleLogic FindMine topics decode error:", err)
+		return nil
+	}
+	for _, topic := range topics {
+		items = append(items, &ScheduledItem{
+			Objtype:   model.TypeTopic,
+			Objid:     topic.Tid,
+			Title:     topic.Title,
+			PublishAt: topic.PublishAt,
+		})
+	}
+
+	filter := bson.M{"author": me.Username, "status": model.ArticleStatusScheduled}
+	cursor, err = db.GetCollection("articles").Find(ctx, filter, opts)
+	if err != nil {
+		objLog.Errorln("ScheduleLogic FindMine articles error:", err)
+		return nil
+	}
+	articles := make([]*model.Article, 0)
+	err = cursor.All(ctx, &articles)
+	cursor.Close(ctx)
+	if err != nil {
+		objLog.Errorln("ScheduleLogic FindMine articles decode error:", err)
+		return nil
+	}
+	for _, article := range articles {
+		items = append(items, &ScheduledItem{
+			Objtype:   model.TypeArticle,
+			Objid:     article.Id,
+			Title:     article.Title,
+			PublishAt: article.PublishAt,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].PublishAt.Before(items[j].PublishAt)
+	})
+	return items
+}
+
+// Reschedule 修改定时发布的时间
+func (self ScheduleLogic) Reschedule(ctx context.Context, me *model.Me, objtype, objid int, publishAt string) error {
+	objLog := GetLogger(ctx)
+
+	at, err := self.parsePublishAt(publishAt)
+	if err != nil {
+		return err
+	}
+	if at.IsZero() {
+		return errors.New("请设置发布时间")
+	}
+
+	coll, filter, err := self.findScheduled(ctx, me, objtype, objid)
+	if err != nil {
+		return err
+	}
+
+	// ctime 跟着发布时间走，保证作者在发布前都能编辑
+	change := bson.M{"publish_at": at, "ctime": at, "mtime": at}
+	result, err := db.GetCollection(coll).UpdateOne(ctx, filter, bson.M{"$set": change})
+	if err != nil {
+		objLog.Errorln("ScheduleLogic Reschedule error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("已经发布，不能修改发布时间")
+	}
+	return nil
+}
+
+// Cancel 取消定时发布：内容转为作者的草稿，可以继续编辑后再发布（管理员取消别人的也是）。返回草稿 id
+func (self ScheduleLogic) Cancel(ctx context.Context, me *model.Me, objtype, objid int) (int, error) {
+	objLog := GetLogger(ctx)
+
+	coll, filter, err := self.findScheduled(ctx, me, objtype, objid)
+	if err != nil {
+		return 0, err
+	}
+
+	owner := me
+	form := url.Values{}
+	var change bson.M
+	switch objtype {
+	case model.TypeTopic:
+		topic := DefaultTopic.findByTid(objid)
+		if topic.Uid != me.Uid {
+			owner = &model.Me{Uid: topic.Uid}
+		}
+		form.Set("title", topic.Title)
+		form.Set("content", topic.Content)
+		form.Set("nid", strconv.Itoa(topic.Nid))
+		form.Set("permission", strconv.Itoa(topic.Permission))
+		form.Set("question", strconv.FormatBool(topic.Question))
+		if topic.AtUsernames != "" {
+			form.Set("usernames", topic.AtUsernames)
+		}
+		if poll := DefaultPoll.findByTid(ctx, me, objid); poll != nil {
+			DefaultPoll.fillForm(form, poll)
+		}
+		change = bson.M{"flag": model.FlagUserDelete}
+	case model.TypeArticle:
+		article, err := DefaultArticle.FindById(ctx, objid)
+		if err != nil {
+			return 0, errors.New("内部服务错误")
+		}
+		if uid := DefaultArticle.getOwner(article.Id); uid != 0 && uid != me.Uid {
+			owner = &model.Me{Uid: uid}
+		}
+		form.Set("title", article.Title)
+		form.Set("content", article.Content)
+		form.Set("cover", article.Cover)
+		form.Set("markdown", strconv.FormatBool(article.Markdown))
+		change = bson.M{"status": model.ArticleStatusOffline}
+	}
+
+	// 先保存草稿，避免内容丢失
+	draft, err := DefaultDraft.Save(ctx, owner, 0, objtype, 0, form)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := db.GetCollection(coll).UpdateOne(ctx, filter, bson.M{"$set": change, "$unset": bson.M{"publish_at": ""}})
+	if err != nil || result.MatchedCount == 0 {
+		DefaultDraft.Delete(ctx, owner, draft.Id)
+		if err != nil {
+			objLog.Errorln("ScheduleLogic Cancel error:", err)
+			return 0, errors.New("内部服务错误")
+		}
+		return 0, errors.New("已经发布，不能取消")
+	}
+
+	return draft.Id, nil
+}
+
+// PublishDue 发布到时间的主题和文章，每分钟执行一次
+func (self ScheduleLogic) PublishDue() {
+	ctx := context.Background()
+	now := time.Now()
+	opts := options.Find().SetSort(bson.D{{Key: "publish_at", Value: 1}}).SetLimit(schedulePublishBatch)
+
+	cursor, err := db.GetCollection("topics").Find(ctx, bson.M{
+		"flag":       model.FlagScheduled,
+		"publish_at": bson.M{"$lte": now},
+	}, opts)
+	if err != nil {
+		logger.Errorln("ScheduleLogic PublishDue find topics error:", err)
+		return
+	}
+	topics := make([]*model.Topic, 0)
+	err = cursor.All(ctx, &topics)
+	cursor.Close(ctx)
+	if err != nil {
+		logger.Errorln("ScheduleLogic PublishDue decode topics error:", err)
+		return
+	}
+	for _, topic := range topics {
+		self.publishTopic(ctx, topic, model.FlagScheduled)
+	}
+
+	cursor, err = db.GetCollection("articles").Find(ctx, bson.M{
+		"status":     model.ArticleStatusScheduled,
+		"publish_at": bson.M{"$lte": now},
+	}, opts)
+	if err != nil {
+		logger.Errorln("ScheduleLogic PublishDue find articles error:", err)
+		return
+	}
+	articles := make([]*model.Article, 0)
+	err = cursor.All(ctx, &articles)
+	cursor.Close(ctx)
+	if err != nil {
+		logger.Errorln("ScheduleLogic PublishDue decode articles error:", err)
+		return
+	}
+	for _, article := range articles {
+		self.publishArticle(ctx, article)
+	}
+}
+
+// publishTopic 发布定时主题：从 fromFlag 状态改为正常，再发动态、通知观察者。
+// 用条件更新抢占，多个实例或审核同时发布时只发一次
+func (ScheduleLogic) publishTopic(ctx context.Context, topic *model.Topic, fromFlag uint8) {
+	now := time.Now()
+	result, err := db.GetCollection("topics").UpdateOne(ctx,
+		bson.M{"_id": topic.Tid, "flag": fromFlag, "publish_at": topic.PublishAt},
+		bson.M{
+			"$set":   bson.M{"flag": model.FlagNoAudit, "ctime": now, "mtime": now},
+			"$unset": bson.M{"publish_at": "", "at_usernames": ""},
+		})
+	if err != nil {
+		logger.Errorln("ScheduleLogic publishTopic error:", err)
+		return
+	}
+	if result.ModifiedCount == 0 {
+		return
+	}
+
+	topic.Flag = model.FlagNoAudit
+	topic.Ctime = model.OftenTime(now)
+	topic.Mtime = model.OftenTime(now)
+	topic.PublishAt = time.Time{}
+	DefaultTopic.published(ctx, nil, topic, topic.AtUsernames)
+}
+
+// publishArticle 发布定时文章：上线，再发动态、通知观察者
+func (ScheduleLogic) publishArticle(ctx context.Context, article *model.Article) {
+	now := time.Now()
+	change := bson.M{
+		"status":   model.ArticleStatusNew,
+		"pub_date": times.Format("Y-m-d H:i:s", now),
+		"ctime":    now,
+		"mtime":    now,
+	}
+	result, err := db.GetCollection("articles").UpdateOne(ctx,
+		bson.M{"_id": article.Id, "status": model.ArticleStatusScheduled, "publish_at": article.PublishAt},
+		bson.M{"$set": change, "$unset": bson.M{"publish_at": ""}})
+	if err != nil {
+		logger.Errorln("ScheduleLogic publishArticle error:", err)
+		return
+	}
+	if result.ModifiedCount == 0 {
+		return
+	}
+
+	article.Status = model.ArticleStatusNew
+	article.PubDate = change["pub_date"].(string)
+	article.Ctime = model.OftenTime(now)
+	article.Mtime = model.OftenTime(now)
+	article.PublishAt = time.Time{}
+	article.AfterLoad()
+	article.AfterInsert()
+
+	go publishObservable.NotifyObservers(DefaultArticle.getOwner(article.Id), model.TypeArticle, article.Id)
+}
+
+// findScheduled 查找待发布的内容并检查 me 能否修改，返回集合名和用于抢占的条件
+func (ScheduleLogic) findScheduled(ctx context.Context, me *model.Me, objtype, objid int) (string, bson.M, error) {
+	switch objtype {
+	case model.TypeTopic:
+		topic := DefaultTopic.findByTid(objid)
+		if topic.Tid == 0 || topic.Flag != model.FlagScheduled {
+			return "", nil, errors.New("该主题不是定时发布的")
+		}
+		if !CanEdit(ctx, me, topic) {
+			return "", nil, NotModifyAuthorityErr
+		}
+		return "topics", bson.M{"_id": objid, "flag": model.FlagScheduled}, nil
+	case model.TypeArticle:
+		article, err := DefaultArticle.FindById(ctx, objid)
+		if err != nil || article.Id == 0 || article.Status != model.ArticleStatusScheduled {
+			return "", nil, errors.New("该文章不是定时发布的")
+		}
+		if !CanEdit(ctx, me, article) {
+			return "", nil, NotModifyAuthorityErr
+		}
+		return "articles", bson.M{"_id": objid, "status": model.ArticleStatusScheduled}, nil
+	}
+
+	return "", nil, errors.New("该类型的内容不支持定时发布")
+}
+
+// parsePublishAt 解析发布时间（本地时间），为空表示立即发布
+func (ScheduleLogic) parsePublishAt(val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+
+	var (
+		at  time.Time
+		err error
+	)
+	for _, layout := range publishAtLayouts {
+		if at, err = time.ParseInLocation(layout, val, time.Local); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, errors.New("发布时间格式不正确")
+	}
+
+	delay := time.Until(at)
+	if delay < minScheduleDelay {
+		return time.Time{}, errors.New("发布时间至少要在 5 分钟之后")
+	}
+	if delay > maxScheduleDelay {
+		return time.Time{}, errors.New("发布时间不能超过 90 天")
+	}
+	// 按秒存储，和 Mongo 的精度一致，方便条件更新时比较
+	return at.Truncate(time.Second), nil
+}
@@ -102,7 +102,7 @@ func (self SearcherLogic) IndexingArticle(isAll bool) {
 			}
 
 			document := model.NewDocument(article, nil)
-			if article.Status != model.ArticleStatusOffline {
+			if article.Status < model.ArticleStatusOffline {
 				solrClient.PushAdd(model.NewDefaultArgsAddCommand(document))
 			} else {
 				solrClient.PushDel(model.NewDelCommand(document))
@@ -193,9 +193,12 @@ func (self SearcherLogic) IndexingTopic(isAll bool) {
 			topicEx := topicExMap[topic.Tid]
 
 			document := model.NewDocument(topic, topicEx)
-			addCommand := model.NewDefaultArgsAddCommand(document)
-
-			solrClient.PushAdd(addCommand)
+			// 关注可见、自己可见的主题不进入索引，已经索引的删除
+			if topic.Restricted() || topic.Flag > model.FlagNormal {
+				solrClient.PushDel(model.NewDelCommand(document))
+			} else {
+				solrClient.PushAdd(model.NewDefaultArgsAddCommand(document))
+			}
 		}
 
 		solrClient.Post()
@@ -487,7 +490,7 @@ func (this *SearcherLogic) SearchByField(field, value string, start, rows int, s
 		"start": []string{strconv.Itoa(start)},
 		"rows":  []string{strconv.Itoa(rows)},
 		"sort":  []string{sort},
-		"fl":    []string{"objid,objtype,title,author,uid,pub_time,tags,viewnum,cmtnum,likenum,lastreplyuid,lastreplytime,updated_at,top,nid"},
+		"fl":    []string{"objid,objtype,title,author,uid,pub_time,tags,viewnum,cmtnum,likenum,lastreplyuid,lastreplytime,updated_at,top,nid,solved"},
 	}
 
 	values.Add("q", value)
@@ -0,0 +1,367 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+	"github.com/studygolang/studygolang/util"
+
+	"github.com/polaris1119/config"
+	"github.com/polaris1119/logger"
+	"github.com/polaris1119/nosql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// 敏感词词库版本号，后台修改后递增，其他实例据此重新加载
+	sensitiveVersionKey = "sensitive:version"
+	// 检查词库版本的间隔
+	sensitiveCheckInterval = 30 * time.Second
+)
+
+// sensitiveDict 内存中的敏感词词库
+type sensitiveDict struct {
+	matcher   *util.ACMatcher
+	words     []*model.SensitiveWord
+	version   string
+	checkedAt time.Time
+}
+
+var (
+	sensitiveLocker  sync.RWMutex
+	sensitiveCurDict *sensitiveDict
+)
+
+// SensitiveHit 命中的敏感词
+type SensitiveHit struct {
+	Word     string `json:"word"`
+	Category string `json:"category"`
+	Action   string `json:"action"`
+}
+
+// SensitiveResult 敏感词检测结果。Action 为命中词中最严重的处理方式，没有命中时为空；
+// Masked 为把 mask 类敏感词替换成 * 之后的文本
+type SensitiveResult struct {
+	Hits   []*SensitiveHit `json:"hits"`
+	Action string          `json:"action"`
+	Masked string          `json:"masked"`
+}
+
+// Words 命中的词，用于提示和记录
+func (this *SensitiveResult) Words() []string {
+	words := make([]string, 0, len(this.Hits))
+	for _, hit := range this.Hits {
+		words = append(words, hit.Word)
+	}
+	return words
+}
+
+type SensitiveLogic struct{}
+
+var DefaultSensitive = SensitiveLogic{}
+
+// Check 检测 text 中的敏感词
+func (self SensitiveLogic) Check(ctx context.Context, text string) *SensitiveResult {
+	result := &SensitiveResult{Hits: make([]*SensitiveHit, 0), Masked: text}
+	if text == "" {
+		return result
+	}
+
+	dict := self.dict(ctx)
+	if dict == nil || dict.matcher == nil {
+		return result
+	}
+
+	matches := dict.matcher.Match(text)
+	seen := make(map[int]bool, len(matches))
+	maskMatches := make([]util.ACMatch, 0)
+	for _, match := range matches {
+		word := dict.words[match.Index]
+		if word.Action == model.SensitiveActionMask {
+			maskMatches = append(maskMatches, match)
+		}
+
+		if seen[match.Index] {
+			continue
+		}
+		seen[match.Index] = true
+
+		result.Hits = append(result.Hits, &SensitiveHit{
+			Word:     word.Word,
+			Category: word.Category,
+			Action:   word.Action,
+		})
+		if model.SensitiveActionLevel[word.Action] > model.SensitiveActionLevel[result.Action] {
+			result.Action = word.Action
+		}
+	}
+	result.Masked = util.Mask(text, maskMatches)
+
+	return result
+}
+
+// FindAll 后台敏感词列表，category、kw 为空时不过滤
+func (SensitiveLogic) FindAll(ctx context.Context, paginator *Paginator, category, kw string) []*model.SensitiveWord {
+	objLog := GetLogger(ctx)
+
+	filter := bson.M{}
+	if category != "" {
+		filter["category"] = category
+	}
+	if kw != "" {
+		filter["word"] = bson.M{"$regex": regexp.QuoteMeta(kw)}
+	}
+
+	total, err := db.GetCollection("sensitive_word").CountDocuments(ctx, filter)
+	if err != nil {
+		objLog.Errorln("SensitiveLogic FindAll count error:", err)
+		return nil
+	}
+	paginator.SetTotal(total)
+
+	words := make([]*model.SensitiveWord, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetSkip(int64(paginator.Offset())).SetLimit(int64(paginator.PerPage()))
+	cursor, err := db.GetCollection("sensitive_word").Find(ctx, filter, opts)
+	if err != nil {
+		objLog.Errorln("SensitiveLogic FindAll error:", err)
+		return nil
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &words); err != nil {
+		objLog.Errorln("SensitiveLogic FindAll decode error:", err)
+		return nil
+	}
+	return words
+}
+
+// Save 新增（id 为 0）或修改敏感词
+func (self SensitiveLogic) Save(ctx context.Context, me *model.Me, id int, word, category, action string) error {
+	objLog := GetLogger(ctx)
+
+	word = strings.TrimSpace(word)
+	if runes, _ := util.NormalizeRunes(word); len(runes) == 0 {
+		return errors.New("敏感词不能为空")
+	}
+	if _, ok := model.SensitiveCategoryMap[category]; !ok {
+		return errors.New("分类不正确")
+	}
+	if _, ok := model.SensitiveActionLevel[action]; !ok {
+		return errors.New("处理方式不正确")
+	}
+
+	coll := db.GetCollection("sensitive_word")
+	now := time.Now()
+
+	var err error
+	if id == 0 {
+		id, err = db.NextID("sensitive_word")
+		if err != nil {
+			objLog.Errorln("SensitiveLogic Save NextID error:", err)
+			return errors.New("内部服务错误")
+		}
+		_, err = coll.InsertOne(ctx, &model.SensitiveWord{
+			Id:        id,
+			Word:      word,
+			Category:  category,
+			Action:    action,
+			OpUid:     me.Uid,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	} else {
+		var result *mongo.UpdateResult
+		result, err = coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+			"word":       word,
+			"category":   category,
+			"action":     action,
+			"op_uid":     me.Uid,
+			"updated_at": now,
+		}})
+		if err == nil && result.MatchedCount == 0 {
+			return NotFoundErr
+		}
+	}
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("该敏感词已存在")
+		}
+		objLog.Errorln("SensitiveLogic Save error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	self.reload(ctx)
+	return nil
+}
+
+// Delete 删除敏感词
+func (self SensitiveLogic) Delete(ctx context.Context, id int) error {
+	objLog := GetLogger(ctx)
+
+	result, err := db.GetCollection("sensitive_word").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		objLog.Errorln("SensitiveLogic Delete error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.DeletedCount == 0 {
+		return NotFoundErr
+	}
+
+	self.reload(ctx)
+	return nil
+}
+
+// reload 词库修改后递增版本号并立即重新加载；其他实例在下次检查版本时加载
+func (self SensitiveLogic) reload(ctx context.Context) {
+	redisClient := nosql.NewRedisFromPool()
+	defer redisClient.Close()
+
+	if err := redisClient.INCR(sensitiveVersionKey); err != nil {
+		GetLogger(ctx).Errorln("SensitiveLogic reload incr version error:", err)
+	}
+
+	sensitiveLocker.Lock()
+	defer sensitiveLocker.Unlock()
+	sensitiveCurDict = self.load(ctx, redisClient.GET(sensitiveVersionKey))
+}
+
+// dict 获取当前词库，超过检查间隔时比较版本号，有变化则重新加载
+func (self SensitiveLogic) dict(ctx context.Context) *sensitiveDict {
+	sensitiveLocker.RLock()
+	dict := sensitiveCurDict
+	sensitiveLocker.RUnlock()
+
+	if dict != nil && time.Since(dict.checkedAt) < sensitiveCheckInterval {
+		return dict
+	}
+
+	sensitiveLocker.Lock()
+	defer sensitiveLocker.Unlock()
+
+	// 等锁期间可能已经被其他请求加载
+	if sensitiveCurDict != dict {
+		return sensitiveCurDict
+	}
+
+	redisClient := nosql.NewRedisFromPool()
+	version := redisClient.GET(sensitiveVersionKey)
+	redisClient.Close()
+
+	if dict != nil && dict.version == version {
+		checked := *dict
+		checked.checkedAt = time.Now()
+		sensitiveCurDict = &checked
+		return sensitiveCurDict
+	}
+
+	sensitiveCurDict = self.load(ctx, version)
+	return sensitiveCurDict
+}
+
+// load 从库中加载词库并构建自动机。从未添加过敏感词时，导入配置文件 [sensitive] 中的旧词库
+func (SensitiveLogic) load(ctx context.Context, version string) *sensitiveDict {
+	objLog := GetLogger(ctx)
+
+	dict := &sensitiveDict{
+		words:     make([]*model.SensitiveWord, 0),
+		version:   version,
+		checkedAt: time.Now(),
+	}
+
+	coll := db.GetCollection("sensitive_word")
+	cursor, err := coll.Find(ctx, bson.M{})
+	if err != nil {
+		objLog.Errorln("SensitiveLogic load error:", err)
+		return dict
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &dict.words); err != nil {
+		objLog.Errorln("SensitiveLogic load decode error:", err)
+		return dict
+	}
+
+	if len(dict.words) == 0 {
+		// 有计数器说明添加过（后来被删光了），不再导入
+		num, err := db.GetCollection("counters").CountDocuments(ctx, bson.M{"_id": "sensitive_word"})
+		if err == nil && num == 0 {
+			dict.words = importIniSensitives(ctx)
+		}
+	}
+
+	patterns := make([]string, len(dict.words))
+	for i, word := range dict.words {
+		patterns[i] = word.Word
+	}
+	dict.matcher = util.NewACMatcher(patterns)
+
+	logger.Infoln("sensitive words loaded, version:", version, "num:", len(patterns))
+	return dict
+}
+
+// importIniSensitives 把配置文件中的敏感词导入库中。content 中的词和之前一样冻结账号；
+// title 中的词之前只检查标题、且按字匹配（不要求连续），导入后会检查标题和内容，
+// 为避免误冻结，改为送审，导入的词记录在日志中，站长可以在后台调整
+func importIniSensitives(ctx context.Context) []*model.SensitiveWord {
+	actions := make(map[string]string)
+	iniWords := make([]string, 0)
+	for _, w := range strings.Split(config.ConfigFile.MustValue("sensitive", "content"), ",") {
+		if w = strings.TrimSpace(w); w != "" && actions[w] == "" {
+			actions[w] = model.SensitiveActionFreeze
+			iniWords = append(iniWords, w)
+		}
+	}
+	titleWords := make([]string, 0)
+	for _, w := range strings.Split(config.ConfigFile.MustValue("sensitive", "title"), ",") {
+		if w = strings.TrimSpace(w); w != "" && actions[w] == "" {
+			actions[w] = model.SensitiveActionReview
+			iniWords = append(iniWords, w)
+			titleWords = append(titleWords, w)
+		}
+	}
+
+	words := make([]*model.SensitiveWord, 0, len(iniWords))
+	now := time.Now()
+	for _, w := range iniWords {
+		id, err := db.NextID("sensitive_word")
+		if err != nil {
+			logger.Errorln("import sensitive word NextID error:", err)
+			continue
+		}
+		word := &model.SensitiveWord{
+			Id:        id,
+			Word:      w,
+			Category:  model.SensitiveCategoryAd,
+			Action:    actions[w],
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if _, err = db.GetCollection("sensitive_word").InsertOne(ctx, word); err != nil {
+			logger.Errorln("import sensitive word:", w, "error:", err)
+			continue
+		}
+		words = append(words, word)
+	}
+
+	if len(words) > 0 {
+		logger.Infoln("import", len(words), "sensitive words from config")
+	}
+	if len(titleWords) > 0 {
+		logger.Infoln("import title sensitive words as review:", strings.Join(titleWords, ","))
+	}
+	return words
+}
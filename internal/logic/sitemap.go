@@ -79,7 +79,7 @@ func GenSitemap() {
 
 		filter := bson.M{
 			"_id":    bson.M{"$gte": little, "$lte": large},
-			"status": bson.M{"$ne": model.ArticleStatusOffline},
+			"status": bson.M{"$lt": model.ArticleStatusOffline},
 		}
 		opts := options.Find().SetProjection(bson.M{"_id": 1, "mtime": 1})
 		cursor, findErr := db.GetCollection("articles").Find(ctx, filter, opts)
@@ -119,10 +119,10 @@ func GenSitemap() {
 	for {
 		sitemapFile := "sitemap_topic_" + strconv.Itoa(large) + ".xml"
 
-		filter := bson.M{
+		filter := publicTopicFilter(bson.M{
 			"_id":  bson.M{"$gte": little, "$lte": large},
 			"flag": bson.M{"$in": []int{0, 1}},
-		}
+		})
 		opts := options.Find().SetProjection(bson.M{"_id": 1, "mtime": 1})
 		cursor, findErr := db.GetCollection("topics").Find(ctx, filter, opts)
 		little = large + 1
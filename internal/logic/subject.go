@@ -150,7 +150,7 @@ func (self SubjectLogic) FindArticles(ctx context.Context, sid int, paginator *P
 
 	articles := make([]*model.Article, 0, len(articleList))
 	for _, article := range articleList {
-		if article.Status != model.ArticleStatusOffline {
+		if article.Status < model.ArticleStatusOffline {
 			articles = append(articles, article)
 		}
 	}
@@ -211,6 +211,20 @@ func (self SubjectLogic) findFollowersBySid(sid int) []*model.SubjectFollower {
 	return followers
 }
 
+// followingSids 用户关注的专栏
+func (self SubjectLogic) followingSids(ctx context.Context, uid int) []int {
+	sids := make([]int, 0)
+	followers := make([]*model.SubjectFollower, 0)
+	cursor, err := db.GetCollection("subject_follower").Find(ctx, bson.M{"uid": uid})
+	if err == nil {
+		cursor.All(ctx, &followers)
+	}
+	for _, f := range followers {
+		sids = append(sids, f.Sid)
+	}
+	return sids
+}
+
 // FindFollowerTotal 专栏关注的用户数
 func (self SubjectLogic) FindFollowerTotal(ctx context.Context, sid int) int64 {
 	objLog := GetLogger(ctx)
@@ -328,6 +342,9 @@ func (self SubjectLogic) Contribute(ctx context.Context, me *model.Me, sid, arti
 		return errors.New("投稿失败:" + err.Error())
 	}
 
+	if subjectArticle.State == model.ContributeStateOnline {
+		go DefaultTimeline.addSubject(articleId, sid)
+	}
 	go self.sendMsgForFollower(ctx, subject, sid, articleId)
 
 	return nil
@@ -371,6 +388,8 @@ func (self SubjectLogic) RemoveContribute(ctx context.Context, sid, articleId in
 		return errors.New("删除投稿失败:" + err.Error())
 	}
 
+	go DefaultTimeline.removeSubject(articleId, sid)
+
 	return nil
 }
 
@@ -400,6 +419,11 @@ func (self SubjectLogic) Publish(ctx context.Context, me *model.Me, form url.Val
 		}
 
 	} else {
+		err = CheckPublish(ctx, NewPublishAction(ctx, me, PublishKindCreate, objtypeNone, form))
+		if err != nil {
+			return
+		}
+
 		subject := &model.Subject{}
 		err = schemaDecoder.Decode(subject, form)
 		if err != nil {
@@ -430,6 +454,12 @@ func (self SubjectLogic) Publish(ctx context.Context, me *model.Me, form url.Val
 func (SubjectLogic) Modify(ctx context.Context, user *model.Me, form url.Values) (errMsg string, err error) {
 	objLog := GetLogger(ctx)
 
+	err = CheckPublish(ctx, NewPublishAction(ctx, user, PublishKindModify, objtypeNone, form))
+	if err != nil {
+		errMsg = err.Error()
+		return
+	}
+
 	change := map[string]interface{}{}
 
 	fields := []string{"name", "description", "cover", "contribute", "audit"}
@@ -0,0 +1,764 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+	"github.com/studygolang/studygolang/util"
+
+	"github.com/polaris1119/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// 标签最长的字数
+	tagMaxLen = 30
+	// 每个内容最多的标签数
+	tagMaxNum = 8
+	// 重建时每批处理的内容数
+	tagRebuildBatch = 200
+)
+
+type TagLogic struct{}
+
+var DefaultTag = TagLogic{}
+
+// FindAll 热门标签，按内容数倒序
+func (TagLogic) FindAll(ctx context.Context, paginator *Paginator) []*model.Tag {
+	objLog := GetLogger(ctx)
+
+	total, err := db.GetCollection("tags").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		objLog.Errorln("TagLogic FindAll count error:", err)
+		return nil
+	}
+	paginator.SetTotal(total)
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "total", Value: -1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(paginator.Offset())).
+		SetLimit(int64(paginator.PerPage()))
+	return findTags(ctx, bson.M{}, opts)
+}
+
+// Autocomplete 按前缀（名称或同义词）补全标签，内容多的在前
+func (TagLogic) Autocomplete(ctx context.Context, prefix string, limit int) []*model.Tag {
+	filter := bson.M{}
+	if prefix = strings.ToLower(strings.TrimSpace(prefix)); prefix != "" {
+		filter["keys"] = bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "total", Value: -1}}).SetLimit(int64(limit))
+	return findTags(ctx, filter, opts)
+}
+
+// FindByName 按名称或同义词（不区分大小写）查找标签，没有时返回 nil
+func (TagLogic) FindByName(ctx context.Context, name string) *model.Tag {
+	tag := &model.Tag{}
+	err := db.GetCollection("tags").FindOne(ctx, bson.M{"keys": strings.ToLower(strings.TrimSpace(name))}).Decode(tag)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			GetLogger(ctx).Errorln("TagLogic FindByName error:", err)
+		}
+		return nil
+	}
+	return tag
+}
+
+func (TagLogic) findById(ctx context.Context, id int) *model.Tag {
+	tag := &model.Tag{}
+	err := db.GetCollection("tags").FindOne(ctx, bson.M{"_id": id}).Decode(tag)
+	if err != nil {
+		return nil
+	}
+	return tag
+}
+
+// FindObjects 标签下的内容，objtype 小于 0 表示所有类型，新发布的在前。不可见的内容不返回，并解除关联
+func (self TagLogic) FindObjects(ctx context.Context, tag *model.Tag, objtype int, paginator *Paginator) []map[string]interface{} {
+	objLog := GetLogger(ctx)
+
+	filter := bson.M{"tag_id": tag.Id}
+	if objtype >= 0 {
+		filter["objtype"] = objtype
+	}
+
+	coll := db.GetCollection("tag_object")
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		objLog.Errorln("TagLogic FindObjects count error:", err)
+		return nil
+	}
+	paginator.SetTotal(total)
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "ctime", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(paginator.Offset())).
+		SetLimit(int64(paginator.PerPage()))
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		objLog.Errorln("TagLogic FindObjects error:", err)
+		return nil
+	}
+	objects := make([]*model.TagObject, 0)
+	err = cursor.All(ctx, &objects)
+	cursor.Close(ctx)
+	if err != nil {
+		objLog.Errorln("TagLogic FindObjects decode error:", err)
+		return nil
+	}
+
+	objids := make(map[int][]int)
+	for _, object := range objects {
+		objids[object.Objtype] = append(objids[object.Objtype], object.Objid)
+	}
+
+	docs := make(map[int]map[int]*objectDoc)
+	for typ, ids := range objids {
+		source := objectSources[typ]
+		if source == nil {
+			continue
+		}
+		docFilter := bson.M{"_id": bson.M{"$in": ids}}
+		for k, v := range source.visible {
+			docFilter[k] = v
+		}
+		docCursor, err := db.GetCollection(source.coll).Find(ctx, docFilter)
+		if err != nil {
+			objLog.Errorln("TagLogic FindObjects find docs error:", err)
+			continue
+		}
+		list := make([]*objectDoc, 0)
+		err = docCursor.All(ctx, &list)
+		docCursor.Close(ctx)
+		if err != nil {
+			objLog.Errorln("TagLogic FindObjects decode docs error:", err)
+			continue
+		}
+		docs[typ] = make(map[int]*objectDoc, len(list))
+		for _, doc := range list {
+			docs[typ][doc.Id] = doc
+		}
+	}
+
+	result := make([]map[string]interface{}, 0, len(objects))
+	removed := 0
+	for _, object := range objects {
+		doc, ok := docs[object.Objtype][object.Objid]
+		if !ok {
+			// 删除、下线、审核隐藏、改为部分可见时没有同步标签，在这里解除关联，让总数和计数准确
+			if _, queried := docs[object.Objtype]; queried {
+				self.link(ctx, object.Objtype, object.Objid, nil, time.Time{})
+				removed++
+			}
+			continue
+		}
+		title, _, ctime := objectSources[object.Objtype].fields(doc)
+		result = append(result, map[string]interface{}{
+			"objtype": object.Objtype,
+			"objid":   object.Objid,
+			"title":   title,
+			"uri":     model.PathUrlMap[object.Objtype] + strconv.Itoa(object.Objid),
+			"tags":    doc.Tags,
+			"ctime":   ctime,
+		})
+	}
+	if removed > 0 {
+		paginator.SetTotal(total - int64(removed))
+	}
+	return result
+}
+
+// Sync 内容发布、修改后同步标签：标签为空的自动生成；同义词转换为规范名称后写回内容；
+// 更新标签和内容的关联及计数。不可见的内容解除关联
+func (self TagLogic) Sync(ctx context.Context, objtype, objid int) {
+	objLog := GetLogger(ctx)
+
+	source, ok := objectSources[objtype]
+	if !ok {
+		return
+	}
+
+	filter := bson.M{"_id": objid}
+	for k, v := range source.visible {
+		filter[k] = v
+	}
+	doc := &objectDoc{}
+	err := db.GetCollection(source.coll).FindOne(ctx, filter).Decode(doc)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			objLog.Errorln("TagLogic Sync find error:", err)
+			return
+		}
+		self.link(ctx, objtype, objid, nil, time.Time{})
+		return
+	}
+
+	title, content, ctime := source.fields(doc)
+	tags := doc.Tags
+	if strings.TrimSpace(tags) == "" {
+		tags = model.AutoTag(title, content, 4)
+	}
+
+	tagIds := make([]int, 0)
+	names := make([]string, 0)
+	for _, name := range splitTags(tags) {
+		tag := self.resolve(ctx, name)
+		if tag == nil || util.InSlice(tag.Id, tagIds) {
+			continue
+		}
+		tagIds = append(tagIds, tag.Id)
+		names = append(names, tag.Name)
+	}
+
+	if tags = strings.Join(names, ","); tags != doc.Tags {
+		_, err = db.GetCollection(source.coll).UpdateOne(ctx, bson.M{"_id": objid}, bson.M{"$set": bson.M{"tags": tags}})
+		if err != nil {
+			objLog.Errorln("TagLogic Sync update tags error:", err)
+		}
+	}
+
+	self.link(ctx, objtype, objid, tagIds, time.Time(ctime))
+}
+
+// resolve 按名称或同义词找到标签，没有时新建
+func (self TagLogic) resolve(ctx context.Context, name string) *model.Tag {
+	if tag := self.FindByName(ctx, name); tag != nil {
+		return tag
+	}
+
+	now := time.Now()
+	tag := &model.Tag{
+		Name:      name,
+		Aliases:   []string{},
+		Keys:      []string{strings.ToLower(name)},
+		Counts:    map[string]int{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	var err error
+	tag.Id, err = db.NextID("tags")
+	if err != nil {
+		GetLogger(ctx).Errorln("TagLogic resolve NextID error:", err)
+		return nil
+	}
+	if _, err = db.GetCollection("tags").InsertOne(ctx, tag); err != nil {
+		// 并发新建同名标签
+		if mongo.IsDuplicateKeyError(err) {
+			return self.FindByName(ctx, name)
+		}
+		GetLogger(ctx).Errorln("TagLogic resolve insert error:", err)
+		return nil
+	}
+	return tag
+}
+
+// link 让内容关联的标签正好是 tagIds，并更新标签的计数
+func (self TagLogic) link(ctx context.Context, objtype, objid int, tagIds []int, ctime time.Time) {
+	objLog := GetLogger(ctx)
+	coll := db.GetCollection("tag_object")
+
+	cursor, err := coll.Find(ctx, bson.M{"objtype": objtype, "objid": objid})
+	if err != nil {
+		objLog.Errorln("TagLogic link find error:", err)
+		return
+	}
+	objects := make([]*model.TagObject, 0)
+	err = cursor.All(ctx, &objects)
+	cursor.Close(ctx)
+	if err != nil {
+		objLog.Errorln("TagLogic link decode error:", err)
+		return
+	}
+
+	linked := make(map[int]bool, len(objects))
+	for _, object := range objects {
+		if util.InSlice(object.TagId, tagIds) {
+			linked[object.TagId] = true
+			continue
+		}
+		result, err := coll.DeleteOne(ctx, bson.M{"_id": object.Id})
+		if err != nil {
+			objLog.Errorln("TagLogic link delete error:", err)
+			continue
+		}
+		if result.DeletedCount > 0 {
+			self.incrCount(ctx, object.TagId, objtype, -1)
+		}
+	}
+
+	for _, tagId := range tagIds {
+		if linked[tagId] {
+			continue
+		}
+		object := &model.TagObject{TagId: tagId, Objtype: objtype, Objid: objid, Ctime: ctime}
+		if object.Id, err = db.NextID("tag_object"); err != nil {
+			objLog.Errorln("TagLogic link NextID error:", err)
+			return
+		}
+		// (tag_id, objtype, objid) 有唯一索引，并发同步时只计数一次
+		if _, err = coll.InsertOne(ctx, object); err != nil {
+			if !mongo.IsDuplicateKeyError(err) {
+				objLog.Errorln("TagLogic link insert error:", err)
+			}
+			continue
+		}
+		self.incrCount(ctx, tagId, objtype, 1)
+	}
+}
+
+func (TagLogic) incrCount(ctx context.Context, tagId, objtype, num int) {
+	_, err := db.GetCollection("tags").UpdateOne(ctx, bson.M{"_id": tagId},
+		bson.M{"$inc": bson.M{"counts." + strconv.Itoa(objtype): num, "total": num}})
+	if err != nil {
+		GetLogger(ctx).Errorln("TagLogic incrCount error:", err)
+	}
+}
+
+// recount 按关联重新计算标签的内容数，tagIds 为空时计算所有标签
+func (TagLogic) recount(ctx context.Context, tagIds ...int) {
+	match := bson.M{}
+	reset := bson.M{}
+	if len(tagIds) > 0 {
+		match["tag_id"] = bson.M{"$in": tagIds}
+		reset["_id"] = bson.M{"$in": tagIds}
+	}
+
+	cursor, err := db.GetCollection("tag_object").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"tag_id": "$tag_id", "objtype": "$objtype"},
+			"num": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		logger.Errorln("TagLogic recount aggregate error:", err)
+		return
+	}
+	var rows []struct {
+		Id struct {
+			TagId   int `bson:"tag_id"`
+			Objtype int `bson:"objtype"`
+		} `bson:"_id"`
+		Num int `bson:"num"`
+	}
+	err = cursor.All(ctx, &rows)
+	cursor.Close(ctx)
+	if err != nil {
+		logger.Errorln("TagLogic recount decode error:", err)
+		return
+	}
+
+	counts := make(map[int]map[string]int)
+	for _, row := range rows {
+		if counts[row.Id.TagId] == nil {
+			counts[row.Id.TagId] = make(map[string]int)
+		}
+		counts[row.Id.TagId][strconv.Itoa(row.Id.Objtype)] = row.Num
+	}
+
+	coll := db.GetCollection("tags")
+	_, err = coll.UpdateMany(ctx, reset, bson.M{"$set": bson.M{"counts": bson.M{}, "total": 0}})
+	if err != nil {
+		logger.Errorln("TagLogic recount reset error:", err)
+		return
+	}
+	for tagId, count := range counts {
+		total := 0
+		for _, num := range count {
+			total += num
+		}
+		_, err = coll.UpdateOne(ctx, bson.M{"_id": tagId}, bson.M{"$set": bson.M{"counts": count, "total": total}})
+		if err != nil {
+			logger.Errorln("TagLogic recount update error:", err)
+		}
+	}
+}
+
+// Rebuild 为所有内容同步标签并重新计数，用于把已有内容的标签导入 tags
+func (self TagLogic) Rebuild() {
+	ctx := context.Background()
+
+	for _, objtype := range objectTypes {
+		coll := db.GetCollection(objectSources[objtype].coll)
+		lastId := 0
+		for {
+			opts := options.Find().
+				SetProjection(bson.M{"_id": 1}).
+				SetSort(bson.M{"_id": 1}).
+				SetLimit(tagRebuildBatch)
+			cursor, err := coll.Find(ctx, bson.M{"_id": bson.M{"$gt": lastId}}, opts)
+			if err != nil {
+				logger.Errorln("TagLogic Rebuild find error:", err)
+				break
+			}
+			docs := make([]*objectDoc, 0)
+			err = cursor.All(ctx, &docs)
+			cursor.Close(ctx)
+			if err != nil {
+				logger.Errorln("TagLogic Rebuild decode error:", err)
+				break
+			}
+
+			for _, doc := range docs {
+				self.Sync(ctx, objtype, doc.Id)
+				lastId = doc.Id
+			}
+			if len(docs) < tagRebuildBatch {
+				break
+			}
+		}
+	}
+
+	self.recount(ctx)
+	logger.Infoln("TagLogic Rebuild finished")
+}
+
+// Modify 修改标签的介绍和同义词（逗号分隔）
+func (TagLogic) Modify(ctx context.Context, id int, intro, aliases string) error {
+	objLog := GetLogger(ctx)
+
+	tag := DefaultTag.findById(ctx, id)
+	if tag == nil {
+		return errors.New("标签不存在")
+	}
+
+	aliasList := make([]string, 0)
+	keys := []string{strings.ToLower(tag.Name)}
+	for _, alias := range splitTags(aliases) {
+		key := strings.ToLower(alias)
+		if key == keys[0] {
+			continue
+		}
+		aliasList = append(aliasList, alias)
+		keys = append(keys, key)
+	}
+
+	num, err := db.GetCollection("tags").CountDocuments(ctx, bson.M{"_id": bson.M{"$ne": id}, "keys": bson.M{"$in": keys}})
+	if err != nil {
+		objLog.Errorln("TagLogic Modify count error:", err)
+		return errors.New("内部服务错误")
+	}
+	if num > 0 {
+		return errors.New("同义词已经是其他标签，请使用合并")
+	}
+
+	_, err = db.GetCollection("tags").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"intro":      strings.TrimSpace(intro),
+		"aliases":    aliasList,
+		"keys":       keys,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("同义词已经是其他标签，请使用合并")
+		}
+		objLog.Errorln("TagLogic Modify update error:", err)
+		return errors.New("内部服务错误")
+	}
+	return nil
+}
+
+// Rename 重命名标签，原名称作为同义词保留。内容中的标签在后台改写
+func (self TagLogic) Rename(ctx context.Context, id int, name string) error {
+	objLog := GetLogger(ctx)
+
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, ",，") || utf8.RuneCountInString(name) > tagMaxLen {
+		return errors.New("标签名称不正确")
+	}
+
+	tag := self.findById(ctx, id)
+	if tag == nil {
+		return errors.New("标签不存在")
+	}
+	if name == tag.Name {
+		return nil
+	}
+	if other := self.FindByName(ctx, name); other != nil && other.Id != id {
+		return errors.New("已有同名标签，请使用合并")
+	}
+
+	key := strings.ToLower(name)
+	aliases := make([]string, 0, len(tag.Aliases)+1)
+	keys := []string{key}
+	for _, alias := range append(tag.Aliases, tag.Name) {
+		aliasKey := strings.ToLower(alias)
+		if aliasKey == key || inStrings(keys, aliasKey) {
+			continue
+		}
+		aliases = append(aliases, alias)
+		keys = append(keys, aliasKey)
+	}
+
+	_, err := db.GetCollection("tags").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"name":       name,
+		"aliases":    aliases,
+		"keys":       keys,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("已有同名标签，请使用合并")
+		}
+		objLog.Errorln("TagLogic Rename update error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	go self.resync(id)
+	return nil
+}
+
+// Merge 把标签 fromId 合并到 toId：from 的名称和同义词成为 to 的同义词，关注者转移到 to，
+// 内容中的标签在后台改写
+func (self TagLogic) Merge(ctx context.Context, fromId, toId int) error {
+	objLog := GetLogger(ctx)
+
+	if fromId == toId {
+		return errors.New("不能合并到自己")
+	}
+	from, to := self.findById(ctx, fromId), self.findById(ctx, toId)
+	if from == nil || to == nil {
+		return errors.New("标签不存在")
+	}
+
+	objects := self.findObjects(ctx, fromId)
+
+	aliases := to.Aliases
+	keys := to.Keys
+	for _, alias := range append([]string{from.Name}, from.Aliases...) {
+		if key := strings.ToLower(alias); !inStrings(keys, key) {
+			aliases = append(aliases, alias)
+			keys = append(keys, key)
+		}
+	}
+
+	session, err := db.GetClient().StartSession()
+	if err != nil {
+		objLog.Errorln("TagLogic Merge StartSession error:", err)
+		return errors.New("内部服务错误")
+	}
+	defer session.EndSession(ctx)
+
+	// 在同一事务中，to 更新失败时 from 不会被删掉
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// 先删除 from，它的名称和同义词才能加到 to 上（keys 有唯一索引）
+		if _, txErr := db.GetCollection("tags").DeleteOne(sessCtx, bson.M{"_id": fromId}); txErr != nil {
+			return nil, txErr
+		}
+		if _, txErr := db.GetCollection("tag_object").DeleteMany(sessCtx, bson.M{"tag_id": fromId}); txErr != nil {
+			return nil, txErr
+		}
+
+		followers, txErr := self.mergeFollows(sessCtx, fromId, toId)
+		if txErr != nil {
+			return nil, txErr
+		}
+
+		_, txErr = db.GetCollection("tags").UpdateOne(sessCtx, bson.M{"_id": toId}, bson.M{"$set": bson.M{
+			"aliases":    aliases,
+			"keys":       keys,
+			"followers":  followers,
+			"updated_at": time.Now(),
+		}})
+		return nil, txErr
+	})
+	if err != nil {
+		objLog.Errorln("TagLogic Merge transaction error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	go func() {
+		bgCtx := context.Background()
+		for _, object := range objects {
+			self.Sync(bgCtx, object.Objtype, object.Objid)
+		}
+		self.recount(bgCtx, toId)
+	}()
+	return nil
+}
+
+// mergeFollows 把 from 的关注转到 to 上，关注了两个标签的用户只保留一条，返回 to 的关注数。
+// 在事务中执行，不能靠唯一索引冲突来判断重复
+func (TagLogic) mergeFollows(ctx context.Context, fromId, toId int) (int64, error) {
+	coll := db.GetCollection("tag_follow")
+
+	uids, err := coll.Distinct(ctx, "uid", bson.M{"tag_id": toId})
+	if err != nil {
+		return 0, err
+	}
+	if len(uids) > 0 {
+		if _, err = coll.DeleteMany(ctx, bson.M{"tag_id": fromId, "uid": bson.M{"$in": uids}}); err != nil {
+			return 0, err
+		}
+	}
+	if _, err = coll.UpdateMany(ctx, bson.M{"tag_id": fromId}, bson.M{"$set": bson.M{"tag_id": toId}}); err != nil {
+		return 0, err
+	}
+
+	return coll.CountDocuments(ctx, bson.M{"tag_id": toId})
+}
+
+// resync 重新同步标签下的所有内容，改写内容中的标签
+func (self TagLogic) resync(tagId int) {
+	ctx := context.Background()
+	for _, object := range self.findObjects(ctx, tagId) {
+		self.Sync(ctx, object.Objtype, object.Objid)
+	}
+}
+
+func (TagLogic) findObjects(ctx context.Context, tagId int) []*model.TagObject {
+	objects := make([]*model.TagObject, 0)
+	cursor, err := db.GetCollection("tag_object").Find(ctx, bson.M{"tag_id": tagId},
+		options.Find().SetProjection(bson.M{"objtype": 1, "objid": 1}))
+	if err != nil {
+		GetLogger(ctx).Errorln("TagLogic findObjects error:", err)
+		return objects
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &objects); err != nil {
+		GetLogger(ctx).Errorln("TagLogic findObjects decode error:", err)
+	}
+	return objects
+}
+
+// Follow 关注标签
+func (TagLogic) Follow(ctx context.Context, uid int, name string) error {
+	objLog := GetLogger(ctx)
+
+	tag := DefaultTag.FindByName(ctx, name)
+	if tag == nil {
+		return errors.New("标签不存在")
+	}
+
+	follow := &model.TagFollow{Uid: uid, TagId: tag.Id, CreatedAt: time.Now()}
+	var err error
+	if follow.Id, err = db.NextID("tag_follow"); err != nil {
+		objLog.Errorln("TagLogic Follow NextID error:", err)
+		return errors.New("内部服务错误")
+	}
+	if _, err = db.GetCollection("tag_follow").InsertOne(ctx, follow); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("已经关注了该标签")
+		}
+		objLog.Errorln("TagLogic Follow insert error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	db.GetCollection("tags").UpdateOne(ctx, bson.M{"_id": tag.Id}, bson.M{"$inc": bson.M{"followers": 1}})
+	return nil
+}
+
+// Unfollow 取消关注标签
+func (TagLogic) Unfollow(ctx context.Context, uid int, name string) error {
+	tag := DefaultTag.FindByName(ctx, name)
+	if tag == nil {
+		return errors.New("标签不存在")
+	}
+
+	result, err := db.GetCollection("tag_follow").DeleteOne(ctx, bson.M{"uid": uid, "tag_id": tag.Id})
+	if err != nil {
+		GetLogger(ctx).Errorln("TagLogic Unfollow error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.DeletedCount > 0 {
+		db.GetCollection("tags").UpdateOne(ctx, bson.M{"_id": tag.Id}, bson.M{"$inc": bson.M{"followers": -1}})
+	}
+	return nil
+}
+
+// HadFollow 是否关注了标签
+func (TagLogic) HadFollow(ctx context.Context, uid, tagId int) bool {
+	if uid == 0 {
+		return false
+	}
+	num, err := db.GetCollection("tag_follow").CountDocuments(ctx, bson.M{"uid": uid, "tag_id": tagId})
+	return err == nil && num > 0
+}
+
+// FindFollowed 用户关注的标签
+func (TagLogic) FindFollowed(ctx context.Context, uid int) []*model.Tag {
+	cursor, err := db.GetCollection("tag_follow").Find(ctx, bson.M{"uid": uid})
+	if err != nil {
+		GetLogger(ctx).Errorln("TagLogic FindFollowed error:", err)
+		return nil
+	}
+	follows := make([]*model.TagFollow, 0)
+	err = cursor.All(ctx, &follows)
+	cursor.Close(ctx)
+	if err != nil {
+		GetLogger(ctx).Errorln("TagLogic FindFollowed decode error:", err)
+		return nil
+	}
+
+	tagIds := make([]int, len(follows))
+	for i, follow := range follows {
+		tagIds[i] = follow.TagId
+	}
+	return findTags(ctx, bson.M{"_id": bson.M{"$in": tagIds}}, options.Find().SetSort(bson.M{"name": 1}))
+}
+
+func findTags(ctx context.Context, filter bson.M, opts *options.FindOptions) []*model.Tag {
+	tags := make([]*model.Tag, 0)
+	cursor, err := db.GetCollection("tags").Find(ctx, filter, opts)
+	if err != nil {
+		GetLogger(ctx).Errorln("findTags error:", err)
+		return tags
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &tags); err != nil {
+		GetLogger(ctx).Errorln("findTags decode error:", err)
+	}
+	return tags
+}
+
+// splitTags 拆分逗号分隔的标签，去掉空白、过长的和重复的（不区分大小写），最多 tagMaxNum 个
+func splitTags(tags string) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(strings.ReplaceAll(tags, "，", ","), ",") {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] || utf8.RuneCountInString(name) > tagMaxLen {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+		if len(names) == tagMaxNum {
+			break
+		}
+	}
+	return names
+}
+
+func inStrings(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// TagObserver 内容发布、修改后同步标签
+type TagObserver struct{}
+
+func (this *TagObserver) Update(action string, uid, objtype, objid int) {
+	if objid == 0 || (action != actionPublish && action != actionModify) {
+		return
+	}
+	DefaultTag.Sync(context.Background(), objtype, objid)
+}
@@ -0,0 +1,40 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSplitTags(t *testing.T) {
+	many := make([]string, tagMaxNum+2)
+	for i := range many {
+		many[i] = "t" + strconv.Itoa(i)
+	}
+
+	tests := []struct {
+		tags string
+		want []string
+	}{
+		{"", []string{}},
+		{" , ,", []string{}},
+		{"go, mongodb ,redis", []string{"go", "mongodb", "redis"}},
+		// 中文逗号也可以分隔
+		{"go，并发", []string{"go", "并发"}},
+		// 不区分大小写去重，保留第一次出现的写法
+		{"Go,go,GO,redis", []string{"Go", "redis"}},
+		{"go," + strings.Repeat("长", tagMaxLen+1) + ",redis", []string{"go", "redis"}},
+		{strings.Join(many, ","), many[:tagMaxNum]},
+	}
+	for _, tt := range tests {
+		if got := splitTags(tt.tags); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitTags(%q) = %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+}
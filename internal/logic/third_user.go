@@ -7,321 +7,254 @@
 package logic
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"io/ioutil"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/studygolang/studygolang/db"
 	"github.com/studygolang/studygolang/internal/model"
 
 	"github.com/polaris1119/logger"
-
-	"github.com/polaris1119/config"
+	"github.com/polaris1119/nosql"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
 )
 
-var githubConf *oauth2.Config
-var giteaConf *oauth2.Config
-
 const GithubAPIBaseUrl = "https://api.github.com"
 const GiteaAPIBaseUrl = "https://gitea.com/api/v1"
 
-func init() {
-	githubConf = &oauth2.Config{
-		ClientID:     config.ConfigFile.MustValue("github", "client_id"),
-		ClientSecret: config.ConfigFile.MustValue("github", "client_secret"),
-		Scopes:       []string{"user:email"},
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  "https://github.com/login/oauth/authorize",
-			TokenURL: "https://github.com/login/oauth/access_token",
-		},
-	}
-
-	giteaConf = &oauth2.Config{
-		ClientID:     config.ConfigFile.MustValue("gitea", "client_id"),
-		ClientSecret: config.ConfigFile.MustValue("gitea", "client_secret"),
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  "https://gitea.com/login/oauth/authorize",
-			TokenURL: "https://gitea.com/login/oauth/access_token",
-		},
-	}
+// 第三方账号没有公开邮箱时，等待用户填写邮箱的注册信息保存在 redis 中。
+// 填写邮箱后发送确认邮件，确认前的有效期和激活邮件一样是 4 小时
+const (
+	oauthSignupKeyPrev   = "oauth:signup:"
+	oauthSignupExpire    = 1800
+	oauthSignupEmailTime = 4 * 3600
+)
+
+// ErrOAuthNeedEmail 第三方账号没有公开邮箱，需要填写邮箱才能完成注册
+var ErrOAuthNeedEmail = errors.New("请填写邮箱完成注册")
+
+type oauthSignup struct {
+	Provider string
+	UserInfo *OAuthUserInfo
+	Token    *oauth2.Token
+	// 用户填写、等待确认的邮箱和确认码
+	Email string
+	Code  string
 }
 
 type ThirdUserLogic struct{}
 
 var DefaultThirdUser = ThirdUserLogic{}
 
-func (ThirdUserLogic) GithubAuthCodeUrl(ctx context.Context, redirectURL string) string {
-	githubConf.RedirectURL = redirectURL
-	return githubConf.AuthCodeURL("state", oauth2.AccessTypeOffline)
+// AuthCodeUrl 跳转到第三方授权页面的地址
+func (ThirdUserLogic) AuthCodeUrl(ctx context.Context, providerName, redirectURL, state string) (string, error) {
+	provider, err := FindOAuthProvider(providerName)
+	if err != nil {
+		return "", err
+	}
+	return provider.AuthCodeURL(ctx, redirectURL, state)
 }
 
-func (self ThirdUserLogic) LoginFromGithub(ctx context.Context, code string) (*model.User, error) {
+// LoginFromOAuth 第三方登录：已绑定的直接登录，否则用第三方的信息注册新用户。
+// 第三方账号没有公开邮箱时返回 ErrOAuthNeedEmail 和凭证，用户填写邮箱后由 CompleteOAuthSignup 完成注册
+func (self ThirdUserLogic) LoginFromOAuth(ctx context.Context, providerName, redirectURL, code string) (*model.User, string, error) {
 	objLog := GetLogger(ctx)
 
-	githubUser, token, err := self.githubTokenAndUser(ctx, code)
+	provider, err := FindOAuthProvider(providerName)
 	if err != nil {
-		objLog.Errorln("LoginFromGithub githubTokenAndUser error:", err)
-		return nil, err
+		return nil, "", err
 	}
 
-	bindUser := &model.BindUser{}
-	err = db.GetCollection("bind_user").FindOne(ctx, bson.M{"username": githubUser.Login, "type": model.BindTypeGithub}).Decode(bindUser)
-	if err != nil && err != mongo.ErrNoDocuments {
-		objLog.Errorln("LoginFromGithub Get BindUser error:", err)
-		return nil, err
+	userInfo, token, err := provider.Exchange(ctx, redirectURL, code)
+	if err != nil {
+		objLog.Errorln("LoginFromOAuth", providerName, "exchange error:", err)
+		return nil, "", err
+	}
+
+	bindUser, err := self.findBindUser(ctx, provider, userInfo)
+	if err != nil {
+		objLog.Errorln("LoginFromOAuth", providerName, "Get BindUser error:", err)
+		return nil, "", err
 	}
 
 	if bindUser.Uid > 0 {
-		change := bson.M{
-			"access_token":  token.AccessToken,
-			"refresh_token": token.RefreshToken,
-		}
-		if !token.Expiry.IsZero() {
-			change["expire"] = int(token.Expiry.Unix())
-		}
-		_, err = db.GetCollection("bind_user").UpdateOne(ctx, bson.M{"uid": bindUser.Uid}, bson.M{"$set": change})
+		err = self.updateBindUser(ctx, bindUser.Id, provider, userInfo, token)
 		if err != nil {
-			objLog.Errorln("LoginFromGithub update token error:", err)
-			return nil, err
+			objLog.Errorln("LoginFromOAuth", providerName, "update token error:", err)
+			return nil, "", err
 		}
 
 		user := DefaultUser.FindOne(ctx, "uid", bindUser.Uid)
-		return user, nil
+		return user, "", nil
 	}
 
-	exists := DefaultUser.EmailOrUsernameExists(ctx, githubUser.Email, githubUser.Login)
-	if exists {
-		objLog.Errorln("LoginFromGithub Github 对应的用户信息被占用")
-		return nil, errors.New("Github 对应的用户信息被占用，可能你注册过本站，用户名密码登录试试！")
-	}
-
-	session, sessErr := db.GetClient().StartSession()
-	if sessErr != nil {
-		objLog.Errorln("LoginFromGithub StartSession error:", sessErr)
-		return nil, sessErr
-	}
-	defer session.EndSession(ctx)
-
-	if githubUser.Email == "" {
-		githubUser.Email = githubUser.Login + "@github.com"
-	}
-	user := &model.User{
-		Email:    githubUser.Email,
-		Username: githubUser.Login,
-		Name:     githubUser.Name,
-		City:     githubUser.Location,
-		Company:  githubUser.Company,
-		Github:   githubUser.Login,
-		Website:  githubUser.Blog,
-		Avatar:   githubUser.AvatarUrl,
-		IsThird:  1,
-		Status:   model.UserStatusAudit,
-	}
-
-	var retUser *model.User
-	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
-		txErr := DefaultUser.doCreateUser(sc, user)
-		if txErr != nil {
-			return nil, txErr
+	if userInfo.Email == "" {
+		if DefaultUser.UserExists(ctx, "username", userInfo.Username) {
+			objLog.Errorln("LoginFromOAuth", providerName, "对应的用户信息被占用")
+			return nil, "", errors.New(provider.DisplayName + " 对应的用户信息被占用，可能你注册过本站，用户名密码登录试试！")
 		}
 
-		bindUser = &model.BindUser{
-			Uid:          user.Uid,
-			Type:         model.BindTypeGithub,
-			Email:        user.Email,
-			Tuid:         githubUser.Id,
-			Username:     githubUser.Login,
-			Name:         githubUser.Name,
-			AccessToken:  token.AccessToken,
-			RefreshToken: token.RefreshToken,
-			Avatar:       githubUser.AvatarUrl,
-		}
-		if !token.Expiry.IsZero() {
-			bindUser.Expire = int(token.Expiry.Unix())
-		}
-		var id int
-		id, txErr = db.NextID("bind_user")
-		if txErr != nil {
-			return nil, txErr
-		}
-		bindUser.Id = id
-		_, txErr = db.GetCollection("bind_user").InsertOne(sc, bindUser)
-		if txErr != nil {
-			return nil, txErr
+		ticket, err := self.saveSignup(provider, userInfo, token)
+		if err != nil {
+			objLog.Errorln("LoginFromOAuth", providerName, "save signup error:", err)
+			return nil, "", err
 		}
-
-		retUser = user
-		return nil, nil
-	})
-
-	if err != nil {
-		objLog.Errorln("LoginFromGithub transaction error:", err)
-		return nil, err
+		return nil, ticket, ErrOAuthNeedEmail
 	}
 
-	return retUser, nil
+	user, err := self.createUser(ctx, provider, userInfo, token)
+	return user, "", err
 }
 
-func (self ThirdUserLogic) BindGithub(ctx context.Context, code string, me *model.Me) error {
-	objLog := GetLogger(ctx)
+// CompleteOAuthSignup 第三方账号没有公开邮箱时，用户填写邮箱后发送确认邮件，
+// 点击邮件中的链接由 ConfirmOAuthSignup 完成注册，未确认的邮箱不会写入账号
+func (self ThirdUserLogic) CompleteOAuthSignup(ctx context.Context, ticket, email string, isHttps bool) error {
+	email = strings.TrimSpace(email)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return errors.New("邮箱格式不正确")
+	}
+	if DefaultUser.UserExists(ctx, "email", email) {
+		return errors.New("该邮箱已注册，可以用邮箱登录后在账号设置中绑定")
+	}
 
-	githubUser, token, err := self.githubTokenAndUser(ctx, code)
+	signup, err := self.findSignup(ticket)
 	if err != nil {
-		objLog.Errorln("LoginFromGithub githubTokenAndUser error:", err)
 		return err
 	}
 
-	bindUser := &model.BindUser{}
-	err = db.GetCollection("bind_user").FindOne(ctx, bson.M{"username": githubUser.Login, "type": model.BindTypeGithub}).Decode(bindUser)
-	if err != nil && err != mongo.ErrNoDocuments {
-		objLog.Errorln("LoginFromGithub Get BindUser error:", err)
-		return err
+	buf := make([]byte, 16)
+	if _, err = rand.Read(buf); err != nil {
+		GetLogger(ctx).Errorln("CompleteOAuthSignup gen code error:", err)
+		return errors.New("内部服务错误")
 	}
+	signup.Email = email
+	signup.Code = hex.EncodeToString(buf)
 
-	if bindUser.Uid > 0 {
-		bindUser.AccessToken = token.AccessToken
-		bindUser.RefreshToken = token.RefreshToken
-		if !token.Expiry.IsZero() {
-			bindUser.Expire = int(token.Expiry.Unix())
-		}
-		_, err = db.GetCollection("bind_user").UpdateOne(ctx, bson.M{"uid": bindUser.Uid}, bson.M{"$set": bindUser})
-		if err != nil {
-			objLog.Errorln("LoginFromGithub update token error:", err)
-			return err
-		}
-
-		return nil
+	data, err := json.Marshal(signup)
+	if err != nil {
+		return err
+	}
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
+	if err = redis.SET(oauthSignupKeyPrev+ticket, string(data), oauthSignupEmailTime); err != nil {
+		GetLogger(ctx).Errorln("CompleteOAuthSignup save signup error:", err)
+		return errors.New("内部服务错误")
 	}
 
-	bindUser = &model.BindUser{
-		Uid:          me.Uid,
-		Type:         model.BindTypeGithub,
-		Email:        githubUser.Email,
-		Tuid:         githubUser.Id,
-		Username:     githubUser.Login,
-		Name:         githubUser.Name,
-		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
-		Avatar:       githubUser.AvatarUrl,
+	go DefaultEmail.SendOAuthSignupMail(email, ticket, signup.Code, isHttps)
+	return nil
+}
+
+// ConfirmOAuthSignup 点击确认邮件中的链接，用确认过的邮箱完成注册
+func (self ThirdUserLogic) ConfirmOAuthSignup(ctx context.Context, ticket, code string) (*model.User, error) {
+	signup, err := self.findSignup(ticket)
+	if err != nil {
+		return nil, err
 	}
-	if !token.Expiry.IsZero() {
-		bindUser.Expire = int(token.Expiry.Unix())
+	if signup.Email == "" || subtle.ConstantTimeCompare([]byte(signup.Code), []byte(code)) != 1 {
+		return nil, errors.New("链接无效或已过期，请重新登录")
 	}
-	id, idErr := db.NextID("bind_user")
-	if idErr != nil {
-		objLog.Errorln("LoginFromGithub NextID error:", idErr)
-		return idErr
+
+	provider, err := FindOAuthProvider(signup.Provider)
+	if err != nil {
+		return nil, err
 	}
-	bindUser.Id = id
-	_, err = db.GetCollection("bind_user").InsertOne(ctx, bindUser)
+
+	signup.UserInfo.Email = signup.Email
+	user, err := self.createUser(ctx, provider, signup.UserInfo, signup.Token)
 	if err != nil {
-		objLog.Errorln("LoginFromGithub insert bindUser error:", err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
+	redis.DEL(oauthSignupKeyPrev + ticket)
+	return user, nil
 }
 
-func (ThirdUserLogic) GiteaAuthCodeUrl(ctx context.Context, redirectURL string) string {
-	giteaConf.RedirectURL = redirectURL
-	return giteaConf.AuthCodeURL("state", oauth2.AccessTypeOffline)
+// findSignup 获取等待填写邮箱的第三方注册信息
+func (ThirdUserLogic) findSignup(ticket string) (*oauthSignup, error) {
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
+
+	signup := &oauthSignup{}
+	if ticket == "" || json.Unmarshal([]byte(redis.GET(oauthSignupKeyPrev+ticket)), signup) != nil || signup.UserInfo == nil {
+		return nil, errors.New("注册已超时，请重新登录")
+	}
+	return signup, nil
 }
 
-func (self ThirdUserLogic) LoginFromGitea(ctx context.Context, code string) (*model.User, error) {
-	objLog := GetLogger(ctx)
+// saveSignup 保存等待填写邮箱的第三方注册信息，返回凭证
+func (ThirdUserLogic) saveSignup(provider *OAuthProvider, userInfo *OAuthUserInfo, token *oauth2.Token) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	ticket := hex.EncodeToString(buf)
 
-	giteaUser, token, err := self.giteaTokenAndUser(ctx, code)
+	data, err := json.Marshal(&oauthSignup{Provider: provider.Name, UserInfo: userInfo, Token: token})
 	if err != nil {
-		objLog.Errorln("LoginFromGithub githubTokenAndUser error:", err)
-		return nil, err
+		return "", err
 	}
 
-	bindUser := &model.BindUser{}
-	err = db.GetCollection("bind_user").FindOne(ctx, bson.M{"username": giteaUser.UserName, "type": model.BindTypeGitea}).Decode(bindUser)
-	if err != nil && err != mongo.ErrNoDocuments {
-		objLog.Errorln("LoginFromGithub Get BindUser error:", err)
-		return nil, err
-	}
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
 
-	if bindUser.Uid > 0 {
-		change := bson.M{
-			"access_token":  token.AccessToken,
-			"refresh_token": token.RefreshToken,
-		}
-		if !token.Expiry.IsZero() {
-			change["expire"] = int(token.Expiry.Unix())
-		}
-		_, err = db.GetCollection("bind_user").UpdateOne(ctx, bson.M{"uid": bindUser.Uid}, bson.M{"$set": change})
-		if err != nil {
-			objLog.Errorln("LoginFromGithub update token error:", err)
-			return nil, err
-		}
+	return ticket, redis.SET(oauthSignupKeyPrev+ticket, string(data), oauthSignupExpire)
+}
 
-		user := DefaultUser.FindOne(ctx, "uid", bindUser.Uid)
-		return user, nil
-	}
+// createUser 用第三方的信息注册新用户并绑定
+func (self ThirdUserLogic) createUser(ctx context.Context, provider *OAuthProvider, userInfo *OAuthUserInfo, token *oauth2.Token) (*model.User, error) {
+	objLog := GetLogger(ctx)
 
-	exists := DefaultUser.EmailOrUsernameExists(ctx, giteaUser.Email, giteaUser.UserName)
+	exists := DefaultUser.EmailOrUsernameExists(ctx, userInfo.Email, userInfo.Username)
 	if exists {
-		objLog.Errorln("LoginFromGitea Gitea 对应的用户信息被占用")
-		return nil, errors.New("Gitea 对应的用户信息被占用，可能你注册过本站，用户名密码登录试试！")
+		objLog.Errorln("LoginFromOAuth", provider.Name, "对应的用户信息被占用")
+		return nil, errors.New(provider.DisplayName + " 对应的用户信息被占用，可能你注册过本站，用户名密码登录试试！")
 	}
 
 	session, sessErr := db.GetClient().StartSession()
 	if sessErr != nil {
-		objLog.Errorln("LoginFromGitea StartSession error:", sessErr)
+		objLog.Errorln("LoginFromOAuth StartSession error:", sessErr)
 		return nil, sessErr
 	}
 	defer session.EndSession(ctx)
 
-	if giteaUser.Email == "" {
-		giteaUser.Email = giteaUser.UserName + "@gitea.com"
-	}
 	user := &model.User{
-		Email:    giteaUser.Email,
-		Username: giteaUser.UserName,
-		Name:     model.DisplayName(giteaUser),
-		City:     "",
-		Company:  "",
-		Gitea:    giteaUser.UserName,
-		Website:  "",
-		Avatar:   giteaUser.AvatarURL,
+		Email:    userInfo.Email,
+		Username: userInfo.Username,
+		Name:     userInfo.Name,
+		City:     userInfo.Location,
+		Company:  userInfo.Company,
+		Website:  userInfo.Website,
+		Avatar:   userInfo.Avatar,
 		IsThird:  1,
 		Status:   model.UserStatusAudit,
 	}
+	switch provider.bindType {
+	case model.BindTypeGithub:
+		user.Github = userInfo.Username
+	case model.BindTypeGitea:
+		user.Gitea = userInfo.Username
+	}
 
 	var retUser *model.User
-	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+	_, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
 		txErr := DefaultUser.doCreateUser(sc, user)
 		if txErr != nil {
 			return nil, txErr
 		}
 
-		bindUser = &model.BindUser{
-			Uid:          user.Uid,
-			Type:         model.BindTypeGithub,
-			Email:        user.Email,
-			Tuid:         int(giteaUser.ID),
-			Username:     giteaUser.UserName,
-			Name:         model.DisplayName(giteaUser),
-			AccessToken:  token.AccessToken,
-			RefreshToken: token.RefreshToken,
-			Avatar:       giteaUser.AvatarURL,
-		}
-		if !token.Expiry.IsZero() {
-			bindUser.Expire = int(token.Expiry.Unix())
-		}
-		var id int
-		id, txErr = db.NextID("bind_user")
-		if txErr != nil {
-			return nil, txErr
-		}
-		bindUser.Id = id
-		_, txErr = db.GetCollection("bind_user").InsertOne(sc, bindUser)
+		txErr = self.insertBindUser(sc, user.Uid, provider, userInfo, token)
 		if txErr != nil {
 			return nil, txErr
 		}
@@ -331,67 +264,50 @@ func (self ThirdUserLogic) LoginFromGitea(ctx context.Context, code string) (*mo
 	})
 
 	if err != nil {
-		objLog.Errorln("LoginFromGitea transaction error:", err)
+		objLog.Errorln("LoginFromOAuth transaction error:", err)
 		return nil, err
 	}
 
 	return retUser, nil
 }
 
-func (self ThirdUserLogic) BindGitea(ctx context.Context, code string, me *model.Me) error {
+// BindOAuth 已登录用户绑定第三方账号
+func (self ThirdUserLogic) BindOAuth(ctx context.Context, providerName, redirectURL, code string, me *model.Me) error {
 	objLog := GetLogger(ctx)
 
-	giteaUser, token, err := self.giteaTokenAndUser(ctx, code)
+	provider, err := FindOAuthProvider(providerName)
 	if err != nil {
-		objLog.Errorln("LoginFromGitea githubTokenAndUser error:", err)
 		return err
 	}
 
-	bindUser := &model.BindUser{}
-	err = db.GetCollection("bind_user").FindOne(ctx, bson.M{"username": giteaUser.UserName, "type": model.BindTypeGitea}).Decode(bindUser)
-	if err != nil && err != mongo.ErrNoDocuments {
-		objLog.Errorln("LoginFromGitea Get BindUser error:", err)
+	userInfo, token, err := provider.Exchange(ctx, redirectURL, code)
+	if err != nil {
+		objLog.Errorln("BindOAuth", providerName, "exchange error:", err)
+		return err
+	}
+
+	bindUser, err := self.findBindUser(ctx, provider, userInfo)
+	if err != nil {
+		objLog.Errorln("BindOAuth", providerName, "Get BindUser error:", err)
 		return err
 	}
 
 	if bindUser.Uid > 0 {
-		bindUser.AccessToken = token.AccessToken
-		bindUser.RefreshToken = token.RefreshToken
-		if !token.Expiry.IsZero() {
-			bindUser.Expire = int(token.Expiry.Unix())
+		if bindUser.Uid != me.Uid {
+			return errors.New("该 " + provider.DisplayName + " 账号已经绑定了其他用户")
 		}
-		_, err = db.GetCollection("bind_user").UpdateOne(ctx, bson.M{"uid": bindUser.Uid}, bson.M{"$set": bindUser})
+
+		err = self.updateBindUser(ctx, bindUser.Id, provider, userInfo, token)
 		if err != nil {
-			objLog.Errorln("LoginFromGitea update token error:", err)
+			objLog.Errorln("BindOAuth", providerName, "update token error:", err)
 			return err
 		}
-
 		return nil
 	}
 
-	bindUser = &model.BindUser{
-		Uid:          me.Uid,
-		Type:         model.BindTypeGithub,
-		Email:        giteaUser.Email,
-		Tuid:         int(giteaUser.ID),
-		Username:     giteaUser.UserName,
-		Name:         model.DisplayName(giteaUser),
-		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
-		Avatar:       giteaUser.AvatarURL,
-	}
-	if !token.Expiry.IsZero() {
-		bindUser.Expire = int(token.Expiry.Unix())
-	}
-	id, idErr := db.NextID("bind_user")
-	if idErr != nil {
-		objLog.Errorln("LoginFromGitea NextID error:", idErr)
-		return idErr
-	}
-	bindUser.Id = id
-	_, err = db.GetCollection("bind_user").InsertOne(ctx, bindUser)
+	err = self.insertBindUser(ctx, me.Uid, provider, userInfo, token)
 	if err != nil {
-		objLog.Errorln("LoginFromGitea insert bindUser error:", err)
+		objLog.Errorln("BindOAuth", providerName, "insert bindUser error:", err)
 		return err
 	}
 
@@ -406,6 +322,24 @@ func (ThirdUserLogic) UnBindUser(ctx context.Context, bindId interface{}, me *mo
 	return err
 }
 
+// ImportAvatar 使用绑定的第三方账号头像作为本站头像
+func (ThirdUserLogic) ImportAvatar(ctx context.Context, bindId int, me *model.Me) error {
+	bindUser := &model.BindUser{}
+	err := db.GetCollection("bind_user").FindOne(ctx, bson.M{"_id": bindId, "uid": me.Uid}).Decode(bindUser)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return NotFoundErr
+		}
+		GetLogger(ctx).Errorln("ThirdUserLogic ImportAvatar find bind user error:", err)
+		return err
+	}
+
+	if bindUser.Avatar == "" {
+		return errors.New("该账号没有头像")
+	}
+	return DefaultUser.ChangeAvatar(ctx, me.Uid, bindUser.Avatar)
+}
+
 func (ThirdUserLogic) findUid(thirdUsername string, typ int) int {
 	bindUser := &model.BindUser{}
 	err := db.GetCollection("bind_user").FindOne(context.Background(), bson.M{"username": thirdUsername, "type": typ}).Decode(bindUser)
@@ -416,64 +350,67 @@ func (ThirdUserLogic) findUid(thirdUsername string, typ int) int {
 	return bindUser.Uid
 }
 
-func (ThirdUserLogic) githubTokenAndUser(ctx context.Context, code string) (*model.GithubUser, *oauth2.Token, error) {
-	token, err := githubConf.Exchange(ctx, code)
-	if err != nil {
-		return nil, nil, err
+// findBindUser 查找第三方账号对应的绑定记录。
+// 早期的 GitHub、Gitea 绑定记录没有 provider 和 sub，通过 type 和用户名匹配
+func (ThirdUserLogic) findBindUser(ctx context.Context, provider *OAuthProvider, userInfo *OAuthUserInfo) (*model.BindUser, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"provider": provider.Name, "sub": userInfo.Id},
+		{"type": provider.bindType, "username": userInfo.Username, "provider": bson.M{"$exists": false}},
+	}}
+	if provider.bindType == model.BindTypeOAuth {
+		filter = bson.M{"provider": provider.Name, "sub": userInfo.Id}
 	}
 
-	httpClient := githubConf.Client(ctx, token)
-	resp, err := httpClient.Get(GithubAPIBaseUrl + "/user")
-	if err != nil {
-		return nil, nil, err
-	}
-	defer resp.Body.Close()
-
-	respBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, nil, err
+	bindUser := &model.BindUser{}
+	err := db.GetCollection("bind_user").FindOne(ctx, filter).Decode(bindUser)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
 	}
+	return bindUser, nil
+}
 
-	githubUser := &model.GithubUser{}
-	err = json.Unmarshal(respBytes, githubUser)
-	if err != nil {
-		return nil, nil, err
+func (ThirdUserLogic) updateBindUser(ctx context.Context, id int, provider *OAuthProvider, userInfo *OAuthUserInfo, token *oauth2.Token) error {
+	change := bson.M{
+		"provider":      provider.Name,
+		"sub":           userInfo.Id,
+		"username":      userInfo.Username,
+		"name":          userInfo.Name,
+		"avatar":        userInfo.Avatar,
+		"access_token":  token.AccessToken,
+		"refresh_token": token.RefreshToken,
 	}
-
-	if githubUser.Id == 0 {
-		return nil, nil, errors.New("get github user info error")
+	if !token.Expiry.IsZero() {
+		change["expire"] = int(token.Expiry.Unix())
 	}
-
-	return githubUser, token, nil
+	_, err := db.GetCollection("bind_user").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": change})
+	return err
 }
 
-func (ThirdUserLogic) giteaTokenAndUser(ctx context.Context, code string) (*model.GiteaUser, *oauth2.Token, error) {
-	token, err := giteaConf.Exchange(ctx, code)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	httpClient := giteaConf.Client(ctx, token)
-	resp, err := httpClient.Get(GiteaAPIBaseUrl + "/user")
-	if err != nil {
-		return nil, nil, err
+func (ThirdUserLogic) insertBindUser(ctx context.Context, uid int, provider *OAuthProvider, userInfo *OAuthUserInfo, token *oauth2.Token) error {
+	tuid, _ := strconv.Atoi(userInfo.Id)
+	bindUser := &model.BindUser{
+		Uid:          uid,
+		Type:         provider.bindType,
+		Provider:     provider.Name,
+		Sub:          userInfo.Id,
+		Email:        userInfo.Email,
+		Tuid:         tuid,
+		Username:     userInfo.Username,
+		Name:         userInfo.Name,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Avatar:       userInfo.Avatar,
+		CreatedAt:    time.Now(),
 	}
-	defer resp.Body.Close()
-
-	respBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, nil, err
+	if !token.Expiry.IsZero() {
+		bindUser.Expire = int(token.Expiry.Unix())
 	}
 
-	giteaUser := &model.GiteaUser{}
-	err = json.Unmarshal(respBytes, giteaUser)
+	id, err := db.NextID("bind_user")
 	if err != nil {
-		return nil, nil, err
-	}
-
-	if giteaUser.ID == 0 {
-		return nil, nil, errors.New("get gitea user info error")
+		return err
 	}
-
-	return giteaUser, token, nil
+	bindUser.Id = id
+	_, err = db.GetCollection("bind_user").InsertOne(ctx, bindUser)
+	return err
 }
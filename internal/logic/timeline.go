@@ -0,0 +1,295 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+	"github.com/studygolang/studygolang/util"
+
+	"github.com/polaris1119/config"
+	"github.com/polaris1119/logger"
+	"github.com/polaris1119/set"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 时间线采用 fan-out-on-read：发布和评论时只写一条事件（timeline_event），
+// 读取时按当前用户关注的人、节点、专栏实时过滤。
+// 站点大V的粉丝数远大于普通用户的关注数，fan-out-on-write 会在发布时放大写入，
+// 而且关注/取关、可见范围修改后都需要回填或清理收件箱；读时过滤则天然一致。
+// 关注的人数在可见性判断中已经限制为 maxFollowingUids，查询走 uid、nid、sids 索引。
+
+const (
+	// 时间线每页最多条数
+	maxTimelineLimit = 50
+	// 摘要长度
+	timelineSummaryLen = 140
+)
+
+type TimelineLogic struct{}
+
+var DefaultTimeline = TimelineLogic{}
+
+// FindByUser 获取 me 的首页时间线。cursor 为上一页最后一条的 id，0 表示第一页；
+// 返回的 nextCursor 为 0 表示没有更多了
+func (self TimelineLogic) FindByUser(ctx context.Context, me *model.Me, cursor, limit int) ([]*model.TimelineEvent, int) {
+	objLog := GetLogger(ctx)
+
+	if limit <= 0 || limit > maxTimelineLimit {
+		limit = 20
+	}
+
+	followingUids := DefaultUserFollow.FollowingUids(ctx, me.Uid)
+	nids := DefaultUserFollow.FollowingNids(ctx, me.Uid)
+	sids := DefaultSubject.followingSids(ctx, me.Uid)
+
+	public := bson.M{"$nin": []int{model.PermissionFollow, model.PermissionOnlyMe}}
+	or := []bson.M{
+		{"uid": me.Uid},
+		{"uid": bson.M{"$in": followingUids}, "permission": bson.M{"$ne": model.PermissionOnlyMe}},
+	}
+	if len(nids) > 0 {
+		or = append(or, bson.M{"action": model.TimelinePublish, "objtype": model.TypeTopic, "nid": bson.M{"$in": nids}, "permission": public})
+	}
+	if len(sids) > 0 {
+		or = append(or, bson.M{"action": model.TimelinePublish, "sids": bson.M{"$in": sids}})
+	}
+
+	filter := bson.M{"$or": or}
+	if cursor > 0 {
+		filter["_id"] = bson.M{"$lt": cursor}
+	}
+
+	events := make([]*model.TimelineEvent, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cur, err := db.GetCollection("timeline_event").Find(ctx, filter, opts)
+	if err != nil {
+		objLog.Errorln("TimelineLogic FindByUser error:", err)
+		return nil, 0
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &events); err != nil {
+		objLog.Errorln("TimelineLogic FindByUser decode error:", err)
+		return nil, 0
+	}
+
+	nextCursor := 0
+	if len(events) == limit {
+		nextCursor = events[len(events)-1].Id
+	}
+
+	return self.fillOtherInfo(ctx, me, events, followingUids), nextCursor
+}
+
+// fillOtherInfo 填充用户信息，并去掉已删除、下线或当前已不可见的内容
+func (TimelineLogic) fillOtherInfo(ctx context.Context, me *model.Me, events []*model.TimelineEvent, followingUids []int) []*model.TimelineEvent {
+	uidSet := set.New(set.NonThreadSafe)
+	tidSet := set.New(set.NonThreadSafe)
+	articleIdSet := set.New(set.NonThreadSafe)
+	projectIdSet := set.New(set.NonThreadSafe)
+	cidSet := set.New(set.NonThreadSafe)
+	for _, event := range events {
+		uidSet.Add(event.Uid)
+		switch event.Objtype {
+		case model.TypeTopic:
+			tidSet.Add(event.Objid)
+		case model.TypeArticle:
+			articleIdSet.Add(event.Objid)
+		case model.TypeProject:
+			projectIdSet.Add(event.Objid)
+		}
+		if event.Cid > 0 {
+			cidSet.Add(event.Cid)
+		}
+	}
+
+	following := make(map[int]bool, len(followingUids))
+	for _, uid := range followingUids {
+		following[uid] = true
+	}
+
+	usersMap := DefaultUser.FindUserInfos(ctx, set.IntSlice(uidSet))
+	topicMap := DefaultTopic.findByTids(set.IntSlice(tidSet))
+	articleMap := DefaultArticle.findByIds(set.IntSlice(articleIdSet))
+	projectMap := DefaultProject.findByIds(set.IntSlice(projectIdSet))
+	commentMap := DefaultComment.findByIds(set.IntSlice(cidSet))
+
+	newEvents := make([]*model.TimelineEvent, 0, len(events))
+	for _, event := range events {
+		switch event.Objtype {
+		case model.TypeTopic:
+			topic, ok := topicMap[event.Objid]
+			if !ok || topic.Flag > model.FlagNormal {
+				continue
+			}
+			if topic.Restricted() && topic.Uid != me.Uid && !me.IsRoot &&
+				(topic.Permission != model.PermissionFollow || !following[topic.Uid]) {
+				continue
+			}
+		case model.TypeArticle:
+			if article, ok := articleMap[event.Objid]; !ok || article.Status >= model.ArticleStatusOffline {
+				continue
+			}
+		case model.TypeProject:
+			if project, ok := projectMap[event.Objid]; !ok || project.Status == model.ProjectStatusOffline {
+				continue
+			}
+		}
+
+		if event.Cid > 0 {
+			comment, ok := commentMap[event.Cid]
+			if !ok || comment.Flag > model.FlagNormal {
+				continue
+			}
+		}
+
+		event.User = usersMap[event.Uid]
+		event.Uri = model.PathUrlMap[event.Objtype] + strconv.Itoa(event.Objid)
+		if event.Cid > 0 {
+			event.Uri += "#commentForm"
+		}
+		newEvents = append(newEvents, event)
+	}
+
+	return newEvents
+}
+
+// record 写入一条时间线事件
+func (TimelineLogic) record(event *model.TimelineEvent) {
+	id, err := db.NextID("timeline_event")
+	if err != nil {
+		logger.Errorln("TimelineLogic record NextID error:", err)
+		return
+	}
+	event.Id = id
+	event.CreatedAt = time.Now()
+
+	_, err = db.GetCollection("timeline_event").InsertOne(context.Background(), event)
+	if err != nil {
+		logger.Errorln("TimelineLogic record error:", err)
+	}
+}
+
+// modifyTopic 主题节点或可见范围修改后，同步时间线事件
+func (TimelineLogic) modifyTopic(tid, nid, permission int) {
+	_, err := db.GetCollection("timeline_event").UpdateMany(context.Background(),
+		bson.M{"objtype": model.TypeTopic, "objid": tid},
+		bson.M{"$set": bson.M{"nid": nid, "permission": permission}})
+	if err != nil {
+		logger.Errorln("TimelineLogic modifyTopic error:", err)
+	}
+}
+
+// addSubject 文章收录进专栏后，专栏的关注者可以在时间线看到
+func (TimelineLogic) addSubject(articleId, sid int) {
+	_, err := db.GetCollection("timeline_event").UpdateMany(context.Background(),
+		bson.M{"action": model.TimelinePublish, "objtype": model.TypeArticle, "objid": articleId},
+		bson.M{"$addToSet": bson.M{"sids": sid}})
+	if err != nil {
+		logger.Errorln("TimelineLogic addSubject error:", err)
+	}
+}
+
+// removeSubject 文章从专栏移除
+func (TimelineLogic) removeSubject(articleId, sid int) {
+	_, err := db.GetCollection("timeline_event").UpdateMany(context.Background(),
+		bson.M{"action": model.TimelinePublish, "objtype": model.TypeArticle, "objid": articleId},
+		bson.M{"$pull": bson.M{"sids": sid}})
+	if err != nil {
+		logger.Errorln("TimelineLogic removeSubject error:", err)
+	}
+}
+
+// publishEvent 根据发布的对象生成事件，不进入时间线的类型返回 nil
+func (TimelineLogic) publishEvent(uid, objtype, objid int) *model.TimelineEvent {
+	event := &model.TimelineEvent{
+		Action:  model.TimelinePublish,
+		Uid:     uid,
+		Objtype: objtype,
+		Objid:   objid,
+		Sids:    []int{},
+	}
+
+	switch objtype {
+	case model.TypeTopic:
+		topic := DefaultTopic.findByTid(objid)
+		if topic.Tid != objid {
+			return nil
+		}
+		event.Nid = topic.Nid
+		event.Permission = topic.Permission
+		event.Title = topic.Title
+		event.Summary = util.Substring(topic.Content, timelineSummaryLen, "...")
+	case model.TypeArticle:
+		article, err := DefaultArticle.FindById(nil, objid)
+		if err != nil || article.Id != objid {
+			return nil
+		}
+		event.Title = article.Title
+		event.Summary = util.Substring(article.Txt, timelineSummaryLen, "...")
+	case model.TypeResource:
+		resource := DefaultResource.findById(objid)
+		if resource.Id != objid {
+			return nil
+		}
+		event.Title = resource.Title
+		event.Summary = util.Substring(resource.Content, timelineSummaryLen, "...")
+	case model.TypeProject:
+		project := DefaultProject.FindOne(nil, objid)
+		if project == nil || project.Id != objid {
+			return nil
+		}
+		event.Title = project.Category + " " + project.Name
+		event.Summary = util.Substring(project.Desc, timelineSummaryLen, "...")
+	default:
+		return nil
+	}
+
+	return event
+}
+
+// TimelineObserver 发布和评论时写入时间线事件
+type TimelineObserver struct{}
+
+// Update 评论时 objid 是 cid。只有长度达到 timeline.comment_min_len 的评论才算有分量的评论，进入时间线
+func (TimelineObserver) Update(action string, uid, objtype, objid int) {
+	if uid == 0 || objid == 0 {
+		return
+	}
+
+	switch action {
+	case actionPublish:
+		if event := DefaultTimeline.publishEvent(uid, objtype, objid); event != nil {
+			DefaultTimeline.record(event)
+		}
+	case actionComment:
+		comment, err := DefaultComment.FindById(objid)
+		if err != nil || comment.Cid != objid {
+			return
+		}
+
+		minLen := config.ConfigFile.MustInt("timeline", "comment_min_len", 30)
+		if utf8.RuneCountInString(comment.Content) < minLen {
+			return
+		}
+
+		event := DefaultTimeline.publishEvent(uid, comment.Objtype, comment.Objid)
+		if event == nil {
+			return
+		}
+		event.Action = model.TimelineComment
+		event.Cid = comment.Cid
+		event.Summary = util.Substring(comment.Content, timelineSummaryLen, "...")
+		DefaultTimeline.record(event)
+	}
+}
@@ -45,7 +45,7 @@ func (self TopicLogic) Publish(ctx context.Context, me *model.Me, form url.Value
 			return
 		}
 
-		if !CanEdit(me, topic) {
+		if !CanEdit(ctx, me, topic) {
 			err = NotModifyAuthorityErr
 			return
 		}
@@ -75,8 +75,31 @@ func (self TopicLogic) Publish(ctx context.Context, me *model.Me, form url.Value
 			}
 		}()
 	} else {
+		action := NewPublishAction(ctx, me, PublishKindCreate, model.TypeTopic, form)
+		err = CheckPublish(ctx, action)
+		if err != nil {
+			return
+		}
+
+		publishAt, parseErr := DefaultSchedule.parsePublishAt(form.Get("publish_at"))
+		if parseErr != nil {
+			err = parseErr
+			return
+		}
+
+		poll, pollErr := DefaultPoll.newPoll(form)
+		if pollErr != nil {
+			err = pollErr
+			return
+		}
+		if poll != nil && !publishAt.IsZero() && !poll.CloseAt.IsZero() && poll.CloseAt.Before(publishAt) {
+			err = errors.New("投票截止时间不能早于发布时间")
+			return
+		}
+
 		usernames := form.Get("usernames")
 		form.Del("usernames")
+		form.Del("publish_at")
 
 		topic := &model.Topic{}
 		err = schemaDecoder.Decode(topic, form)
@@ -85,10 +108,19 @@ func (self TopicLogic) Publish(ctx context.Context, me *model.Me, form url.Value
 			return
 		}
 		topic.Uid = me.Uid
+		topic.Question = DefaultQuestion.questionMode(ctx, form, topic.Nid, nil)
+		topic.AnswerCid = 0
 		topic.Lastreplytime = model.NewOftenTime()
 		now := model.OftenTime(time.Now())
 		topic.Ctime = now
 		topic.Mtime = now
+		if !publishAt.IsZero() {
+			// 定时发布：ctime 先记为发布时间，到时间后再改为实际发布的时间
+			topic.Flag = model.FlagScheduled
+			topic.PublishAt = publishAt
+			topic.Ctime = model.OftenTime(publishAt)
+			topic.Mtime = topic.Ctime
+		}
 
 		newID, idErr := db.NextID("topics")
 		if idErr != nil {
@@ -97,6 +129,24 @@ func (self TopicLogic) Publish(ctx context.Context, me *model.Me, form url.Value
 			return
 		}
 		topic.Tid = newID
+		if action.Review {
+			topic.Flag = model.FlagPending
+		}
+		if topic.Flag != model.FlagNoAudit {
+			// 发布时才通知 @ 的用户
+			topic.AtUsernames = usernames
+		}
+
+		if poll != nil {
+			poll.Id, err = db.NextID("poll")
+			if err != nil {
+				objLog.Errorln("TopicLogic Publish poll NextID error:", err)
+				return
+			}
+			poll.Tid = topic.Tid
+			poll.Uid = me.Uid
+			poll.CreatedAt = time.Now()
+		}
 
 		session, sessErr := db.GetClient().StartSession()
 		if sessErr != nil {
@@ -119,7 +169,11 @@ func (self TopicLogic) Publish(ctx context.Context, me *model.Me, form url.Value
 			if insertErr != nil {
 				return nil, insertErr
 			}
-			return nil, nil
+
+			if poll != nil {
+				_, insertErr = db.GetCollection("poll").InsertOne(sc, poll)
+			}
+			return nil, insertErr
 		})
 		if err != nil {
 			objLog.Errorln("TopicLogic Publish transaction error:", err)
@@ -153,39 +207,94 @@ func (self TopicLogic) Publish(ctx context.Context, me *model.Me, form url.Value
 			}
 		}()
 
-		topicEx := &model.TopicEx{Tid: topic.Tid}
-		DefaultFeed.publish(topic, topicEx, me)
+		tid = topic.Tid
 
-		ext := map[string]interface{}{
-			"objid":   topic.Tid,
-			"objtype": model.TypeTopic,
-			"uid":     me.Uid,
-			"msgtype": model.MsgtypePublishAtMe,
+		if action.Review {
+			// 审核通过后再发动态、通知
+			DefaultModeration.hold(ctx, action, model.TypeTopic, tid, me.Uid, nil)
+			return
 		}
-		go DefaultMessage.SendSysMsgAtUsernames(ctx, usernames, ext, 0)
 
-		go publishObservable.NotifyObservers(me.Uid, model.TypeTopic, topic.Tid)
+		if !publishAt.IsZero() {
+			// 到时间后再发动态、通知
+			return
+		}
 
-		tid = topic.Tid
+		self.published(ctx, me, topic, usernames)
 	}
 
 	return
 }
 
+// published 主题发布（或审核通过）后：发布动态、给 @ 的用户发消息、通知观察者
+func (TopicLogic) published(ctx context.Context, me *model.Me, topic *model.Topic, usernames string) {
+	topicEx := &model.TopicEx{Tid: topic.Tid}
+	DefaultFeed.publish(topic, topicEx, me)
+
+	ext := map[string]interface{}{
+		"objid":   topic.Tid,
+		"objtype": model.TypeTopic,
+		"uid":     topic.Uid,
+		"msgtype": model.MsgtypePublishAtMe,
+	}
+	go DefaultMessage.SendSysMsgAtUsernames(ctx, usernames, ext, 0)
+
+	go publishObservable.NotifyObservers(topic.Uid, model.TypeTopic, topic.Tid)
+}
+
 // Modify 修改主题
 func (TopicLogic) Modify(ctx context.Context, user *model.Me, form url.Values) (errMsg string, err error) {
 	objLog := GetLogger(ctx)
 
+	tid := goutils.MustInt(form.Get("tid"))
+	topic := &model.Topic{}
+	err = db.GetCollection("topics").FindOne(ctx, bson.M{"_id": tid}).Decode(topic)
+	if err != nil {
+		objLog.Errorf("获取主题 【%d】 失败：%s", tid, err)
+		errMsg = "主题不存在"
+		return
+	}
+
+	if !CanEdit(ctx, user, topic) {
+		err = NotModifyAuthorityErr
+		errMsg = err.Error()
+		return
+	}
+
+	// 非作者移动节点，需要有目标节点的管理权限
+	nid := goutils.MustInt(form.Get("nid"))
+	if user.Uid != topic.Uid && nid != topic.Nid && !DefaultNodeModerator.CanModerate(ctx, user, nid) {
+		err = errors.New("无权将主题移动到该节点")
+		errMsg = err.Error()
+		return
+	}
+
+	summary, err := DefaultRevision.checkSummary(form.Get("summary"))
+	if err != nil {
+		errMsg = err.Error()
+		return
+	}
+
+	action := NewPublishAction(ctx, user, PublishKindModify, model.TypeTopic, form)
+	err = CheckPublish(ctx, action)
+	if err != nil {
+		errMsg = err.Error()
+		return
+	}
+
 	change := bson.M{
 		"editor_uid": user.Uid,
+		"nid":        nid,
+		"permission": goutils.MustInt(form.Get("permission")),
+		"question":   DefaultQuestion.questionMode(ctx, form, nid, topic),
+		"mtime":      time.Now(),
 	}
 
-	fields := []string{"title", "content", "nid", "permission"}
+	fields := []string{"title", "content"}
 	for _, field := range fields {
 		change[field] = form.Get(field)
 	}
 
-	tid := goutils.MustInt(form.Get("tid"))
 	_, err = db.GetCollection("topics").UpdateOne(ctx, bson.M{"_id": tid}, bson.M{"$set": change})
 	if err != nil {
 		objLog.Errorf("更新主题 【%d】 信息失败：%s\n", tid, err)
@@ -193,6 +302,27 @@ func (TopicLogic) Modify(ctx context.Context, user *model.Me, form url.Values) (
 		return
 	}
 
+	DefaultNodeModerator.recordEdit(ctx, user, topic, nid)
+	before := DefaultRevision.snapshot(topic)
+	DefaultRevision.record(ctx, model.TypeTopic, tid, before, user.Uid,
+		change["title"].(string), change["content"].(string), summary, 0)
+
+	if action.Review {
+		DefaultModeration.holdTopic(ctx, tid)
+		DefaultModeration.hold(ctx, action, model.TypeTopic, tid, topic.Uid, before)
+	}
+
+	permission := change["permission"].(int)
+	if nid != topic.Nid || permission != topic.Permission {
+		go DefaultTimeline.modifyTopic(tid, nid, permission)
+	}
+	// 定时发布的主题还没有动态；待审核（包括这次修改送审）的主题动态已下线，审核通过时再同步
+	if permission != topic.Permission && topic.Flag != model.FlagScheduled && topic.Flag != model.FlagPending && !action.Review {
+		topic.Permission = permission
+		topic.Nid = nid
+		DefaultFeed.modifyTopicPermission(topic)
+	}
+
 	go modifyObservable.NotifyObservers(user.Uid, model.TypeTopic, tid)
 
 	return
@@ -202,6 +332,13 @@ func (TopicLogic) Modify(ctx context.Context, user *model.Me, form url.Values) (
 func (self TopicLogic) Append(ctx context.Context, uid, tid int, content string) error {
 	objLog := GetLogger(ctx)
 
+	action := NewPublishAction(ctx, publishUser(ctx, uid), PublishKindAppend, model.TypeTopic, nil)
+	action.Content = content
+	if err := CheckPublish(ctx, action); err != nil {
+		return err
+	}
+	content = action.Content
+
 	num, err := db.GetCollection("topic_append").CountDocuments(ctx, bson.M{"tid": tid})
 	if err != nil {
 		objLog.Errorln("TopicLogic Append error:", err)
@@ -322,7 +459,7 @@ func (self TopicLogic) FindAll(ctx context.Context, paginator *Paginator, orderB
 	if querystring != "" {
 		filter = buildFilter(querystring, args...)
 	}
-	filter = self.addFlagFilter(filter)
+	filter = topicVisibleFilter(ctx, self.addFlagFilter(filter))
 
 	total, err := db.GetCollection("topics").CountDocuments(ctx, filter)
 	if err != nil {
@@ -386,6 +523,7 @@ func (TopicLogic) FindLastList(beginTime string, limit int) ([]*model.Topic, err
 		"ctime": bson.M{"$gt": beginTime},
 		"flag":  bson.M{"$in": []uint8{model.FlagNoAudit, model.FlagNormal}},
 	}
+	filter = publicTopicFilter(filter)
 	findOpts := options.Find().
 		SetSort(bson.M{"_id": -1}).
 		SetLimit(int64(limit))
@@ -404,7 +542,7 @@ func (TopicLogic) FindLastList(beginTime string, limit int) ([]*model.Topic, err
 // FindRecent 获得最近的主题(uids[0]，则获取某个用户最近的主题)
 func (self TopicLogic) FindRecent(limit int, uids ...int) []*model.Topic {
 	ctx := context.Background()
-	filter := self.addFlagFilter(bson.M{})
+	filter := publicTopicFilter(self.addFlagFilter(bson.M{}))
 	if len(uids) > 0 {
 		filter["uid"] = uids[0]
 	}
@@ -441,6 +579,7 @@ func (TopicLogic) FindByNid(ctx context.Context, nid, curTid string) []*model.To
 		"_id":  bson.M{"$ne": goutils.MustInt(curTid)},
 		"flag": bson.M{"$lt": model.FlagAuditDelete},
 	}
+	filter = publicTopicFilter(filter)
 	findOpts := options.Find().SetLimit(10)
 
 	cursor, err := db.GetCollection("topics").Find(ctx, filter, findOpts)
@@ -458,14 +597,14 @@ func (TopicLogic) FindByNid(ctx context.Context, nid, curTid string) []*model.To
 	return topics
 }
 
-// FindByTids 获取多个主题详细信息
+// FindByTids 获取多个主题详细信息。不区分用户，只返回所有人可见的主题
 func (TopicLogic) FindByTids(tids []int) []*model.Topic {
 	if len(tids) == 0 {
 		return nil
 	}
 
 	ctx := context.Background()
-	cursor, err := db.GetCollection("topics").Find(ctx, bson.M{"_id": bson.M{"$in": tids}})
+	cursor, err := db.GetCollection("topics").Find(ctx, publicTopicFilter(bson.M{"_id": bson.M{"$in": tids}}))
 	if err != nil {
 		logger.Errorln("TopicLogic FindByTids error:", err)
 		return nil
@@ -480,6 +619,7 @@ func (TopicLogic) FindByTids(tids []int) []*model.Topic {
 	return topics
 }
 
+// FindFullinfoByTids 获取多个主题及其扩展信息。不区分用户，只返回所有人可见的主题
 func (self TopicLogic) FindFullinfoByTids(tids []int) []map[string]interface{} {
 	if len(tids) == 0 {
 		return nil
@@ -487,7 +627,7 @@ func (self TopicLogic) FindFullinfoByTids(tids []int) []map[string]interface{} {
 
 	ctx := context.Background()
 
-	topicCursor, err := db.GetCollection("topics").Find(ctx, bson.M{"_id": bson.M{"$in": tids}})
+	topicCursor, err := db.GetCollection("topics").Find(ctx, publicTopicFilter(bson.M{"_id": bson.M{"$in": tids}}))
 	if err != nil {
 		logger.Errorln("TopicLogic FindFullinfoByTids topics error:", err)
 		return nil
@@ -562,6 +702,11 @@ func (self TopicLogic) FindByTid(ctx context.Context, tid int) (topicMap map[str
 		return
 	}
 
+	if !CanViewTopic(ctx, topic) {
+		err = NotFoundErr
+		return
+	}
+
 	topicEx := &model.TopicEx{}
 	_ = db.GetCollection("topics_ex").FindOne(ctx, bson.M{"tid": tid}).Decode(topicEx)
 
@@ -575,6 +720,24 @@ func (self TopicLogic) FindByTid(ctx context.Context, tid int) (topicMap map[str
 
 	replies, owerUser, lastReplyUser := DefaultComment.FindObjComments(ctx, topic.Tid, model.TypeTopic, topic.Uid, topic.Lastreplyuid)
 	topicMap["user"] = owerUser
+	me, _ := ctx.Value("user").(*model.Me)
+	if poll := DefaultPoll.findByTid(ctx, me, tid); poll != nil {
+		topicMap["poll"] = poll
+	}
+	if topic.Question {
+		if bounty := DefaultBounty.FindByTid(ctx, tid); bounty != nil {
+			topicMap["bounty"] = bounty
+		}
+	}
+	// 被采纳的答案置顶显示在问题下面
+	if topic.AnswerCid != 0 {
+		for _, reply := range replies {
+			if reply["cid"] == topic.AnswerCid {
+				topicMap["answer"] = reply
+				break
+			}
+		}
+	}
 	if topic.Lastreplyuid != 0 {
 		topicMap["lastreplyusername"] = lastReplyUser.Username
 	}
@@ -652,7 +815,7 @@ func (TopicLogic) findByTid(tid int) *model.Topic {
 	return topic
 }
 
-// findByTids 获取多个主题详细信息 包内用
+// findByTids 获取多个主题详细信息 包内用。不过滤可见范围，调用方需要按当前用户判断（CanViewTopic）
 func (TopicLogic) findByTids(tids []int) map[int]*model.Topic {
 	if len(tids) == 0 {
 		return nil
@@ -820,6 +983,7 @@ func (TopicLogic) Count(ctx context.Context, querystring string, args ...interfa
 			filter[k] = v
 		}
 	}
+	filter = topicVisibleFilter(ctx, filter)
 
 	total, err := db.GetCollection("topics").CountDocuments(ctx, filter)
 	if err != nil {
@@ -851,12 +1015,12 @@ func (TopicLogic) addFlagFilter(filter bson.M) bson.M {
 	return filter
 }
 
-// buildTopicSort 将 "field DESC" 形式的排序转为 bson.M
-func buildTopicSort(orderBy string) bson.M {
+// buildTopicSort 将 "field DESC" 形式的排序转为 bson.D（保持字段顺序）
+func buildTopicSort(orderBy string) bson.D {
 	if orderBy == "" {
-		return bson.M{"_id": -1}
+		return bson.D{{Key: "_id", Value: -1}}
 	}
-	sort := bson.M{}
+	sort := bson.D{}
 	parts := splitOrderBy(orderBy)
 	for _, p := range parts {
 		field := p[0]
@@ -867,7 +1031,7 @@ func buildTopicSort(orderBy string) bson.M {
 		if len(p) > 1 && (p[1] == "DESC" || p[1] == "desc") {
 			dir = -1
 		}
-		sort[field] = dir
+		sort = append(sort, bson.E{Key: field, Value: dir})
 	}
 	return sort
 }
@@ -959,7 +1123,8 @@ func (self TopicComment) SetObjinfo(ids []int, commentMap map[int][]*model.Comme
 	}
 
 	for _, topic := range topics {
-		if topic.Flag > model.FlagNormal {
+		// 非公开主题的评论不出现在评论列表中
+		if topic.Flag > model.FlagNormal || topic.Restricted() {
 			continue
 		}
 		objinfo := make(map[string]interface{})
@@ -126,6 +126,7 @@ func (self TopicNodeLogic) Modify(ctx context.Context, form url.Values) error {
 	for _, field := range fields {
 		change[field] = form.Get(field)
 	}
+	change["question"] = node.Question
 
 	_, err = db.GetCollection("topics_node").UpdateOne(ctx, bson.M{"_id": nid}, bson.M{"$set": change})
 	if err != nil {
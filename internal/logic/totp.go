@@ -0,0 +1,389 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/goutils"
+	"github.com/polaris1119/nosql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// 允许前后各偏差一个时间步，容忍客户端时钟误差
+	totpSkew = 1
+
+	recoveryCodeNum = 10
+
+	// 密码验证通过后，等待输入两步验证码的有效期（秒）及最多尝试次数
+	totpTicketExpire  = 300
+	totpTicketMaxTry  = 5
+	totpTicketKeyPrev = "login:totp:ticket:"
+)
+
+var (
+	ErrTotpCode   = errors.New("验证码错误")
+	ErrTotpTicket = errors.New("登录已超时，请重新登录")
+)
+
+type TotpLogic struct{}
+
+var DefaultTotp = TotpLogic{}
+
+// FindOne 获取用户的两步验证信息，不存在返回 nil
+func (TotpLogic) FindOne(ctx context.Context, uid int) *model.UserTotp {
+	userTotp := &model.UserTotp{}
+	err := db.GetCollection("user_totp").FindOne(ctx, bson.M{"_id": uid}).Decode(userTotp)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			GetLogger(ctx).Errorln("TotpLogic FindOne error:", err)
+		}
+		return nil
+	}
+	return userTotp
+}
+
+// IsEnabled 用户是否已开启两步验证
+func (self TotpLogic) IsEnabled(ctx context.Context, uid int) bool {
+	userTotp := self.FindOne(ctx, uid)
+	return userTotp != nil && userTotp.Enabled
+}
+
+// IsRequired 站点要求管理员必须开启两步验证时，判断该用户是否在要求之列
+func (TotpLogic) IsRequired(ctx context.Context, uid int) bool {
+	if UserSetting[model.KeyTotpForceAdmin] != 1 {
+		return false
+	}
+
+	user := DefaultUser.FindOne(ctx, "_id", uid)
+	if user.IsRoot {
+		return true
+	}
+
+	userRoles := make([]*model.UserRole, 0)
+	cursor, err := db.GetCollection("user_role").Find(ctx, bson.M{"uid": uid})
+	if err != nil {
+		GetLogger(ctx).Errorln("TotpLogic IsRequired find user role error:", err)
+		return false
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &userRoles); err != nil {
+		GetLogger(ctx).Errorln("TotpLogic IsRequired decode user role error:", err)
+		return false
+	}
+
+	for _, userRole := range userRoles {
+		if userRole.Roleid <= model.Administrator {
+			return true
+		}
+	}
+	return false
+}
+
+// Setup 生成新的密钥（未开启状态），返回密钥和用于生成二维码的 otpauth URI。
+// 已开启两步验证的用户需要先关闭
+func (self TotpLogic) Setup(ctx context.Context, uid int, username string) (string, string, error) {
+	objLog := GetLogger(ctx)
+
+	if self.IsEnabled(ctx, uid) {
+		return "", "", errors.New("已经开启了两步验证")
+	}
+
+	secret, err := GenTotpSecret()
+	if err != nil {
+		objLog.Errorln("TotpLogic Setup gen secret error:", err)
+		return "", "", errors.New("内部服务错误")
+	}
+
+	setData := bson.M{
+		"secret":     secret,
+		"enabled":    false,
+		"last_step":  0,
+		"created_at": time.Now(),
+	}
+	_, err = db.GetCollection("user_totp").UpdateOne(ctx, bson.M{"_id": uid}, bson.M{"$set": setData}, options.Update().SetUpsert(true))
+	if err != nil {
+		objLog.Errorln("TotpLogic Setup save error:", err)
+		return "", "", errors.New("内部服务错误")
+	}
+
+	return secret, TotpURI(model.WebsiteSetting.Name, username, secret), nil
+}
+
+// Enable 校验验证码后开启两步验证，返回一次性恢复码（明文只出现这一次）
+func (self TotpLogic) Enable(ctx context.Context, uid int, code string) ([]string, error) {
+	objLog := GetLogger(ctx)
+
+	userTotp := self.FindOne(ctx, uid)
+	if userTotp == nil || userTotp.Secret == "" {
+		return nil, errors.New("请先获取两步验证密钥")
+	}
+	if userTotp.Enabled {
+		return nil, errors.New("已经开启了两步验证")
+	}
+
+	step, ok := ValidateTotp(userTotp.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrTotpCode
+	}
+
+	codes, hashes, err := genRecoveryCodes()
+	if err != nil {
+		objLog.Errorln("TotpLogic Enable gen recovery codes error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+
+	_, err = db.GetCollection("user_totp").UpdateOne(ctx, bson.M{"_id": uid}, bson.M{"$set": bson.M{
+		"enabled":        true,
+		"recovery_codes": hashes,
+		"last_step":      step,
+		"enabled_at":     time.Now(),
+	}})
+	if err != nil {
+		objLog.Errorln("TotpLogic Enable save error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+
+	return codes, nil
+}
+
+// Disable 关闭两步验证，需要验证码或恢复码。被强制要求的用户不能关闭
+func (self TotpLogic) Disable(ctx context.Context, uid int, code string) error {
+	if self.IsRequired(ctx, uid) {
+		return errors.New("管理员必须开启两步验证，不能关闭")
+	}
+
+	if err := self.Verify(ctx, uid, code); err != nil {
+		return err
+	}
+
+	_, err := db.GetCollection("user_totp").DeleteOne(ctx, bson.M{"_id": uid})
+	if err != nil {
+		GetLogger(ctx).Errorln("TotpLogic Disable error:", err)
+		return errors.New("内部服务错误")
+	}
+	return nil
+}
+
+// RegenRecoveryCodes 重新生成恢复码，旧的恢复码全部作废
+func (self TotpLogic) RegenRecoveryCodes(ctx context.Context, uid int, code string) ([]string, error) {
+	objLog := GetLogger(ctx)
+
+	if err := self.Verify(ctx, uid, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := genRecoveryCodes()
+	if err != nil {
+		objLog.Errorln("TotpLogic RegenRecoveryCodes gen error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+
+	_, err = db.GetCollection("user_totp").UpdateOne(ctx, bson.M{"_id": uid}, bson.M{"$set": bson.M{"recovery_codes": hashes}})
+	if err != nil {
+		objLog.Errorln("TotpLogic RegenRecoveryCodes save error:", err)
+		return nil, errors.New("内部服务错误")
+	}
+	return codes, nil
+}
+
+// Verify 校验 TOTP 验证码或恢复码，恢复码使用后即作废
+func (self TotpLogic) Verify(ctx context.Context, uid int, code string) error {
+	objLog := GetLogger(ctx)
+
+	userTotp := self.FindOne(ctx, uid)
+	if userTotp == nil || !userTotp.Enabled {
+		return errors.New("未开启两步验证")
+	}
+
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) == totpDigits {
+		step, ok := ValidateTotp(userTotp.Secret, code, time.Now())
+		if !ok || step <= userTotp.LastStep {
+			return ErrTotpCode
+		}
+
+		// 以 last_step 作条件更新，防止同一验证码被并发重复使用
+		result, err := db.GetCollection("user_totp").UpdateOne(ctx,
+			bson.M{"_id": uid, "last_step": userTotp.LastStep},
+			bson.M{"$set": bson.M{"last_step": step}})
+		if err != nil {
+			objLog.Errorln("TotpLogic Verify update last step error:", err)
+			return errors.New("内部服务错误")
+		}
+		if result.ModifiedCount == 0 {
+			return ErrTotpCode
+		}
+		return nil
+	}
+
+	// 恢复码
+	codeHash := hashAccessToken(strings.ToLower(strings.ReplaceAll(code, "-", "")))
+	result, err := db.GetCollection("user_totp").UpdateOne(ctx,
+		bson.M{"_id": uid, "recovery_codes": codeHash},
+		bson.M{"$pull": bson.M{"recovery_codes": codeHash}})
+	if err != nil {
+		objLog.Errorln("TotpLogic Verify recovery code error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.ModifiedCount == 0 {
+		return ErrTotpCode
+	}
+	return nil
+}
+
+// NeedSecondStep 密码（或第三方）验证通过后，是否还需要两步验证
+func (self TotpLogic) NeedSecondStep(ctx context.Context, uid int) bool {
+	return self.IsEnabled(ctx, uid) || self.IsRequired(ctx, uid)
+}
+
+// GenLoginTicket 生成等待两步验证的登录凭证
+func (TotpLogic) GenLoginTicket(username string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	ticket := hex.EncodeToString(buf)
+
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
+
+	return ticket, redis.SET(totpTicketKeyPrev+ticket, username, totpTicketExpire)
+}
+
+// CheckLoginTicket 获取登录凭证对应的用户名；超过尝试次数后凭证作废
+func (TotpLogic) CheckLoginTicket(ticket string) (string, error) {
+	if ticket == "" {
+		return "", ErrTotpTicket
+	}
+
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
+
+	key := totpTicketKeyPrev + ticket
+	username := redis.GET(key)
+	if username == "" {
+		return "", ErrTotpTicket
+	}
+
+	tryKey := key + ":try"
+	tryNum := goutils.MustInt(redis.GET(tryKey)) + 1
+	if tryNum > totpTicketMaxTry {
+		redis.DEL(key)
+		redis.DEL(tryKey)
+		return "", errors.New("验证码错误次数太多，请重新登录")
+	}
+	redis.SET(tryKey, tryNum, totpTicketExpire)
+
+	return username, nil
+}
+
+// DelLoginTicket 两步验证通过后删除登录凭证
+func (TotpLogic) DelLoginTicket(ticket string) {
+	redis := nosql.NewRedisFromPool()
+	defer redis.Close()
+
+	key := totpTicketKeyPrev + ticket
+	redis.DEL(key)
+	redis.DEL(key + ":try")
+}
+
+// GenTotpSecret 生成 160 位的随机密钥，base32 编码
+func GenTotpSecret() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf), nil
+}
+
+// TotpURI 生成身份验证器 App 扫码用的 otpauth URI
+func TotpURI(issuer, account, secret string) string {
+	if issuer == "" {
+		issuer = "StudyGolang"
+	}
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// ValidateTotp 校验验证码，通过时返回对应的时间步
+func ValidateTotp(secret, code string, t time.Time) (int64, bool) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	step := t.Unix() / totpPeriod
+	for i := -totpSkew; i <= totpSkew; i++ {
+		expected := hotp(key, step+int64(i), totpDigits)
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// hotp RFC 4226 HOTP 算法
+func hotp(key []byte, counter int64, digits int) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// genRecoveryCodes 生成恢复码，返回明文（xxxxx-xxxxx 格式）和对应的 sha256
+func genRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeNum)
+	hashes := make([]string, recoveryCodeNum)
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		plain := hex.EncodeToString(buf)
+		codes[i] = plain[:5] + "-" + plain[5:]
+		hashes[i] = hashAccessToken(plain)
+	}
+	return codes, hashes, nil
+}
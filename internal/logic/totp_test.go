@@ -0,0 +1,40 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/studygolang/studygolang/internal/logic"
+)
+
+func TestValidateTotp(t *testing.T) {
+	// RFC 6238 附录 B 的测试向量（SHA1，取后 6 位）
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		if _, ok := logic.ValidateTotp(secret, tt.code, time.Unix(tt.unix, 0)); !ok {
+			t.Errorf("ValidateTotp(%d, %s) should pass", tt.unix, tt.code)
+		}
+	}
+
+	// 允许一个时间步的误差，超出则失败
+	if _, ok := logic.ValidateTotp(secret, "287082", time.Unix(59+30, 0)); !ok {
+		t.Error("ValidateTotp should allow one step skew")
+	}
+	if _, ok := logic.ValidateTotp(secret, "287082", time.Unix(59+90, 0)); ok {
+		t.Error("ValidateTotp should reject code out of window")
+	}
+}
@@ -9,6 +9,7 @@ package logic
 import (
 	"context"
 	"errors"
+	"math"
 	"math/rand"
 	"net/url"
 	"strings"
@@ -191,9 +192,46 @@ func (UserLogic) UpdateUserStatus(ctx context.Context, uid, status int) error {
 	_, err := db.GetCollection("user_info").UpdateOne(ctx, bson.M{"_id": uid}, bson.M{"$set": bson.M{"status": status}})
 	if err != nil {
 		objLog.Errorf("更新用户 【%d】 状态失败：%s", uid, err)
+		return err
 	}
 
-	return err
+	// 冻结或停用后，已登录的设备全部下线
+	if status == model.UserStatusFreeze || status == model.UserStatusOutage {
+		DefaultUserSession.RevokeAll(ctx, uid, "")
+	}
+
+	return nil
+}
+
+// CheckManage 后台管理用户（修改状态、删除内容）前检查：站长不能被管理，
+// 也不能管理角色比自己高的用户（角色 id 越小权限越高）
+func (self UserLogic) CheckManage(ctx context.Context, me *model.Me, uid int) error {
+	user := self.FindOne(ctx, "uid", uid)
+	if user == nil || user.Uid == 0 {
+		return errors.New("用户不存在")
+	}
+	if user.IsRoot {
+		return errors.New("不能操作站长")
+	}
+	if me.IsRoot {
+		return nil
+	}
+
+	if topRoleId(user.Roleids) < topRoleId(me.RoleIds) {
+		return errors.New("不能操作角色比自己高的用户")
+	}
+	return nil
+}
+
+// topRoleId 权限最高的角色 id，没有角色时返回 math.MaxInt32
+func topRoleId(roleIds []int) int {
+	top := math.MaxInt32
+	for _, roleId := range roleIds {
+		if roleId < top {
+			top = roleId
+		}
+	}
+	return top
 }
 
 // ChangeAvatar 更换头像
@@ -537,6 +575,8 @@ func (self UserLogic) UpdatePasswd(ctx context.Context, username, curPasswd, new
 		return "用户不存在", err
 	}
 
+	uid := userLogin.Uid
+
 	if userLogin.Passwd != "" {
 		_, err = self.Login(ctx, username, curPasswd)
 		if err != nil {
@@ -561,6 +601,9 @@ func (self UserLogic) UpdatePasswd(ctx context.Context, username, curPasswd, new
 		logger.Errorf("用户 %s 更新密码错误：%s", username, err)
 		return "对不起，内部服务错误！", err
 	}
+
+	// 密码修改后，所有设备需要重新登录
+	DefaultUserSession.RevokeAll(ctx, uid, "")
 	return "", nil
 }
 
@@ -594,6 +637,11 @@ func (self UserLogic) ResetPasswd(ctx context.Context, email, passwd string) (st
 		objLog.Errorf("用户 %s 更新密码错误：%s", email, err)
 		return "对不起，内部服务错误！", err
 	}
+
+	user := self.FindOne(ctx, "email", email)
+	if user.Uid > 0 {
+		DefaultUserSession.RevokeAll(ctx, user.Uid, "")
+	}
 	return "", nil
 }
 
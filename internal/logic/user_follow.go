@@ -0,0 +1,312 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 判断可见性时，最多取用户关注的人数
+const maxFollowingUids = 5000
+
+type UserFollowLogic struct{}
+
+var DefaultUserFollow = UserFollowLogic{}
+
+// Follow uid 关注 followUid
+func (UserFollowLogic) Follow(ctx context.Context, uid, followUid int) error {
+	objLog := GetLogger(ctx)
+
+	if uid == followUid {
+		return errors.New("不能关注自己")
+	}
+
+	user := DefaultUser.FindOne(ctx, "uid", followUid)
+	if user == nil || user.Uid == 0 {
+		return errors.New("用户不存在")
+	}
+
+	id, err := db.NextID("user_follow")
+	if err != nil {
+		objLog.Errorln("UserFollowLogic Follow NextID error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	follow := &model.UserFollow{
+		Id:        id,
+		Uid:       uid,
+		FollowUid: followUid,
+		CreatedAt: time.Now(),
+	}
+	_, err = db.GetCollection("user_follow").InsertOne(ctx, follow)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("已经关注了该用户")
+		}
+		objLog.Errorln("UserFollowLogic Follow insert error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	incrFollowNum(ctx, uid, followUid, 1)
+	return nil
+}
+
+// Unfollow uid 取消关注 followUid
+func (UserFollowLogic) Unfollow(ctx context.Context, uid, followUid int) error {
+	objLog := GetLogger(ctx)
+
+	result, err := db.GetCollection("user_follow").DeleteOne(ctx, bson.M{"uid": uid, "follow_uid": followUid})
+	if err != nil {
+		objLog.Errorln("UserFollowLogic Unfollow error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("还没有关注该用户")
+	}
+
+	incrFollowNum(ctx, uid, followUid, -1)
+	return nil
+}
+
+// IsFollowing uid 是否关注了 followUid
+func (UserFollowLogic) IsFollowing(ctx context.Context, uid, followUid int) bool {
+	if uid == 0 || followUid == 0 {
+		return false
+	}
+
+	total, err := db.GetCollection("user_follow").CountDocuments(ctx, bson.M{"uid": uid, "follow_uid": followUid})
+	if err != nil {
+		logger.Errorln("UserFollowLogic IsFollowing error:", err)
+		return false
+	}
+	return total > 0
+}
+
+// FindFollowing uid 关注的人，按关注时间倒序
+func (self UserFollowLogic) FindFollowing(ctx context.Context, uid int, paginator *Paginator) []*model.User {
+	return self.findUsers(ctx, bson.M{"uid": uid}, "follow_uid", paginator)
+}
+
+// FindFollowers 关注 uid 的人（粉丝），按关注时间倒序
+func (self UserFollowLogic) FindFollowers(ctx context.Context, uid int, paginator *Paginator) []*model.User {
+	return self.findUsers(ctx, bson.M{"follow_uid": uid}, "uid", paginator)
+}
+
+// FollowingUids uid 关注的所有用户 uid（最多 maxFollowingUids 个），用于可见性判断
+func (UserFollowLogic) FollowingUids(ctx context.Context, uid int) []int {
+	uids := make([]int, 0)
+	if uid == 0 {
+		return uids
+	}
+
+	follows := make([]*model.UserFollow, 0)
+	opts := options.Find().SetProjection(bson.M{"follow_uid": 1}).SetLimit(maxFollowingUids)
+	cursor, err := db.GetCollection("user_follow").Find(ctx, bson.M{"uid": uid}, opts)
+	if err != nil {
+		logger.Errorln("UserFollowLogic FollowingUids error:", err)
+		return uids
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &follows); err != nil {
+		logger.Errorln("UserFollowLogic FollowingUids decode error:", err)
+		return uids
+	}
+
+	for _, follow := range follows {
+		uids = append(uids, follow.FollowUid)
+	}
+	return uids
+}
+
+// FollowNode uid 关注节点 nid
+func (UserFollowLogic) FollowNode(ctx context.Context, uid, nid int) error {
+	objLog := GetLogger(ctx)
+
+	node := DefaultNode.FindOne(nid)
+	if node.Nid == 0 {
+		return errors.New("节点不存在")
+	}
+
+	id, err := db.NextID("node_follow")
+	if err != nil {
+		objLog.Errorln("UserFollowLogic FollowNode NextID error:", err)
+		return errors.New("内部服务错误")
+	}
+
+	follow := &model.NodeFollow{
+		Id:        id,
+		Uid:       uid,
+		Nid:       nid,
+		CreatedAt: time.Now(),
+	}
+	_, err = db.GetCollection("node_follow").InsertOne(ctx, follow)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("已经关注了该节点")
+		}
+		objLog.Errorln("UserFollowLogic FollowNode insert error:", err)
+		return errors.New("内部服务错误")
+	}
+	return nil
+}
+
+// UnfollowNode uid 取消关注节点 nid
+func (UserFollowLogic) UnfollowNode(ctx context.Context, uid, nid int) error {
+	objLog := GetLogger(ctx)
+
+	result, err := db.GetCollection("node_follow").DeleteOne(ctx, bson.M{"uid": uid, "nid": nid})
+	if err != nil {
+		objLog.Errorln("UserFollowLogic UnfollowNode error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("还没有关注该节点")
+	}
+	return nil
+}
+
+// FollowingNids uid 关注的所有节点
+func (UserFollowLogic) FollowingNids(ctx context.Context, uid int) []int {
+	nids := make([]int, 0)
+	if uid == 0 {
+		return nids
+	}
+
+	follows := make([]*model.NodeFollow, 0)
+	opts := options.Find().SetProjection(bson.M{"nid": 1})
+	cursor, err := db.GetCollection("node_follow").Find(ctx, bson.M{"uid": uid}, opts)
+	if err != nil {
+		logger.Errorln("UserFollowLogic FollowingNids error:", err)
+		return nids
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &follows); err != nil {
+		logger.Errorln("UserFollowLogic FollowingNids decode error:", err)
+		return nids
+	}
+
+	for _, follow := range follows {
+		nids = append(nids, follow.Nid)
+	}
+	return nids
+}
+
+func (UserFollowLogic) findUsers(ctx context.Context, filter bson.M, uidField string, paginator *Paginator) []*model.User {
+	objLog := GetLogger(ctx)
+
+	total, err := db.GetCollection("user_follow").CountDocuments(ctx, filter)
+	if err != nil {
+		objLog.Errorln("UserFollowLogic findUsers count error:", err)
+		return nil
+	}
+	paginator.SetTotal(total)
+
+	follows := make([]*model.UserFollow, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetSkip(int64(paginator.Offset())).SetLimit(int64(paginator.PerPage()))
+	cursor, err := db.GetCollection("user_follow").Find(ctx, filter, opts)
+	if err != nil {
+		objLog.Errorln("UserFollowLogic findUsers error:", err)
+		return nil
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &follows); err != nil {
+		objLog.Errorln("UserFollowLogic findUsers decode error:", err)
+		return nil
+	}
+
+	uids := make([]int, 0, len(follows))
+	for _, follow := range follows {
+		if uidField == "uid" {
+			uids = append(uids, follow.Uid)
+		} else {
+			uids = append(uids, follow.FollowUid)
+		}
+	}
+
+	userMap := DefaultUser.FindUserInfos(ctx, uids)
+	users := make([]*model.User, 0, len(uids))
+	for _, uid := range uids {
+		if user, ok := userMap[uid]; ok {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+func incrFollowNum(ctx context.Context, uid, followUid, num int) {
+	coll := db.GetCollection("user_info")
+	if _, err := coll.UpdateOne(ctx, bson.M{"_id": uid}, bson.M{"$inc": bson.M{"follow_num": num}}); err != nil {
+		logger.Errorln("incr follow_num error:", err)
+	}
+	if _, err := coll.UpdateOne(ctx, bson.M{"_id": followUid}, bson.M{"$inc": bson.M{"fans_num": num}}); err != nil {
+		logger.Errorln("incr fans_num error:", err)
+	}
+}
+
+// topicVisibleFilter 在 filter 上加上当前用户可见的条件：关注可见的主题只有作者和关注了作者的人可见，
+// 自己可见的主题只有作者可见
+func topicVisibleFilter(ctx context.Context, filter bson.M) bson.M {
+	restricted := bson.M{"permission": bson.M{"$nin": []int{model.PermissionFollow, model.PermissionOnlyMe}}}
+
+	me, ok := ctx.Value("user").(*model.Me)
+	if !ok || me.Uid == 0 {
+		return appendAndFilter(filter, restricted)
+	}
+	if me.IsRoot {
+		return filter
+	}
+
+	return appendAndFilter(filter, bson.M{"$or": []bson.M{
+		restricted,
+		{"uid": me.Uid},
+		{"permission": model.PermissionFollow, "uid": bson.M{"$in": DefaultUserFollow.FollowingUids(ctx, me.Uid)}},
+	}})
+}
+
+// publicTopicFilter 不区分用户的地方（侧边栏、最新列表等）只展示所有人可见的主题
+func publicTopicFilter(filter bson.M) bson.M {
+	return appendAndFilter(filter, bson.M{"permission": bson.M{"$nin": []int{model.PermissionFollow, model.PermissionOnlyMe}}})
+}
+
+// CanViewTopic 当前用户能否查看主题
+func CanViewTopic(ctx context.Context, topic *model.Topic) bool {
+	if !topic.Restricted() {
+		return true
+	}
+
+	me, ok := ctx.Value("user").(*model.Me)
+	if !ok || me.Uid == 0 {
+		return false
+	}
+	if me.IsRoot || me.Uid == topic.Uid {
+		return true
+	}
+
+	return topic.Permission == model.PermissionFollow && DefaultUserFollow.IsFollowing(ctx, me.Uid, topic.Uid)
+}
+
+func appendAndFilter(filter, cond bson.M) bson.M {
+	if and, ok := filter["$and"].([]bson.M); ok {
+		filter["$and"] = append(and, cond)
+	} else {
+		filter["$and"] = []bson.M{cond}
+	}
+	return filter
+}
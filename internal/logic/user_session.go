@@ -0,0 +1,200 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/studygolang/studygolang/db"
+	"github.com/studygolang/studygolang/internal/model"
+
+	"github.com/polaris1119/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// 记住登录状态时会话的有效期，和 cookie 的默认 MaxAge 一致
+	rememberSessionTTL = 30 * 24 * time.Hour
+	// 未记住登录状态时，会话闲置超过该时间即失效
+	idleSessionTTL = 24 * time.Hour
+	// 最后访问时间的更新间隔，避免每个请求都写库
+	sessionTouchInterval = time.Minute
+)
+
+var ErrSessionInvalid = errors.New("登录已失效，请重新登录")
+
+type UserSessionLogic struct{}
+
+var DefaultUserSession = UserSessionLogic{}
+
+// Create 为用户创建一个登录会话，返回写入 cookie 的会话 ID 明文
+func (UserSessionLogic) Create(ctx context.Context, username, userAgent, ip string, remember bool) (string, error) {
+	objLog := GetLogger(ctx)
+
+	userLogin := &model.UserLogin{}
+	err := db.GetCollection("user_login").FindOne(ctx, bson.M{"username": username}).Decode(userLogin)
+	if err != nil {
+		objLog.Errorln("UserSessionLogic Create find user error:", username, err)
+		return "", errors.New("用户不存在")
+	}
+
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		objLog.Errorln("UserSessionLogic Create gen sid error:", err)
+		return "", errors.New("内部服务错误")
+	}
+	sid := hex.EncodeToString(buf)
+
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	now := time.Now()
+	session := &model.UserSession{
+		Uid:        userLogin.Uid,
+		Username:   userLogin.Username,
+		SidHash:    hashAccessToken(sid),
+		UserAgent:  userAgent,
+		Ip:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		Remember:   remember,
+	}
+	if remember {
+		session.ExpireAt = now.Add(rememberSessionTTL)
+	} else {
+		session.ExpireAt = now.Add(idleSessionTTL)
+	}
+
+	session.Id, err = db.NextID("user_session")
+	if err != nil {
+		objLog.Errorln("UserSessionLogic Create NextID error:", err)
+		return "", errors.New("内部服务错误")
+	}
+
+	_, err = db.GetCollection("user_session").InsertOne(ctx, session)
+	if err != nil {
+		objLog.Errorln("UserSessionLogic Create insert error:", err)
+		return "", errors.New("内部服务错误")
+	}
+
+	return sid, nil
+}
+
+// Authenticate 通过 cookie 中的会话 ID 获取会话，并按需更新最后访问时间和 IP
+func (UserSessionLogic) Authenticate(ctx context.Context, sid, ip string) (*model.UserSession, error) {
+	if sid == "" {
+		return nil, ErrSessionInvalid
+	}
+
+	session := &model.UserSession{}
+	err := db.GetCollection("user_session").FindOne(ctx, bson.M{"sid_hash": hashAccessToken(sid)}).Decode(session)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			logger.Errorln("UserSessionLogic Authenticate error:", err)
+		}
+		return nil, ErrSessionInvalid
+	}
+
+	if session.Expired() {
+		return nil, ErrSessionInvalid
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval || session.Ip != ip {
+		setData := bson.M{
+			"last_seen_at": now,
+			"ip":           ip,
+		}
+		if !session.Remember {
+			setData["expire_at"] = now.Add(idleSessionTTL)
+		}
+
+		go func(id int) {
+			_, err := db.GetCollection("user_session").UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$set": setData})
+			if err != nil {
+				logger.Errorln("UserSessionLogic touch session error:", err)
+			}
+		}(session.Id)
+	}
+
+	return session, nil
+}
+
+// FindByUid 获取用户当前有效的所有会话，currentSid 对应的会话会被标记出来
+func (UserSessionLogic) FindByUid(ctx context.Context, uid int, currentSid string) []*model.UserSession {
+	objLog := GetLogger(ctx)
+
+	sessions := make([]*model.UserSession, 0)
+	filter := bson.M{"uid": uid, "expire_at": bson.M{"$gt": time.Now()}}
+	cursor, err := db.GetCollection("user_session").Find(ctx, filter, options.Find().SetSort(bson.M{"last_seen_at": -1}))
+	if err != nil {
+		objLog.Errorln("UserSessionLogic FindByUid error:", err)
+		return sessions
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &sessions); err != nil {
+		objLog.Errorln("UserSessionLogic FindByUid decode error:", err)
+		return sessions
+	}
+
+	if currentSid != "" {
+		currentHash := hashAccessToken(currentSid)
+		for _, session := range sessions {
+			session.Current = session.SidHash == currentHash
+		}
+	}
+	return sessions
+}
+
+// Revoke 注销用户的某个会话（远程登出）
+func (UserSessionLogic) Revoke(ctx context.Context, uid, id int) error {
+	objLog := GetLogger(ctx)
+
+	result, err := db.GetCollection("user_session").DeleteOne(ctx, bson.M{"_id": id, "uid": uid})
+	if err != nil {
+		objLog.Errorln("UserSessionLogic Revoke error:", err)
+		return errors.New("内部服务错误")
+	}
+	if result.DeletedCount == 0 {
+		return NotFoundErr
+	}
+	return nil
+}
+
+// RevokeBySid 注销 cookie 中的会话，用于退出登录
+func (UserSessionLogic) RevokeBySid(ctx context.Context, sid string) error {
+	if sid == "" {
+		return nil
+	}
+
+	_, err := db.GetCollection("user_session").DeleteOne(ctx, bson.M{"sid_hash": hashAccessToken(sid)})
+	if err != nil {
+		GetLogger(ctx).Errorln("UserSessionLogic RevokeBySid error:", err)
+	}
+	return err
+}
+
+// RevokeAll 注销用户的所有会话；exceptSid 不为空时保留该会话（一般是当前会话）
+func (UserSessionLogic) RevokeAll(ctx context.Context, uid int, exceptSid string) error {
+	filter := bson.M{"uid": uid}
+	if exceptSid != "" {
+		filter["sid_hash"] = bson.M{"$ne": hashAccessToken(exceptSid)}
+	}
+
+	_, err := db.GetCollection("user_session").DeleteMany(ctx, filter)
+	if err != nil {
+		GetLogger(ctx).Errorf("注销用户 【%d】 所有会话失败：%s", uid, err)
+	}
+	return err
+}
@@ -34,6 +34,10 @@ var DefaultWiki = WikiLogic{}
 func (WikiLogic) Create(ctx context.Context, me *model.Me, form url.Values) error {
 	objLog := GetLogger(ctx)
 
+	if err := CheckPublish(ctx, NewPublishAction(ctx, me, PublishKindCreate, model.TypeWiki, form)); err != nil {
+		return err
+	}
+
 	wiki := &model.Wiki{}
 	err := schemaDecoder.Decode(wiki, form)
 	if err != nil {
@@ -64,11 +68,24 @@ func (WikiLogic) Create(ctx context.Context, me *model.Me, form url.Values) erro
 func (self WikiLogic) Modify(ctx context.Context, me *model.Me, form url.Values) error {
 	objLog := GetLogger(ctx)
 
+	if err := CheckPublish(ctx, NewPublishAction(ctx, me, PublishKindModify, model.TypeWiki, form)); err != nil {
+		return err
+	}
+
+	summary, err := DefaultRevision.checkSummary(form.Get("summary"))
+	if err != nil {
+		return err
+	}
+
 	id := goutils.MustInt(form.Get("id"))
 	wiki := self.FindById(ctx, id)
-	if !CanEdit(me, wiki) {
+	if wiki == nil {
+		return NotFoundErr
+	}
+	if !CanEdit(ctx, me, wiki) {
 		return errors.New("没有权限")
 	}
+	before := DefaultRevision.snapshot(wiki)
 
 	if wiki.Uid != me.Uid {
 		hasExists := false
@@ -89,16 +106,19 @@ func (self WikiLogic) Modify(ctx context.Context, me *model.Me, form url.Values)
 	wiki.Title = form.Get("title")
 	wiki.Content = form.Get("content")
 
-	_, err := db.GetCollection("wiki").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+	_, err = db.GetCollection("wiki").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
 		"title":   wiki.Title,
 		"content": wiki.Content,
 		"cuid":    wiki.Cuid,
+		"mtime":   time.Now(),
 	}})
 	if err != nil {
 		objLog.Errorf("更新wiki 【%d】 信息失败：%s\n", id, err)
 		return err
 	}
 
+	DefaultRevision.record(ctx, model.TypeWiki, id, before, me.Uid, wiki.Title, wiki.Content, summary, 0)
+
 	go modifyObservable.NotifyObservers(me.Uid, model.TypeWiki, wiki.Id)
 
 	return nil
@@ -0,0 +1,77 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// 个人访问令牌（Personal Access Token）的权限范围
+const (
+	ScopeRead          = "read"           // 读取（所有 GET 请求）
+	ScopeTopicsWrite   = "topics:write"   // 发布、修改主题
+	ScopeArticlesWrite = "articles:write" // 发布、修改文章、资源、项目、图书等
+	ScopeCommentsWrite = "comments:write" // 评论、喜欢、收藏
+	ScopeUserWrite     = "user:write"     // 修改个人资料、关注用户、兑换物品等账号操作
+	ScopeMessages      = "messages"       // 站内短消息
+	ScopeAdmin         = "admin"          // 管理操作，拥有全部权限
+)
+
+// AllScopes 所有可选的权限范围
+var AllScopes = []string{
+	ScopeRead,
+	ScopeTopicsWrite,
+	ScopeArticlesWrite,
+	ScopeCommentsWrite,
+	ScopeUserWrite,
+	ScopeMessages,
+	ScopeAdmin,
+}
+
+// WriteScope 发布、修改某类内容需要的权限范围
+func WriteScope(objtype int) string {
+	switch objtype {
+	case TypeTopic:
+		return ScopeTopicsWrite
+	case TypeComment:
+		return ScopeCommentsWrite
+	}
+	return ScopeArticlesWrite
+}
+
+// AccessTokenPrefix 令牌明文的前缀，方便识别和扫描泄露
+const AccessTokenPrefix = "sgp_"
+
+// AccessToken 个人访问令牌，用于脚本调用 /api/v1；库中只保存令牌的 sha256
+type AccessToken struct {
+	Id         int       `json:"id" bson:"_id"`
+	Uid        int       `json:"uid" bson:"uid"`
+	Name       string    `json:"name" bson:"name"`
+	TokenHash  string    `json:"-" bson:"token_hash"`
+	TokenTail  string    `json:"token_tail" bson:"token_tail"` // 明文最后 4 位，用于列表展示
+	Scopes     []string  `json:"scopes" bson:"scopes"`
+	ExpireAt   time.Time `json:"expire_at" bson:"expire_at"` // 零值表示永不过期
+	LastUsedAt time.Time `json:"last_used_at" bson:"last_used_at"`
+	LastUsedIp string    `json:"last_used_ip" bson:"last_used_ip"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+}
+
+func (*AccessToken) CollectionName() string {
+	return "access_token"
+}
+
+// Expired 令牌是否已过期
+func (this *AccessToken) Expired() bool {
+	return !this.ExpireAt.IsZero() && time.Now().After(this.ExpireAt)
+}
+
+// HasScope 令牌是否拥有某个权限范围；admin 拥有全部权限
+func (this *AccessToken) HasScope(scope string) bool {
+	for _, s := range this.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
@@ -19,10 +19,11 @@ const (
 	ArticleStatusNew = iota
 	ArticleStatusOnline
 	ArticleStatusOffline
+	ArticleStatusScheduled // 定时发布，到发布时间前不展示
 )
 
 var LangSlice = []string{"中文", "英文"}
-var ArticleStatusSlice = []string{"未上线", "已上线", "已下线"}
+var ArticleStatusSlice = []string{"未上线", "已上线", "已下线", "定时发布"}
 
 // 抓取的文章信息
 type Article struct {
@@ -53,6 +54,8 @@ type Article struct {
 	OpUser        string    `json:"op_user" bson:"op_user"`
 	Ctime         OftenTime `json:"ctime" bson:"ctime"`
 	Mtime         OftenTime `json:"mtime" bson:"mtime"`
+	// PublishAt 定时发布的时间，发布后清空
+	PublishAt time.Time `json:"publish_at,omitempty" bson:"publish_at,omitempty"`
 
 	IsSelf bool  `json:"is_self" bson:"-"`
 	User   *User `json:"-" bson:"-"`
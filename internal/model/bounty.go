@@ -0,0 +1,41 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// 悬赏状态
+const (
+	BountyStatusOpen     = iota // 悬赏中，铜币由系统托管
+	BountyStatusAccepted        // 采纳了答案，铜币给了回答者
+	BountyStatusLiked           // 到期未采纳，铜币给了最多赞的回答
+	BountyStatusRefunded        // 到期未采纳，铜币退回给提问者
+)
+
+// 到期未采纳时的处理规则（配置项 bounty_expire_rule）
+const (
+	BountyExpireRefund = iota // 退回给提问者
+	BountyExpireLiked         // 给最多赞的回答，没有符合条件的回答时退回
+)
+
+// Bounty 问答主题的悬赏，一个主题只能悬赏一次
+type Bounty struct {
+	Id       int       `json:"id" bson:"_id"`
+	Tid      int       `json:"tid" bson:"tid"`
+	Uid      int       `json:"uid" bson:"uid"` // 悬赏人（提问者）
+	Amount   int       `json:"amount" bson:"amount"`
+	Deadline time.Time `json:"deadline" bson:"deadline"`
+	Status   int       `json:"status" bson:"status"`
+	// 得到悬赏的回复及其作者，退回时为 0
+	WinnerCid int       `json:"winner_cid" bson:"winner_cid"`
+	WinnerUid int       `json:"winner_uid" bson:"winner_uid"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	SettledAt time.Time `json:"settled_at,omitempty" bson:"settled_at,omitempty"`
+}
+
+func (*Bounty) CollectionName() string {
+	return "bounty"
+}
@@ -21,6 +21,8 @@ const (
 	TypeComment = 100
 	// 置顶
 	TypeTop = 101
+	// 私信（举报用）
+	TypeMessage = 102
 )
 
 const (
@@ -61,8 +63,16 @@ type Comment struct {
 	Uid     int       `json:"uid" bson:"uid"`
 	Floor   int       `json:"floor" bson:"floor"`
 	Flag    int       `json:"flag" bson:"flag"`
+	Likenum int       `json:"likenum" bson:"likenum"`
 	Ctime   OftenTime `json:"ctime" bson:"ctime"`
 
+	// ParentCid 回复的评论，0 表示直接评论对象
+	ParentCid int `json:"parent_cid" bson:"parent_cid"`
+	// Path 从顶层评论到自己的 cid（定长），如 0000000012/0000000034/，用前缀查询整棵子树
+	Path    string `json:"-" bson:"path"`
+	Depth   int    `json:"depth" bson:"depth"`     // 顶层评论为 0
+	Replies int    `json:"replies" bson:"replies"` // 直接回复数
+
 	Objinfo    map[string]interface{} `json:"objinfo" bson:"-"`
 	ReplyFloor int                    `json:"reply_floor" bson:"-"`
 }
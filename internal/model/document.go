@@ -47,6 +47,9 @@ type Document struct {
 
 	Nid int `json:"nid"`
 
+	// 问答模式的主题是否已解决
+	Solved bool `json:"solved"`
+
 	HlTitle   string `json:",omitempty"` // 高亮的标题
 	HlContent string `json:",omitempty"` // 高亮的内容
 }
@@ -87,7 +90,8 @@ func NewDocument(object interface{}, objectExt interface{}) *Document {
 			Cmtnum:  cmtnum,
 			Likenum: likenum,
 
-			Nid: objdoc.Nid,
+			Nid:    objdoc.Nid,
+			Solved: objdoc.Solved(),
 
 			Top:           objdoc.Top,
 			Lastreplyuid:  objdoc.Lastreplyuid,
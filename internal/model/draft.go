@@ -0,0 +1,29 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// Draft 主题、文章、wiki 的草稿（自动保存），只有作者自己可见。
+// Objid 为 0 表示新发布的内容，否则是对已有内容的修改，同一内容每人只有一份草稿
+type Draft struct {
+	Id      int    `json:"id" bson:"_id"`
+	Uid     int    `json:"uid" bson:"uid"`
+	Objtype int    `json:"objtype" bson:"objtype"`
+	Objid   int    `json:"objid" bson:"objid"`
+	Title   string `json:"title" bson:"title"`
+	Content string `json:"content,omitempty" bson:"content"`
+	// Form 发布时需要的其他字段，如主题的 nid、permission，发布时原样提交
+	Form      map[string]string `json:"form,omitempty" bson:"form"`
+	CreatedAt time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at" bson:"updated_at"`
+	// ExpireAt 过期时间，每次保存顺延，过期后由 TTL 索引自动删除
+	ExpireAt time.Time `json:"expire_at" bson:"expire_at"`
+}
+
+func (*Draft) CollectionName() string {
+	return "draft"
+}
@@ -6,14 +6,46 @@
 
 package model
 
+import "time"
+
+// 默认收藏夹，每个用户都有，不需要创建，不能修改和删除，始终私有
+const (
+	FavoriteFolderDefault     = 0
+	FavoriteFolderDefaultName = "默认收藏夹"
+)
+
 // 用户收藏（用户可以收藏文章、话题、资源等）
 type Favorite struct {
-	Uid     int    `json:"uid" bson:"uid"`
-	Objtype int    `json:"objtype" bson:"objtype"`
-	Objid   int    `json:"objid" bson:"objid"`
-	Ctime   string `json:"ctime" bson:"ctime"`
+	Uid      int `json:"uid" bson:"uid"`
+	Objtype  int `json:"objtype" bson:"objtype"`
+	Objid    int `json:"objid" bson:"objid"`
+	FolderId int `json:"folder_id" bson:"folder_id"`
+	// Title 收藏时内容的标题，用于搜索和导出
+	Title string    `json:"title" bson:"title"`
+	Note  string    `json:"note" bson:"note"`
+	Ctime OftenTime `json:"ctime" bson:"ctime"`
+
+	Uri string `json:"uri" bson:"-"`
 }
 
 func (*Favorite) CollectionName() string {
 	return "favorites"
 }
+
+// FavoriteFolder 收藏夹。公开的收藏夹其他人可以查看
+type FavoriteFolder struct {
+	Id        int       `json:"id" bson:"_id"`
+	Uid       int       `json:"uid" bson:"uid"`
+	Name      string    `json:"name" bson:"name"`
+	Intro     string    `json:"intro" bson:"intro"`
+	Public    bool      `json:"public" bson:"public"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+
+	// 收藏数
+	Num int `json:"num" bson:"-"`
+}
+
+func (*FavoriteFolder) CollectionName() string {
+	return "favorite_folder"
+}
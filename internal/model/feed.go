@@ -48,6 +48,10 @@ func PublishFeed(object interface{}, objectExt interface{}, me *Me) {
 	var feed *Feed
 	switch objdoc := object.(type) {
 	case *Topic:
+		if objdoc.Restricted() {
+			return
+		}
+
 		node := &TopicNode{}
 		err := db.GetCollection("topics_node").FindOne(context.Background(), bson.M{"_id": objdoc.Nid}).Decode(node)
 		if err == nil && !node.ShowIndex {
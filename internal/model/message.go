@@ -47,6 +47,8 @@ const (
 	MsgtypePublishAtMe = 11 // 发布时提到我
 
 	MsgtypeSubjectContribute = 12 //专栏投稿
+	MsgtypeModeration        = 13 // 内容审核结果
+	MsgtypeReportResult      = 14 // 举报处理结果
 )
 
 // 系统消息
@@ -31,6 +31,12 @@ const (
 	MissionTypeModify = 65
 	// 被回复
 	MissionTypeReplied = 70
+	// 回答被采纳
+	MissionTypeAnswer = 71
+	// 悬赏：托管、得到悬赏、退回
+	MissionTypeBounty       = 72
+	MissionTypeBountyAward  = 73
+	MissionTypeBountyRefund = 74
 	// 额外赠予
 	MissionTypeAward = 80
 	// 活跃奖励
@@ -0,0 +1,68 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// 进入审核队列的来源
+const (
+	ModerationSourceSensitive = "sensitive" // 命中需要审核的敏感词
+	ModerationSourceNewUser   = "new_user"  // 新用户发布
+	ModerationSourceReport    = "report"    // 用户举报
+)
+
+var ModerationSourceMap = map[string]string{
+	ModerationSourceSensitive: "敏感词",
+	ModerationSourceNewUser:   "新用户",
+	ModerationSourceReport:    "举报",
+}
+
+// 审核状态
+const (
+	ModerationStatePending = iota
+	ModerationStateApproved
+	ModerationStateRejected
+)
+
+// ModerationItem 审核队列中的一条内容。同一内容同时只有一条待审核记录，多个来源合并到 Sources 中
+type ModerationItem struct {
+	Id      int      `json:"id" bson:"_id"`
+	Objtype int      `json:"objtype" bson:"objtype"` // model.TypeXXX，评论为 TypeComment
+	Objid   int      `json:"objid" bson:"objid"`
+	Uid     int      `json:"uid" bson:"uid"` // 内容作者
+	Sources []string `json:"sources" bson:"sources"`
+	Reason  string   `json:"reason" bson:"reason"`
+	// Held 内容是否被隐藏（审核通过前不展示）；举报进来的内容默认不隐藏
+	Held bool `json:"held" bson:"held"`
+	// Created 是否是新发布的内容，审核通过时需要补发动态、通知等
+	Created bool   `json:"created" bson:"created"`
+	Title   string `json:"title" bson:"title"`
+	Content string `json:"content" bson:"content"`
+	// PrevTitle、PrevContent 修改送审前的标题和内容，拒绝修改时恢复
+	PrevTitle   string    `json:"prev_title" bson:"prev_title,omitempty"`
+	PrevContent string    `json:"prev_content" bson:"prev_content,omitempty"`
+	State       int       `json:"state" bson:"state"`
+	OpUid       int       `json:"op_uid" bson:"op_uid"`
+	OpReason    string    `json:"op_reason" bson:"op_reason"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+
+	User *User `json:"user" bson:"-"`
+}
+
+func (*ModerationItem) CollectionName() string {
+	return "moderation_item"
+}
+
+// Reported 是否被用户举报
+func (this *ModerationItem) Reported() bool {
+	for _, source := range this.Sources {
+		if source == ModerationSourceReport {
+			return true
+		}
+	}
+	return false
+}
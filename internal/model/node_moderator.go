@@ -0,0 +1,57 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// NodeModerator 节点版主，管理该节点及其子节点下的主题和评论
+type NodeModerator struct {
+	Id        int       `json:"id" bson:"_id"`
+	Nid       int       `json:"nid" bson:"nid"`
+	Uid       int       `json:"uid" bson:"uid"`
+	Username  string    `json:"username" bson:"username"`
+	OpUid     int       `json:"op_uid" bson:"op_uid"` // 任命人
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+func (*NodeModerator) CollectionName() string {
+	return "node_moderator"
+}
+
+// 版主（管理）操作
+const (
+	ModActionEdit          = "edit"           // 编辑主题
+	ModActionMove          = "move"           // 移动主题到其他节点
+	ModActionLock          = "lock"           // 锁定主题（禁止回复）
+	ModActionUnlock        = "unlock"         // 解除锁定
+	ModActionNodeTop       = "node_top"       // 节点内置顶
+	ModActionNodeUntop     = "node_untop"     // 取消节点内置顶
+	ModActionDelete        = "delete"         // 删除主题
+	ModActionDeleteComment = "delete_comment" // 删除评论
+	ModActionAccept        = "accept"         // 采纳答案
+	ModActionAssign        = "assign"         // 任命版主
+	ModActionRevoke        = "revoke"         // 撤销版主
+)
+
+// ModeratorLog 版主操作记录
+type ModeratorLog struct {
+	Id        int       `json:"id" bson:"_id"`
+	Nid       int       `json:"nid" bson:"nid"`
+	Uid       int       `json:"uid" bson:"uid"` // 操作人
+	Username  string    `json:"username" bson:"username"`
+	Action    string    `json:"action" bson:"action"`
+	Objtype   int       `json:"objtype" bson:"objtype"`
+	Objid     int       `json:"objid" bson:"objid"`
+	TargetUid int       `json:"target_uid" bson:"target_uid"` // 被操作内容的作者，或被任命/撤销的用户
+	Reason    string    `json:"reason" bson:"reason"`
+	Detail    string    `json:"detail" bson:"detail"`
+	Ip        string    `json:"ip" bson:"ip"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+func (*ModeratorLog) CollectionName() string {
+	return "moderator_log"
+}
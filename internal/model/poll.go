@@ -0,0 +1,67 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// 投票结果什么时候可见
+const (
+	PollResultAlways = iota // 始终可见
+	PollResultVoted         // 投票后（或投票结束后）可见
+)
+
+// PollOption 投票选项，Id 即在 Options 中的下标，创建后不变
+type PollOption struct {
+	Id    int    `json:"id" bson:"id"`
+	Title string `json:"title" bson:"title"`
+	Votes int    `json:"votes" bson:"votes"`
+}
+
+// Poll 主题中的投票，一个主题最多一个
+type Poll struct {
+	Id       int  `json:"id" bson:"_id"`
+	Tid      int  `json:"tid" bson:"tid"`
+	Uid      int  `json:"uid" bson:"uid"`
+	Multiple bool `json:"multiple" bson:"multiple"`
+	// MaxChoices 多选时最多选几项，0 表示不限
+	MaxChoices  int           `json:"max_choices" bson:"max_choices"`
+	Options     []*PollOption `json:"options" bson:"options"`
+	Voters      int           `json:"voters" bson:"voters"` // 投票人数
+	ShowResult  int           `json:"show_result" bson:"show_result"`
+	AllowChange bool          `json:"allow_change" bson:"allow_change"` // 是否允许改票
+	// CloseAt 投票截止时间，零值表示不截止
+	CloseAt   time.Time `json:"close_at,omitempty" bson:"close_at,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+
+	// 当前用户的选择，没投票时为空
+	MyChoices []int `json:"my_choices,omitempty" bson:"-"`
+	// 结果对当前用户不可见（此时 Votes 都为 0）
+	Hidden bool `json:"hidden" bson:"-"`
+	Closed bool `json:"closed" bson:"-"`
+}
+
+func (*Poll) CollectionName() string {
+	return "poll"
+}
+
+// IsClosed 投票是否已截止
+func (this *Poll) IsClosed() bool {
+	return !this.CloseAt.IsZero() && time.Now().After(this.CloseAt)
+}
+
+// PollVote 用户的一次投票，同一投票每人一条，改票时更新 Choices
+type PollVote struct {
+	Id        int       `json:"id" bson:"_id"`
+	PollId    int       `json:"poll_id" bson:"poll_id"`
+	Uid       int       `json:"uid" bson:"uid"`
+	Choices   []int     `json:"choices" bson:"choices"` // 选中的选项 Id，升序
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+func (*PollVote) CollectionName() string {
+	return "poll_vote"
+}
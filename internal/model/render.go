@@ -0,0 +1,19 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+// TocItem 目录中的一项，对应一个标题
+type TocItem struct {
+	Level int    `json:"level"`
+	Id    string `json:"id"` // 标题的锚点
+	Title string `json:"title"`
+}
+
+// Rendered 服务端渲染并过滤后的内容，按内容的哈希缓存
+type Rendered struct {
+	HTML string     `json:"html"`
+	Toc  []*TocItem `json:"toc"`
+}
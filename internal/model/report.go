@@ -0,0 +1,85 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// 举报原因
+const (
+	ReportReasonSpam       = "spam"       // 广告、垃圾信息
+	ReportReasonAbuse      = "abuse"      // 辱骂、人身攻击
+	ReportReasonPorn       = "porn"       // 色情低俗
+	ReportReasonIllegal    = "illegal"    // 违法违规
+	ReportReasonPlagiarism = "plagiarism" // 抄袭、未注明转载
+	ReportReasonOther      = "other"      // 其他
+)
+
+var ReportReasonMap = map[string]string{
+	ReportReasonSpam:       "广告、垃圾信息",
+	ReportReasonAbuse:      "辱骂、人身攻击",
+	ReportReasonPorn:       "色情低俗",
+	ReportReasonIllegal:    "违法违规",
+	ReportReasonPlagiarism: "抄袭、未注明转载",
+	ReportReasonOther:      "其他",
+}
+
+// 举报处理状态
+const (
+	ReportStatePending = iota // 未处理
+	ReportStateValid          // 举报属实
+	ReportStateInvalid        // 举报不属实
+)
+
+// Report 一条举报。同一用户对同一内容只能举报一次
+type Report struct {
+	Id         int       `json:"id" bson:"_id"`
+	Objtype    int       `json:"objtype" bson:"objtype"` // model.TypeXXX，评论为 TypeComment，私信为 TypeMessage
+	Objid      int       `json:"objid" bson:"objid"`
+	Uid        int       `json:"uid" bson:"uid"`               // 举报人
+	TargetUid  int       `json:"target_uid" bson:"target_uid"` // 被举报内容的作者
+	Reason     string    `json:"reason" bson:"reason"`
+	Remark     string    `json:"remark" bson:"remark"`
+	Weight     float64   `json:"weight" bson:"weight"` // 计入阈值的权重，经常误报的用户权重低
+	State      int       `json:"state" bson:"state"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	ResolvedAt time.Time `json:"resolved_at" bson:"resolved_at"`
+}
+
+func (*Report) CollectionName() string {
+	return "report"
+}
+
+// ReportStat 同一内容的举报汇总
+type ReportStat struct {
+	Id        int            `json:"id" bson:"_id"`
+	Objtype   int            `json:"objtype" bson:"objtype"`
+	Objid     int            `json:"objid" bson:"objid"`
+	TargetUid int            `json:"target_uid" bson:"target_uid"`
+	Num       int            `json:"num" bson:"num"`     // 举报人数
+	Score     float64        `json:"score" bson:"score"` // 加权后的举报数，和阈值比较
+	Reasons   map[string]int `json:"reasons" bson:"reasons"`
+	Queued    bool           `json:"queued" bson:"queued"` // 已送审核队列
+	Hidden    bool           `json:"hidden" bson:"hidden"` // 已自动隐藏
+	CreatedAt time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at" bson:"updated_at"`
+}
+
+func (*ReportStat) CollectionName() string {
+	return "report_stat"
+}
+
+// Reporter 举报人的信誉：举报属实和不属实的次数
+type Reporter struct {
+	Uid       int       `json:"uid" bson:"_id"`
+	Total     int       `json:"total" bson:"total"`
+	Valid     int       `json:"valid" bson:"valid"`
+	Invalid   int       `json:"invalid" bson:"invalid"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+func (*Reporter) CollectionName() string {
+	return "reporter"
+}
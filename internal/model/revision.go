@@ -0,0 +1,30 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// Revision 主题、文章、wiki 的一个历史版本，每次修改（含管理员修改、回滚）生成一条，不可修改。
+// 第一次修改时会先把修改前的内容保存为版本 1
+type Revision struct {
+	Id      int    `json:"id" bson:"_id"`
+	Objtype int    `json:"objtype" bson:"objtype"`
+	Objid   int    `json:"objid" bson:"objid"`
+	Version int    `json:"version" bson:"version"` // 从 1 开始，同一对象内递增
+	Uid     int    `json:"uid" bson:"uid"`         // 编辑者
+	Title   string `json:"title" bson:"title"`
+	Content string `json:"content,omitempty" bson:"content"`
+	Summary string `json:"summary" bson:"summary"` // 编辑说明
+	// Rollback 回滚到的版本号，不是回滚时为 0
+	Rollback  int       `json:"rollback" bson:"rollback"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+
+	User *User `json:"user,omitempty" bson:"-"`
+}
+
+func (*Revision) CollectionName() string {
+	return "revision"
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// IP 名单类型
+const (
+	RiskListBlock = "block" // 黑名单：不让登录
+	RiskListAllow = "allow" // 白名单：优先于黑名单，如公司、学校的出口 IP
+)
+
+var RiskListMap = map[string]string{
+	RiskListBlock: "黑名单",
+	RiskListAllow: "白名单",
+}
+
+// RiskIP IP 黑白名单中的一条，可以是单个 IP 或 CIDR 网段（IPv4/IPv6）
+type RiskIP struct {
+	Id   int    `json:"id" bson:"_id"`
+	List string `json:"list" bson:"list"`
+	Cidr string `json:"cidr" bson:"cidr"` // 规范化之后的网段，单个 IP 为 /32 或 /128
+	// Reason 加入原因，自动加入的（如发布敏感词被冻结）OpUid 为 0
+	Reason string `json:"reason" bson:"reason"`
+	OpUid  int    `json:"op_uid" bson:"op_uid"`
+	// ExpireAt 过期时间，零值表示永久；过期后由 TTL 索引自动删除
+	ExpireAt  time.Time `json:"expire_at" bson:"expire_at,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+func (*RiskIP) CollectionName() string {
+	return "risk_ip"
+}
+
+// Expired 是否已过期
+func (this *RiskIP) Expired() bool {
+	return !this.ExpireAt.IsZero() && time.Now().After(this.ExpireAt)
+}
+
+// IPSeenUser 最近从某个 IP 访问过的用户，用于排查
+type IPSeenUser struct {
+	Uid      int       `json:"uid"`
+	Username string    `json:"username"`
+	Ip       string    `json:"ip"`
+	Source   string    `json:"source"` // login：最后一次登录，session：登录会话
+	SeenAt   time.Time `json:"seen_at"`
+}
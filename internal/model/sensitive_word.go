@@ -0,0 +1,56 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// 敏感词分类
+const (
+	SensitiveCategoryAd       = "ad"       // 广告
+	SensitiveCategoryPolitics = "politics" // 政治
+	SensitiveCategoryPorn     = "porn"     // 色情
+	SensitiveCategoryAbuse    = "abuse"    // 辱骂
+	SensitiveCategoryOther    = "other"    // 其他
+)
+
+var SensitiveCategoryMap = map[string]string{
+	SensitiveCategoryAd:       "广告",
+	SensitiveCategoryPolitics: "政治",
+	SensitiveCategoryPorn:     "色情",
+	SensitiveCategoryAbuse:    "辱骂",
+	SensitiveCategoryOther:    "其他",
+}
+
+// 命中敏感词后的处理方式，按严重程度从低到高
+const (
+	SensitiveActionMask   = "mask"   // 替换为 *
+	SensitiveActionReview = "review" // 送审，审核通过前不展示；只有主题和评论支持送审，博文、资源等其他内容按拒绝发布处理
+	SensitiveActionReject = "reject" // 拒绝发布
+	SensitiveActionFreeze = "freeze" // 拒绝发布，冻结账号并将 IP 加入黑名单
+)
+
+// SensitiveActionLevel 处理方式的严重程度，多个词命中时取最严重的
+var SensitiveActionLevel = map[string]int{
+	SensitiveActionMask:   1,
+	SensitiveActionReview: 2,
+	SensitiveActionReject: 3,
+	SensitiveActionFreeze: 4,
+}
+
+// SensitiveWord 敏感词
+type SensitiveWord struct {
+	Id        int       `json:"id" bson:"_id"`
+	Word      string    `json:"word" bson:"word"`
+	Category  string    `json:"category" bson:"category"`
+	Action    string    `json:"action" bson:"action"`
+	OpUid     int       `json:"op_uid" bson:"op_uid"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+func (*SensitiveWord) CollectionName() string {
+	return "sensitive_word"
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// Tag 标签。Name 为规范名称，Aliases 为同义词，内容中的同义词都会转换为 Name
+type Tag struct {
+	Id      int      `json:"id" bson:"_id"`
+	Name    string   `json:"name" bson:"name"`
+	Intro   string   `json:"intro" bson:"intro"`
+	Aliases []string `json:"aliases" bson:"aliases"`
+	// Keys 名称和同义词的小写形式，用于查找，有唯一索引
+	Keys []string `json:"-" bson:"keys"`
+	// Counts 各类内容的数量，key 为 model.TypeXXX 的字符串形式
+	Counts    map[string]int `json:"counts" bson:"counts"`
+	Total     int            `json:"total" bson:"total"`
+	Followers int            `json:"followers" bson:"followers"`
+	CreatedAt time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at" bson:"updated_at"`
+}
+
+func (*Tag) CollectionName() string {
+	return "tags"
+}
+
+// TagObject 标签和内容的关联，按标签列出内容时不依赖搜索引擎
+type TagObject struct {
+	Id      int       `json:"id" bson:"_id"`
+	TagId   int       `json:"tag_id" bson:"tag_id"`
+	Objtype int       `json:"objtype" bson:"objtype"`
+	Objid   int       `json:"objid" bson:"objid"`
+	Ctime   time.Time `json:"ctime" bson:"ctime"` // 内容的发布时间，用于排序
+}
+
+func (*TagObject) CollectionName() string {
+	return "tag_object"
+}
+
+// TagFollow 关注的标签
+type TagFollow struct {
+	Id        int       `json:"id" bson:"_id"`
+	Uid       int       `json:"uid" bson:"uid"`
+	TagId     int       `json:"tag_id" bson:"tag_id"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+func (*TagFollow) CollectionName() string {
+	return "tag_follow"
+}
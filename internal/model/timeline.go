@@ -0,0 +1,38 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// 时间线事件类型
+const (
+	TimelinePublish = "publish" // 发布了主题、文章、资源、项目等
+	TimelineComment = "comment" // 发表了（有分量的）评论
+)
+
+// TimelineEvent 首页时间线事件。发布和评论时写入一条（只写一次），
+// 读取时按当前用户关注的人、节点和专栏过滤（fan-out-on-read）
+type TimelineEvent struct {
+	Id         int       `json:"id" bson:"_id"`
+	Action     string    `json:"action" bson:"action"`
+	Uid        int       `json:"uid" bson:"uid"`
+	Objtype    int       `json:"objtype" bson:"objtype"`
+	Objid      int       `json:"objid" bson:"objid"`
+	Cid        int       `json:"cid" bson:"cid"`   // 评论事件的评论 id
+	Nid        int       `json:"nid" bson:"nid"`   // 主题所在节点
+	Sids       []int     `json:"sids" bson:"sids"` // 文章所属专栏
+	Permission int       `json:"permission" bson:"permission"`
+	Title      string    `json:"title" bson:"title"`
+	Summary    string    `json:"summary" bson:"summary"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+
+	User *User  `json:"user" bson:"-"`
+	Uri  string `json:"uri" bson:"-"`
+}
+
+func (*TimelineEvent) CollectionName() string {
+	return "timeline_event"
+}
@@ -13,6 +13,8 @@ const (
 	FlagNormal
 	FlagAuditDelete
 	FlagUserDelete
+	FlagPending   // 待审核，审核通过前不展示
+	FlagScheduled // 定时发布，到发布时间前不展示
 )
 
 const (
@@ -23,7 +25,7 @@ const (
 const (
 	PermissionPublic = iota // 公开
 	PermissionLogin         // 登录可见
-	PermissionFollow        // 关注可见（暂未实现）
+	PermissionFollow        // 关注可见（关注了作者的用户可见）
 	PermissionPay           // 知识星球或其他方式付费可见
 	PermissionOnlyMe        // 自己可见
 )
@@ -41,11 +43,18 @@ type Topic struct {
 	EditorUid     int       `json:"editor_uid" bson:"editor_uid"`
 	Top           uint8     `json:"top" bson:"top"`
 	TopTime       int64     `json:"top_time" bson:"top_time"`
+	NodeTop       uint8     `json:"node_top" bson:"node_top"` // 节点内置顶（版主操作）
 	Tags          string    `json:"tags" bson:"tags"`
 	Permission    int       `json:"permission" bson:"permission"`
 	CloseReply    bool      `json:"close_reply" bson:"close_reply"`
+	Question      bool      `json:"question" bson:"question"`     // 问答模式，可以采纳一个回复为答案
+	AnswerCid     int       `json:"answer_cid" bson:"answer_cid"` // 被采纳的回复，0 表示未解决
 	Ctime         OftenTime `json:"ctime" bson:"ctime"`
 	Mtime         OftenTime `json:"mtime" bson:"mtime"`
+	// PublishAt 定时发布的时间，发布后清空
+	PublishAt time.Time `json:"publish_at,omitempty" bson:"publish_at,omitempty"`
+	// AtUsernames 延后发布（定时或待审核）时要 @ 的用户，发布时通知后清空
+	AtUsernames string `json:"-" bson:"at_usernames,omitempty"`
 
 	// 为了方便，加上Node（节点名称，数据表没有）
 	Node string `bson:"-"`
@@ -57,6 +66,16 @@ func (*Topic) CollectionName() string {
 	return "topics"
 }
 
+// Solved 问答模式的主题是否已采纳答案
+func (this *Topic) Solved() bool {
+	return this.Question && this.AnswerCid != 0
+}
+
+// Restricted 是否只对部分用户可见（关注可见、自己可见），这类主题不进入动态和搜索
+func (this *Topic) Restricted() bool {
+	return this.Permission == PermissionFollow || this.Permission == PermissionOnlyMe
+}
+
 func (this *Topic) BeforeInsert() {
 	if this.Tags == "" {
 		this.Tags = AutoTag(this.Title, this.Content, 4)
@@ -122,6 +141,7 @@ type TopicNode struct {
 	Seq       int       `json:"seq" bson:"seq"`
 	Intro     string    `json:"intro" bson:"intro"`
 	ShowIndex bool      `json:"show_index" bson:"show_index"`
+	Question  bool      `json:"question" bson:"question"` // 节点下的新主题都是问答模式
 	Ctime     time.Time `json:"ctime" bson:"ctime"`
 
 	Level int `json:"-" bson:"-"`
@@ -87,6 +87,8 @@ type User struct {
 	VipExpire   int       `json:"vip_expire" bson:"vip_expire"`
 	Status      int       `json:"status" bson:"status"`
 	IsRoot      bool      `json:"is_root" bson:"is_root"`
+	FollowNum   int       `json:"follow_num" bson:"follow_num"` // 关注数
+	FansNum     int       `json:"fans_num" bson:"fans_num"`     // 粉丝数
 	Ctime       OftenTime `json:"ctime" bson:"ctime"`
 	Mtime       time.Time `json:"mtime" bson:"mtime"`
 
@@ -177,18 +179,21 @@ type UserRole struct {
 const (
 	BindTypeGithub = iota
 	BindTypeGitea
+	BindTypeOAuth // 其他 OAuth2/OIDC 登录方式，具体见 Provider
 )
 
 type BindUser struct {
 	Id           int       `json:"id" bson:"_id"`
 	Uid          int       `json:"uid" bson:"uid"`
 	Type         int       `json:"type" bson:"type"`
+	Provider     string    `json:"provider" bson:"provider"` // 登录方式名称，对应配置中的 provider
+	Sub          string    `json:"-" bson:"sub"`             // 用户在第三方的唯一标识（OIDC 的 sub）
 	Email        string    `json:"email" bson:"email"`
 	Tuid         int       `json:"tuid" bson:"tuid"`
 	Username     string    `json:"username" bson:"username"`
 	Name         string    `json:"name" bson:"name"`
-	AccessToken  string    `json:"access_token" bson:"access_token"`
-	RefreshToken string    `json:"refresh_token" bson:"refresh_token"`
+	AccessToken  string    `json:"-" bson:"access_token"`
+	RefreshToken string    `json:"-" bson:"refresh_token"`
 	Expire       int       `json:"expire" bson:"expire"`
 	Avatar       string    `json:"avatar" bson:"avatar"`
 	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
@@ -0,0 +1,32 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// UserFollow 用户关注关系：Uid 关注了 FollowUid
+type UserFollow struct {
+	Id        int       `json:"id" bson:"_id"`
+	Uid       int       `json:"uid" bson:"uid"`
+	FollowUid int       `json:"follow_uid" bson:"follow_uid"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+func (*UserFollow) CollectionName() string {
+	return "user_follow"
+}
+
+// NodeFollow 节点关注关系：Uid 关注了节点 Nid，其下的新主题会出现在 Uid 的时间线中
+type NodeFollow struct {
+	Id        int       `json:"id" bson:"_id"`
+	Uid       int       `json:"uid" bson:"uid"`
+	Nid       int       `json:"nid" bson:"nid"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+func (*NodeFollow) CollectionName() string {
+	return "node_follow"
+}
@@ -9,26 +9,30 @@ package model
 import "time"
 
 var BalanceTypeMap = map[int]string{
-	MissionTypeLogin:    "每日登录奖励",
-	MissionTypeInitial:  "初始资本",
-	MissionTypeShare:    "分享获得",
-	MissionTypeAdd:      "充值获得",
-	MissionTypeReply:    "创建回复",
-	MissionTypeTopic:    "创建主题",
-	MissionTypeArticle:  "发表文章",
-	MissionTypeResource: "分享资源",
-	MissionTypeWiki:     "创建WIKI",
-	MissionTypeProject:  "发布项目",
-	MissionTypeBook:     "分享图书",
-	MissionTypeAppend:   "增加附言",
-	MissionTypeTop:      "置顶",
-	MissionTypeModify:   "修改",
-	MissionTypeReplied:  "回复收益",
-	MissionTypeAward:    "额外赠予",
-	MissionTypeActive:   "活跃奖励",
-	MissionTypeGift:     "兑换物品",
-	MissionTypePunish:   "处罚",
-	MissionTypeSpam:     "Spam",
+	MissionTypeLogin:        "每日登录奖励",
+	MissionTypeInitial:      "初始资本",
+	MissionTypeShare:        "分享获得",
+	MissionTypeAdd:          "充值获得",
+	MissionTypeReply:        "创建回复",
+	MissionTypeTopic:        "创建主题",
+	MissionTypeArticle:      "发表文章",
+	MissionTypeResource:     "分享资源",
+	MissionTypeWiki:         "创建WIKI",
+	MissionTypeProject:      "发布项目",
+	MissionTypeBook:         "分享图书",
+	MissionTypeAppend:       "增加附言",
+	MissionTypeTop:          "置顶",
+	MissionTypeModify:       "修改",
+	MissionTypeReplied:      "回复收益",
+	MissionTypeAnswer:       "回答被采纳",
+	MissionTypeBounty:       "悬赏",
+	MissionTypeBountyAward:  "悬赏收益",
+	MissionTypeBountyRefund: "悬赏退回",
+	MissionTypeAward:        "额外赠予",
+	MissionTypeActive:       "活跃奖励",
+	MissionTypeGift:         "兑换物品",
+	MissionTypePunish:       "处罚",
+	MissionTypeSpam:         "Spam",
 }
 
 type UserBalanceDetail struct {
@@ -0,0 +1,34 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// UserSession 服务端登录会话。cookie 中只保存会话 ID 明文，库中保存其 sha256
+type UserSession struct {
+	Id         int       `json:"id" bson:"_id"`
+	Uid        int       `json:"uid" bson:"uid"`
+	Username   string    `json:"username" bson:"username"`
+	SidHash    string    `json:"-" bson:"sid_hash"`
+	UserAgent  string    `json:"user_agent" bson:"user_agent"`
+	Ip         string    `json:"ip" bson:"ip"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at" bson:"last_seen_at"`
+	ExpireAt   time.Time `json:"expire_at" bson:"expire_at"`
+	Remember   bool      `json:"remember" bson:"remember"` // 记住登录状态；否则过期时间随访问顺延
+
+	// 是否是当前请求所用的会话，不入库
+	Current bool `json:"current" bson:"-"`
+}
+
+func (*UserSession) CollectionName() string {
+	return "user_session"
+}
+
+// Expired 会话是否已过期
+func (this *UserSession) Expired() bool {
+	return time.Now().After(this.ExpireAt)
+}
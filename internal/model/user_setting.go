@@ -10,9 +10,31 @@ import "time"
 
 const (
 	KeyNewUserWait     = "new_user_wait"    // 新用户注册多久才能发布帖子，单位秒，0表示没限制
+	KeyNewUserReview   = "new_user_review"  // 新用户注册多久内发布的主题和评论需要审核，单位秒，0表示不需要
 	KeyCanEditTime     = "can_edit_time"    // 发布后多久内能够编辑，单位秒
 	KeyPublishTimes    = "publish_times"    // 一天发布次数大于该值，需要验证码
 	KeyPublishInterval = "publish_interval" // 发布时间间隔在该值内，需要验证码，单位秒
+	KeyTotpForceAdmin  = "totp_force_admin" // 为 1 时，管理员（角色 ≤ Administrator）必须开启两步验证
+	KeyAnswerAward     = "answer_award"     // 回答被采纳奖励的铜币数，0表示不奖励
+
+	// 举报
+	KeyReportReviewNum = "report_review_num" // 同一内容被举报的（加权）次数达到该值，送审核队列，0表示不送
+	KeyReportHideNum   = "report_hide_num"   // 同一内容被举报的（加权）次数达到该值，自动隐藏并送审，0表示不隐藏
+
+	// 悬赏
+	KeyBountyMin        = "bounty_min"         // 悬赏的最少铜币数
+	KeyBountyMaxDays    = "bounty_max_days"    // 悬赏的最长期限，单位天
+	KeyBountyExpireRule = "bounty_expire_rule" // 到期未采纳时：0 退回给提问者；1 给最多赞的回答，没有时退回
+	KeyBountyMinLikes   = "bounty_min_likes"   // 到期给最多赞的回答时，回答至少要有的赞数
+
+	// 登录防暴力破解
+	KeyLoginFailWindow   = "login_fail_window"   // 登录失败次数的统计窗口，单位秒
+	KeyLoginCaptchaFails = "login_captcha_fails" // 窗口内失败次数达到该值，登录需要验证码
+	KeyLoginLockFails    = "login_lock_fails"    // 同一账号窗口内失败次数达到该值，临时锁定账号
+	KeyLoginIPLockFails  = "login_ip_lock_fails" // 同一 IP 窗口内失败次数达到该值，临时禁止该 IP 登录
+	KeyLoginLockTime     = "login_lock_time"     // 临时锁定的时长，单位秒
+	KeyLoginMaxDelay     = "login_max_delay"     // 连续失败后，两次尝试之间的最大等待时间，单位秒
+	KeyLoginTotpFails    = "login_totp_fails"    // 同一账号窗口内两步验证失败次数达到该值，临时锁定两步验证
 )
 
 type UserSetting struct {
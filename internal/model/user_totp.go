@@ -0,0 +1,23 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package model
+
+import "time"
+
+// UserTotp 用户的两步验证（TOTP，RFC 6238）信息，_id 即 uid
+type UserTotp struct {
+	Uid           int       `json:"uid" bson:"_id"`
+	Secret        string    `json:"-" bson:"secret"` // base32 编码的密钥
+	Enabled       bool      `json:"enabled" bson:"enabled"`
+	RecoveryCodes []string  `json:"-" bson:"recovery_codes"` // 恢复码的 sha256，用过即删除
+	LastStep      int64     `json:"-" bson:"last_step"`      // 最后一次通过验证的时间步，防止验证码重放
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
+	EnabledAt     time.Time `json:"enabled_at" bson:"enabled_at"`
+}
+
+func (*UserTotp) CollectionName() string {
+	return "user_totp"
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package util
+
+import "unicode"
+
+// NormalizeRunes 敏感词匹配前的归一化：全角转半角、转小写，并去掉空白、标点和符号，
+// 防止通过插入空格、标点或使用全角字符绕过。
+// 返回归一化后的字符，以及每个字符在原文（按 rune）中的位置
+func NormalizeRunes(text string) ([]rune, []int) {
+	src := []rune(text)
+	runes := make([]rune, 0, len(src))
+	positions := make([]int, 0, len(src))
+	for i, r := range src {
+		if r == 0x3000 {
+			r = ' '
+		} else if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+
+		runes = append(runes, unicode.ToLower(r))
+		positions = append(positions, i)
+	}
+	return runes, positions
+}
+
+// ACMatch 一次匹配：Index 是模式串的下标，Start、End 是在原文（按 rune）中的起止位置（包含 End）
+type ACMatch struct {
+	Index int
+	Start int
+	End   int
+}
+
+type acNode struct {
+	next    map[rune]int
+	fail    int
+	outputs []int
+}
+
+// ACMatcher Aho-Corasick 多模式匹配自动机。模式串和待匹配文本都会经过 NormalizeRunes 归一化。
+// 构建后只读，可以并发使用
+type ACMatcher struct {
+	nodes []*acNode
+	// 各模式串归一化后的长度
+	lens []int
+}
+
+// NewACMatcher 用 patterns 构建自动机，归一化后为空的模式串会被忽略
+func NewACMatcher(patterns []string) *ACMatcher {
+	m := &ACMatcher{
+		nodes: []*acNode{{next: map[rune]int{}}},
+		lens:  make([]int, len(patterns)),
+	}
+
+	for i, pattern := range patterns {
+		runes, _ := NormalizeRunes(pattern)
+		if len(runes) == 0 {
+			continue
+		}
+		m.lens[i] = len(runes)
+
+		cur := 0
+		for _, r := range runes {
+			next, ok := m.nodes[cur].next[r]
+			if !ok {
+				next = len(m.nodes)
+				m.nodes = append(m.nodes, &acNode{next: map[rune]int{}})
+				m.nodes[cur].next[r] = next
+			}
+			cur = next
+		}
+		m.nodes[cur].outputs = append(m.nodes[cur].outputs, i)
+	}
+
+	// BFS 构建失败指针，并把失败链上的输出合并进来
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		for r, child := range m.nodes[cur].next {
+			fail := m.nodes[cur].fail
+			for fail > 0 {
+				if _, ok := m.nodes[fail].next[r]; ok {
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			if next, ok := m.nodes[fail].next[r]; ok && next != child {
+				m.nodes[child].fail = next
+			}
+			m.nodes[child].outputs = append(m.nodes[child].outputs, m.nodes[m.nodes[child].fail].outputs...)
+			queue = append(queue, child)
+		}
+	}
+
+	return m
+}
+
+// Match 返回 text 中所有的匹配（可能重叠）
+func (m *ACMatcher) Match(text string) []ACMatch {
+	runes, positions := NormalizeRunes(text)
+
+	matches := make([]ACMatch, 0)
+	cur := 0
+	for i, r := range runes {
+		for cur > 0 {
+			if _, ok := m.nodes[cur].next[r]; ok {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		if next, ok := m.nodes[cur].next[r]; ok {
+			cur = next
+		}
+
+		for _, index := range m.nodes[cur].outputs {
+			length := m.lens[index]
+			matches = append(matches, ACMatch{
+				Index: index,
+				Start: positions[i-length+1],
+				End:   positions[i],
+			})
+		}
+	}
+
+	return matches
+}
+
+// Mask 把 matches 覆盖的原文字符替换为 *
+func Mask(text string, matches []ACMatch) string {
+	if len(matches) == 0 {
+		return text
+	}
+
+	runes := []rune(text)
+	for _, match := range matches {
+		for i := match.Start; i <= match.End && i < len(runes); i++ {
+			if !unicode.IsSpace(runes[i]) {
+				runes[i] = '*'
+			}
+		}
+	}
+	return string(runes)
+}
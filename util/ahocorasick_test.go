@@ -0,0 +1,64 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package util_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/studygolang/studygolang/util"
+)
+
+func TestACMatcherMatch(t *testing.T) {
+	matcher := util.NewACMatcher([]string{"发票", "he", "she", "hers", "Ｃasino"})
+
+	tests := []struct {
+		name string
+		text string
+		want []int
+	}{
+		{"没有命中", "正常的内容", []int{}},
+		{"直接命中", "代开发票", []int{0}},
+		{"插入空格和标点", "代开 发，票", []int{0}},
+		{"重叠命中", "ushers", []int{1, 2, 3}},
+		{"全角和大小写", "play ＣＡＳＩＮＯ now", []int{4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]int, 0)
+			for _, match := range matcher.Match(tt.text) {
+				got = append(got, match.Index)
+			}
+			sort.Ints(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Match(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Match(%q) = %v, want %v", tt.text, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	matcher := util.NewACMatcher([]string{"发票"})
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"代开发票", "代开**"},
+		{"代开发 票呀", "代开* *呀"},
+		{"没有", "没有"},
+	}
+	for _, tt := range tests {
+		if got := util.Mask(tt.text, matcher.Match(tt.text)); got != tt.want {
+			t.Errorf("Mask(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package util
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 差异片段的类型
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// 编辑距离超过该值时不再细算，直接认为全部删除后全部插入，避免大文本耗时过长
+const maxDiffEdits = 2000
+
+// DiffOp 一段差异，相邻的同类型片段已合并
+type DiffOp struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffLines 按行比较
+func DiffLines(a, b string) []DiffOp {
+	return Diff(SplitLines(a), SplitLines(b))
+}
+
+// DiffWords 按词比较：英文单词、数字为一个词，中文每个字为一个词
+func DiffWords(a, b string) []DiffOp {
+	return Diff(SplitWords(a), SplitWords(b))
+}
+
+// SplitLines 按行切分，每行保留行尾的换行符，拼接后和原文一致
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// SplitWords 按词切分：连续的字母数字、连续的空白各为一个词，其他字符（含汉字、标点）单独为一个词。
+// 拼接后和原文一致
+func SplitWords(s string) []string {
+	words := make([]string, 0, len(s)/4)
+
+	start, lastKind := 0, 0
+	for i, r := range s {
+		kind := wordKind(r)
+		if i > start && (kind != lastKind || kind == wordKindOther) {
+			words = append(words, s[start:i])
+			start = i
+		}
+		lastKind = kind
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+const (
+	wordKindOther = iota
+	wordKindAlnum
+	wordKindSpace
+)
+
+func wordKind(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return wordKindSpace
+	case unicode.Is(unicode.Han, r):
+		return wordKindOther
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+		return wordKindAlnum
+	}
+	return wordKindOther
+}
+
+// Diff 用 Myers 算法比较两组片段，得到从 a 到 b 的最短编辑序列
+func Diff(a, b []string) []DiffOp {
+	ops := make([]DiffOp, 0)
+
+	// 去掉相同的前缀和后缀，减少计算量
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops = appendDiffOp(ops, DiffEqual, a[:prefix]...)
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	ops = appendDiffOp(ops, DiffEqual, a[len(a)-suffix:]...)
+
+	return mergeDiffOps(ops)
+}
+
+// myers 返回未合并的编辑序列
+func myers(a, b []string) []DiffOp {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(a, b)
+	}
+
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d] 为第 d 步之前 v 在 [-d-1, d+1] 范围内的值，用于回溯
+	trace := make([][]int, 0)
+
+	found := false
+	for d := 0; d <= max && d <= maxDiffEdits; d++ {
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		return replaceAll(a, b)
+	}
+
+	// 回溯，得到倒序的编辑序列
+	reversed := make([]DiffOp, 0)
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		snapshot := trace[d]
+		at := func(k int) int { return snapshot[k+d+1] }
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, DiffOp{Op: DiffEqual, Text: a[x]})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, DiffOp{Op: DiffInsert, Text: b[y]})
+		} else {
+			x--
+			reversed = append(reversed, DiffOp{Op: DiffDelete, Text: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, DiffOp{Op: DiffEqual, Text: a[x]})
+	}
+
+	ops := make([]DiffOp, len(reversed))
+	for i, op := range reversed {
+		ops[len(reversed)-1-i] = op
+	}
+	return ops
+}
+
+func replaceAll(a, b []string) []DiffOp {
+	ops := appendDiffOp(nil, DiffDelete, a...)
+	return appendDiffOp(ops, DiffInsert, b...)
+}
+
+func appendDiffOp(ops []DiffOp, op string, texts ...string) []DiffOp {
+	for _, text := range texts {
+		ops = append(ops, DiffOp{Op: op, Text: text})
+	}
+	return ops
+}
+
+// mergeDiffOps 合并相邻的同类型片段；删除和插入交替出现时，先列出删除
+func mergeDiffOps(ops []DiffOp) []DiffOp {
+	merged := make([]DiffOp, 0, len(ops))
+
+	var del, ins strings.Builder
+	flush := func() {
+		if del.Len() > 0 {
+			merged = append(merged, DiffOp{Op: DiffDelete, Text: del.String()})
+			del.Reset()
+		}
+		if ins.Len() > 0 {
+			merged = append(merged, DiffOp{Op: DiffInsert, Text: ins.String()})
+			ins.Reset()
+		}
+	}
+
+	for _, op := range ops {
+		switch op.Op {
+		case DiffDelete:
+			del.WriteString(op.Text)
+		case DiffInsert:
+			ins.WriteString(op.Text)
+		default:
+			flush()
+			if last := len(merged) - 1; last >= 0 && merged[last].Op == DiffEqual {
+				merged[last].Text += op.Text
+			} else {
+				merged = append(merged, op)
+			}
+		}
+	}
+	flush()
+
+	return merged
+}
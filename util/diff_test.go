@@ -0,0 +1,69 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package util_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/studygolang/studygolang/util"
+)
+
+func TestDiffLines(t *testing.T) {
+	a := "line1\nline2\nline3\nline4\n"
+	b := "line1\nline2 changed\nline3\nline5\n"
+
+	want := []util.DiffOp{
+		{Op: util.DiffEqual, Text: "line1\n"},
+		{Op: util.DiffDelete, Text: "line2\n"},
+		{Op: util.DiffInsert, Text: "line2 changed\n"},
+		{Op: util.DiffEqual, Text: "line3\n"},
+		{Op: util.DiffDelete, Text: "line4\n"},
+		{Op: util.DiffInsert, Text: "line5\n"},
+	}
+	if got := util.DiffLines(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffLines() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffWords(t *testing.T) {
+	want := []util.DiffOp{
+		{Op: util.DiffEqual, Text: "Go 语言"},
+		{Op: util.DiffDelete, Text: "很"},
+		{Op: util.DiffInsert, Text: "非常"},
+		{Op: util.DiffEqual, Text: "好 "},
+		{Op: util.DiffDelete, Text: "hello"},
+		{Op: util.DiffInsert, Text: "world"},
+	}
+	if got := util.DiffWords("Go 语言很好 hello", "Go 语言非常好 world"); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffWords() = %+v, want %+v", got, want)
+	}
+}
+
+// 删除的片段拼起来是 a，插入的片段拼起来是 b
+func TestDiffRestore(t *testing.T) {
+	pairs := [][2]string{
+		{"", "abc"},
+		{"abc", ""},
+		{"a b c d e", "a c e f"},
+		{"x\ny\nz", "z\ny\nx"},
+	}
+	for _, pair := range pairs {
+		var a, b strings.Builder
+		for _, op := range util.DiffWords(pair[0], pair[1]) {
+			if op.Op != util.DiffInsert {
+				a.WriteString(op.Text)
+			}
+			if op.Op != util.DiffDelete {
+				b.WriteString(op.Text)
+			}
+		}
+		if a.String() != pair[0] || b.String() != pair[1] {
+			t.Errorf("DiffWords(%q, %q) restores %q, %q", pair[0], pair[1], a.String(), b.String())
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package util
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// ParsePrefix 解析 IP 或 CIDR 网段（IPv4/IPv6），单个 IP 视为 /32 或 /128。
+// 返回的网段已去掉主机位，IPv4 映射的 IPv6 地址转换为 IPv4
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap().WithZone("")
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr, bits := prefix.Addr(), prefix.Bits()
+	if addr.Is4In6() {
+		addr = addr.Unmap()
+		bits -= 96
+		if bits < 0 {
+			bits = 0
+		}
+	}
+	return netip.PrefixFrom(addr, bits).Masked(), nil
+}
+
+// IPSet IP 网段集合。按前缀长度分组存放，查找时从最长的前缀开始，每个长度查一次 map，
+// 次数只和出现过的前缀长度种类有关，和网段数量无关
+type IPSet struct {
+	prefixes map[netip.Prefix]int
+	bits4    []int
+	bits6    []int
+}
+
+func NewIPSet() *IPSet {
+	return &IPSet{prefixes: make(map[netip.Prefix]int)}
+}
+
+// Add 加入网段，val 为调用方的数据（如下标），同一网段重复加入时覆盖
+func (s *IPSet) Add(prefix netip.Prefix, val int) {
+	prefix = prefix.Masked()
+	s.prefixes[prefix] = val
+
+	if prefix.Addr().Is4() {
+		s.bits4 = insertBits(s.bits4, prefix.Bits())
+	} else {
+		s.bits6 = insertBits(s.bits6, prefix.Bits())
+	}
+}
+
+// Lookup 查找包含 ip 的最长（最精确的）网段，返回其 val
+func (s *IPSet) Lookup(ip string) (int, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return 0, false
+	}
+	addr = addr.Unmap().WithZone("")
+
+	bitsList := s.bits6
+	if addr.Is4() {
+		bitsList = s.bits4
+	}
+	for _, bits := range bitsList {
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if val, ok := s.prefixes[prefix]; ok {
+			return val, true
+		}
+	}
+	return 0, false
+}
+
+// Len 网段数量
+func (s *IPSet) Len() int {
+	return len(s.prefixes)
+}
+
+// insertBits 按降序插入，已存在时不重复
+func insertBits(bitsList []int, bits int) []int {
+	i := sort.Search(len(bitsList), func(i int) bool { return bitsList[i] <= bits })
+	if i < len(bitsList) && bitsList[i] == bits {
+		return bitsList
+	}
+	bitsList = append(bitsList, 0)
+	copy(bitsList[i+1:], bitsList[i:])
+	bitsList[i] = bits
+	return bitsList
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/studygolang/studygolang/util"
+)
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"1.2.3.4", "1.2.3.4/32", false},
+		{"1.2.3.4/24", "1.2.3.0/24", false},
+		{"::ffff:1.2.3.4", "1.2.3.4/32", false},
+		{"::ffff:1.2.3.4/120", "1.2.3.0/24", false},
+		{"2001:DB8::1", "2001:db8::1/128", false},
+		{"2001:db8::1/32", "2001:db8::/32", false},
+		{"1.2.3", "", true},
+		{"1.2.3.4/33", "", true},
+	}
+	for _, tt := range tests {
+		got, err := util.ParsePrefix(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePrefix(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("ParsePrefix(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIPSetLookup(t *testing.T) {
+	set := util.NewIPSet()
+	for i, s := range []string{"10.0.0.0/8", "10.1.0.0/16", "192.168.1.10", "2001:db8::/32"} {
+		prefix, err := util.ParsePrefix(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set.Add(prefix, i)
+	}
+
+	tests := []struct {
+		ip     string
+		want   int
+		wantOk bool
+	}{
+		{"10.2.3.4", 0, true},
+		{"10.1.3.4", 1, true}, // 最精确的网段优先
+		{"192.168.1.10", 2, true},
+		{"::ffff:192.168.1.10", 2, true},
+		{"192.168.1.11", 0, false},
+		{"2001:db8:1::1", 3, true},
+		{"2001:db9::1", 0, false},
+		{"invalid", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := set.Lookup(tt.ip)
+		if ok != tt.wantOk || got != tt.want {
+			t.Errorf("Lookup(%q) = %d, %v, want %d, %v", tt.ip, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package util
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// 允许的标签及其允许的属性，其他标签去掉（保留内容），其他属性一律去掉
+var sanitizeTags = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": nil,
+	"br":         nil,
+	"code":       {"class"},
+	"dd":         nil,
+	"del":        nil,
+	"details":    nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"h1":         {"id"},
+	"h2":         {"id"},
+	"h3":         {"id"},
+	"h4":         {"id"},
+	"h5":         {"id"},
+	"h6":         {"id"},
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"input":      {"type", "checked", "disabled"},
+	"kbd":        nil,
+	"li":         {"class"},
+	"ol":         {"start"},
+	"p":          nil,
+	"pre":        {"class"},
+	"s":          nil,
+	"span":       {"class"},
+	"strong":     nil,
+	"sub":        nil,
+	"summary":    nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"align", "style"},
+	"th":         {"align", "style"},
+	"thead":      nil,
+	"tr":         nil,
+	"ul":         {"class"},
+}
+
+// 连同内容一起去掉的标签
+var sanitizeDropTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true,
+	"object": true, "embed": true, "applet": true, "noscript": true, "noembed": true,
+	"template": true, "textarea": true, "select": true, "svg": true, "math": true,
+	"head": true, "title": true, "xmp": true, "plaintext": true,
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true, "input": true}
+
+// SanitizeHTML 按白名单过滤 HTML：只保留常见的排版标签和安全的属性，
+// 链接只允许 http、https、mailto 和相对地址，并补齐未闭合的标签
+func SanitizeHTML(s string) string {
+	var (
+		buf   strings.Builder
+		stack []string
+		// 在需要丢弃内容的标签中的层数
+		drop int
+	)
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			for i := len(stack) - 1; i >= 0; i-- {
+				buf.WriteString("</" + stack[i] + ">")
+			}
+			return buf.String()
+		case html.TextToken:
+			if drop == 0 {
+				buf.WriteString(html.EscapeString(string(z.Text())))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			name := token.Data
+			if sanitizeDropTags[name] {
+				if tt == html.StartTagToken {
+					drop++
+				}
+				continue
+			}
+			allowed, ok := sanitizeTags[name]
+			if drop > 0 || !ok {
+				continue
+			}
+			attrs, ok := sanitizeAttrs(name, token.Attr, allowed)
+			if !ok {
+				continue
+			}
+
+			buf.WriteString("<" + name + attrs + ">")
+			if tt == html.StartTagToken && !voidTags[name] {
+				stack = append(stack, name)
+			}
+		case html.EndTagToken:
+			name := z.Token().Data
+			if sanitizeDropTags[name] {
+				if drop > 0 {
+					drop--
+				}
+				continue
+			}
+			if drop > 0 {
+				continue
+			}
+			// 关闭最近的同名标签，中间未闭合的一起关闭；没有打开过的结束标签丢掉
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] != name {
+					continue
+				}
+				for j := len(stack) - 1; j >= i; j-- {
+					buf.WriteString("</" + stack[j] + ">")
+				}
+				stack = stack[:i]
+				break
+			}
+		}
+	}
+}
+
+// 文本中的匹配不替换为链接的标签
+var linkifySkipTags = map[string]bool{"a": true, "code": true, "pre": true}
+
+// LinkifyText 在 HTML 的文本中（不在链接、代码中）查找 re 的匹配，替换为 link 返回的 HTML。
+// link 的参数是未转义的子匹配，返回空字符串表示不替换；返回值原样输出，由调用方保证安全
+func LinkifyText(s string, re *regexp.Regexp, link func(match []string) string) string {
+	var (
+		buf  strings.Builder
+		skip int
+	)
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return buf.String()
+		case html.TextToken:
+			if skip > 0 {
+				buf.Write(z.Raw())
+				continue
+			}
+			text := string(z.Text())
+			last := 0
+			for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+				match := make([]string, len(loc)/2)
+				for i := range match {
+					if loc[2*i] >= 0 {
+						match[i] = text[loc[2*i]:loc[2*i+1]]
+					}
+				}
+				replaced := link(match)
+				if replaced == "" {
+					continue
+				}
+				buf.WriteString(html.EscapeString(text[last:loc[0]]))
+				buf.WriteString(replaced)
+				last = loc[1]
+			}
+			buf.WriteString(html.EscapeString(text[last:]))
+		case html.StartTagToken:
+			buf.Write(z.Raw())
+			if name, _ := z.TagName(); linkifySkipTags[string(name)] {
+				skip++
+			}
+		case html.EndTagToken:
+			buf.Write(z.Raw())
+			if name, _ := z.TagName(); linkifySkipTags[string(name)] && skip > 0 {
+				skip--
+			}
+		default:
+			buf.Write(z.Raw())
+		}
+	}
+}
+
+// sanitizeAttrs 过滤属性，返回拼好的属性串。返回 false 表示整个标签都不保留
+func sanitizeAttrs(tag string, attrs []html.Attribute, allowed []string) (string, bool) {
+	var buf strings.Builder
+	external := false
+	for _, attr := range attrs {
+		if !inStrings(allowed, attr.Key) {
+			continue
+		}
+
+		key, val := attr.Key, attr.Val
+		switch key {
+		case "href", "src":
+			var ok bool
+			if val, ok = safeURL(val); !ok {
+				continue
+			}
+			lower := strings.ToLower(val)
+			external = strings.HasPrefix(lower, "http") || strings.HasPrefix(lower, "//")
+		case "id", "class":
+			if val = safeName(val, key == "class"); val == "" {
+				continue
+			}
+		case "style":
+			// 只保留表格的对齐方式，转换为 align
+			key, val = "align", strings.TrimSpace(strings.TrimPrefix(strings.ReplaceAll(val, " ", ""), "text-align:"))
+			val = strings.TrimSuffix(val, ";")
+			fallthrough
+		case "align":
+			if val != "left" && val != "center" && val != "right" {
+				continue
+			}
+		case "width", "height", "start":
+			if val == "" || strings.Trim(val, "0123456789") != "" {
+				continue
+			}
+		case "type":
+			if val != "checkbox" {
+				continue
+			}
+		case "checked", "disabled":
+			buf.WriteString(" " + key)
+			continue
+		}
+		buf.WriteString(" " + key + `="` + html.EscapeString(val) + `"`)
+	}
+
+	switch tag {
+	case "a":
+		if external {
+			buf.WriteString(` rel="nofollow noopener"`)
+		}
+	case "input":
+		// 只保留任务列表的复选框
+		if !strings.Contains(buf.String(), `type="checkbox"`) {
+			return "", false
+		}
+	}
+	return buf.String(), true
+}
+
+// safeURL 只允许 http、https、mailto 和相对地址
+func safeURL(val string) (string, bool) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return "", false
+	}
+	u, err := url.Parse(val)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return val, true
+	}
+	return "", false
+}
+
+// safeName 过滤 id、class：只保留字母、数字、-、_，class 可以有多个（空格分隔）
+func safeName(val string, multi bool) string {
+	names := []string{val}
+	if multi {
+		names = strings.Fields(val)
+	}
+
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		valid := name != ""
+		for _, r := range name {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			result = append(result, name)
+		}
+	}
+	return strings.Join(result, " ")
+}
+
+func inStrings(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package util_test
+
+import (
+	"html"
+	"regexp"
+	"testing"
+
+	"github.com/studygolang/studygolang/util"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`<p>Hello <strong>Go</strong></p>`, `<p>Hello <strong>Go</strong></p>`},
+		{`<p onclick="alert(1)">x</p>`, `<p>x</p>`},
+		{`<script>alert(1)</script><p>ok</p>`, `<p>ok</p>`},
+		{`<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="jav&#x09;ascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="/topics/1" title="t">x</a>`, `<a href="/topics/1" title="t">x</a>`},
+		{`<a href="https://golang.org">go</a>`, `<a href="https://golang.org" rel="nofollow noopener">go</a>`},
+		{`<img src="data:image/png;base64,xx" onerror="alert(1)">`, `<img>`},
+		{`<pre><code class="language-go">a &lt; b</code></pre>`, `<pre><code class="language-go">a &lt; b</code></pre>`},
+		{`<h2 id="安装-go">安装 Go</h2>`, `<h2 id="安装-go">安装 Go</h2>`},
+		{`<h2 id="a&quot;b">x</h2>`, `<h2>x</h2>`},
+		{`<li><input type="checkbox" checked disabled> done</li>`, `<li><input type="checkbox" checked disabled> done</li>`},
+		{`<input type="text" value="x">`, ``},
+		{`<div><em>unclosed`, `<em>unclosed</em>`},
+		{`</b>text<iframe src="x"><p>in</p></iframe>`, `text`},
+		{`<!-- comment -->&lt;tag&gt;`, `&lt;tag&gt;`},
+		{`<td align="center" onclick="x">x</td>`, `<td align="center">x</td>`},
+		{`<th style="text-align:right">x</th><td style="color:red">y</td>`, `<th align="right">x</th><td>y</td>`},
+	}
+	for _, tt := range tests {
+		if got := util.SanitizeHTML(tt.in); got != tt.want {
+			t.Errorf("SanitizeHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLinkifyText(t *testing.T) {
+	re := regexp.MustCompile(`@([a-z]+)`)
+	link := func(match []string) string {
+		if match[1] == "nobody" {
+			return ""
+		}
+		return `<a href="/user/` + match[1] + `">` + html.EscapeString(match[0]) + `</a>`
+	}
+
+	tests := []struct {
+		in, want string
+	}{
+		{`<p>hi @polaris</p>`, `<p>hi <a href="/user/polaris">@polaris</a></p>`},
+		{`<p>@nobody &amp; @go</p>`, `<p>@nobody &amp; <a href="/user/go">@go</a></p>`},
+		{`<p><code>@polaris</code></p>`, `<p><code>@polaris</code></p>`},
+		{`<pre><code>a @b</code></pre>@c`, `<pre><code>a @b</code></pre><a href="/user/c">@c</a>`},
+		{`<a href="/x">@polaris</a> @go`, `<a href="/x">@polaris</a> <a href="/user/go">@go</a>`},
+		{`<p>&lt;@polaris&gt;</p>`, `<p>&lt;<a href="/user/polaris">@polaris</a>&gt;</p>`},
+	}
+	for _, tt := range tests {
+		if got := util.LinkifyText(tt.in, re, link); got != tt.want {
+			t.Errorf("LinkifyText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package util
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TokenBucket 令牌桶：桶容量为 Burst，每 Period 匀速补满。
+// 只负责计算，桶的状态（剩余令牌数和上次更新时间）由调用方保存
+type TokenBucket struct {
+	Burst  int
+	Period time.Duration
+}
+
+// ParseTokenBucket 解析形如 "10/1m" 的配置：1 分钟最多 10 次
+func ParseTokenBucket(s string) (TokenBucket, error) {
+	bucket := TokenBucket{}
+
+	parts := strings.SplitN(strings.TrimSpace(s), "/", 2)
+	if len(parts) != 2 {
+		return bucket, errors.New("invalid token bucket: " + s)
+	}
+
+	burst, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || burst <= 0 {
+		return bucket, errors.New("invalid token bucket burst: " + s)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || period <= 0 {
+		return bucket, errors.New("invalid token bucket period: " + s)
+	}
+
+	bucket.Burst = burst
+	bucket.Period = period
+	return bucket, nil
+}
+
+// Refill 按流逝的时间补充令牌，last 为零值表示新桶（满的）
+func (b TokenBucket) Refill(tokens float64, last, now time.Time) float64 {
+	if last.IsZero() {
+		return float64(b.Burst)
+	}
+
+	elapsed := now.Sub(last)
+	if elapsed <= 0 {
+		return tokens
+	}
+	tokens += float64(b.Burst) * float64(elapsed) / float64(b.Period)
+	return math.Min(tokens, float64(b.Burst))
+}
+
+// Take 取一个令牌，返回取之后剩余的令牌数和是否取到
+func (b TokenBucket) Take(tokens float64, last, now time.Time) (float64, bool) {
+	tokens = b.Refill(tokens, last, now)
+	if tokens < 1 {
+		return tokens, false
+	}
+	return tokens - 1, true
+}
+
+// Wait 剩余 tokens 个令牌时，还要等多久才有一个令牌
+func (b TokenBucket) Wait(tokens float64) time.Duration {
+	if tokens >= 1 {
+		return 0
+	}
+	return b.refillTime(1 - tokens)
+}
+
+// FullAfter 剩余 tokens 个令牌时，多久之后桶会补满
+func (b TokenBucket) FullAfter(tokens float64) time.Duration {
+	if tokens >= float64(b.Burst) {
+		return 0
+	}
+	return b.refillTime(float64(b.Burst) - tokens)
+}
+
+func (b TokenBucket) refillTime(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens * float64(b.Period) / float64(b.Burst)))
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 The StudyGolang Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+// http://studygolang.com
+
+package util_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/studygolang/studygolang/util"
+)
+
+func TestParseTokenBucket(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    util.TokenBucket
+		wantErr bool
+	}{
+		{"10/1m", util.TokenBucket{Burst: 10, Period: time.Minute}, false},
+		{" 5 / 30s ", util.TokenBucket{Burst: 5, Period: 30 * time.Second}, false},
+		{"10", util.TokenBucket{}, true},
+		{"0/1m", util.TokenBucket{}, true},
+		{"10/abc", util.TokenBucket{}, true},
+		{"10/-1m", util.TokenBucket{}, true},
+	}
+	for _, tt := range tests {
+		got, err := util.ParseTokenBucket(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTokenBucket(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTokenBucket(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTokenBucketTake(t *testing.T) {
+	bucket := util.TokenBucket{Burst: 3, Period: 3 * time.Second}
+	now := time.Now()
+
+	// 新桶是满的，可以连续取 Burst 次
+	tokens, last := 0.0, time.Time{}
+	for i := 0; i < 3; i++ {
+		var ok bool
+		tokens, ok = bucket.Take(tokens, last, now)
+		if !ok {
+			t.Fatalf("take %d: want ok", i+1)
+		}
+		last = now
+	}
+
+	if _, ok := bucket.Take(tokens, last, now); ok {
+		t.Fatal("take from empty bucket: want not ok")
+	}
+	if wait := bucket.Wait(tokens); wait != time.Second {
+		t.Errorf("Wait() = %v, want 1s", wait)
+	}
+	if full := bucket.FullAfter(tokens); full != 3*time.Second {
+		t.Errorf("FullAfter() = %v, want 3s", full)
+	}
+
+	// 1 秒补充 1 个
+	later := now.Add(time.Second)
+	if _, ok := bucket.Take(tokens, last, later); !ok {
+		t.Error("take after refill: want ok")
+	}
+
+	// 补充不超过容量
+	if got := bucket.Refill(tokens, last, now.Add(time.Hour)); got != 3 {
+		t.Errorf("Refill() = %v, want 3", got)
+	}
+}